@@ -0,0 +1,60 @@
+package billing
+
+import (
+	"context"
+	"doubleboiler/config"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Provider is whoever takes the money. The app keeps its own record of each organisation's
+// subscription and the provider tells it about changes, like failed payments, through a webhook.
+type Provider interface {
+	CreateCustomer(ctx context.Context, organisationID, email string) (string, error)
+	Subscribe(ctx context.Context, customerID, planID string) (Subscription, error)
+	Cancel(ctx context.Context, subscriptionID string) error
+	ParseWebhook(r *http.Request) (Event, error)
+}
+
+// Subscription is the provider's side of a subscription.
+type Subscription struct {
+	ID        string
+	Status    string
+	PeriodEnd time.Time
+}
+
+// Event is a change to a subscription reported by the provider. Providers retry and don't
+// promise to deliver in order, so each event carries its own ID and the time it happened.
+type Event struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	Status         string    `json:"status"`
+	PeriodEnd      time.Time `json:"period_end"`
+	OccurredAt     time.Time `json:"occurred_at"`
+}
+
+const (
+	StatusTrialing  = "trialing"
+	StatusActive    = "active"
+	StatusPastDue   = "past_due"
+	StatusCancelled = "cancelled"
+)
+
+var ValidStatuses = []string{StatusTrialing, StatusActive, StatusPastDue, StatusCancelled}
+
+var ErrInvalidSignature = errors.New("Invalid billing webhook signature")
+
+var Default Provider
+
+func init() {
+	switch config.BILLING_PROVIDER {
+	case "fake":
+		Default = Fake{}
+	case "none":
+		Default = None{}
+	default:
+		log.Fatalf("Unknown billing provider %s", config.BILLING_PROVIDER)
+	}
+}
@@ -0,0 +1,50 @@
+package billing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeParseWebhook(t *testing.T) {
+	body, err := json.Marshal(Event{
+		ID:             "fake_evt_123",
+		SubscriptionID: "fake_sub_123",
+		Status:         StatusPastDue,
+		PeriodEnd:      time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest("POST", "/webhooks/billing", bytes.NewReader(body))
+	req.Header.Set(SignatureHeader, Sign(body))
+
+	event, err := Fake{}.ParseWebhook(req)
+	assert.Nil(t, err)
+	assert.Equal(t, "fake_evt_123", event.ID)
+	assert.Equal(t, "fake_sub_123", event.SubscriptionID)
+	assert.Equal(t, StatusPastDue, event.Status)
+	assert.True(t, event.PeriodEnd.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	tampered := httptest.NewRequest("POST", "/webhooks/billing", bytes.NewReader(bytes.Replace(body, []byte("past_due"), []byte("active"), 1)))
+	tampered.Header.Set(SignatureHeader, Sign(body))
+
+	_, err = Fake{}.ParseWebhook(tampered)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	unsigned := httptest.NewRequest("POST", "/webhooks/billing", bytes.NewReader(body))
+	_, err = Fake{}.ParseWebhook(unsigned)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestFakeSubscribe(t *testing.T) {
+	sub, err := Fake{}.Subscribe(context.Background(), "fake_cus_123", "team")
+	assert.Nil(t, err)
+	assert.NotEqual(t, "", sub.ID)
+	assert.Equal(t, StatusActive, sub.Status)
+	assert.True(t, sub.PeriodEnd.After(time.Now()))
+}
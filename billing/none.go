@@ -0,0 +1,29 @@
+package billing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// None is for running without a provider. Organisations can stay on, or move to, the free plan,
+// but nothing can be charged for and no webhooks are accepted.
+type None struct{}
+
+var ErrBillingDisabled = errors.New("Paid plans aren't available")
+
+func (None) CreateCustomer(ctx context.Context, organisationID, email string) (string, error) {
+	return "", ErrBillingDisabled
+}
+
+func (None) Subscribe(ctx context.Context, customerID, planID string) (Subscription, error) {
+	return Subscription{}, ErrBillingDisabled
+}
+
+func (None) Cancel(ctx context.Context, subscriptionID string) error {
+	return nil
+}
+
+func (None) ParseWebhook(r *http.Request) (Event, error) {
+	return Event{}, ErrBillingDisabled
+}
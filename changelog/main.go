@@ -1,8 +1,30 @@
 package changelog
 
-import "time"
+import (
+	"bufio"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Entries are Markdown with a little front matter giving the title and date, like:
+//
+//	---
+//	title: Hello World
+//	date: 2020-02-10T01:18:18+00:00
+//	---
+//	Welcome to DoubleBoiler
+//
+//go:embed entries/*.md
+var entries embed.FS
 
 type Change struct {
+	// Slug is the entry's filename, which stays the same however the entry is edited.
+	Slug  string
 	Date  time.Time
 	Title string
 	Body  string
@@ -10,6 +32,97 @@ type Change struct {
 
 var Changes changeList
 
+func init() {
+	changes, err := load(entries)
+	if err != nil {
+		panic(err)
+	}
+	Changes = changes
+}
+
+func load(fsys fs.FS) (changeList, error) {
+	files, err := fs.Glob(fsys, "entries/*.md")
+	if err != nil {
+		return nil, err
+	}
+
+	changes := changeList{}
+	for _, file := range files {
+		raw, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		change, err := parse(strings.TrimSuffix(path.Base(file), ".md"), string(raw))
+		if err != nil {
+			return nil, fmt.Errorf("changelog entry %s: %w", file, err)
+		}
+		changes = append(changes, change)
+	}
+
+	sort.Sort(changes)
+	return changes, nil
+}
+
+func parse(slug, raw string) (Change, error) {
+	change := Change{Slug: slug}
+
+	scanner := bufio.NewScanner(strings.NewReader(raw))
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) != "---" {
+		return change, fmt.Errorf("missing front matter")
+	}
+
+	closed := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "---" {
+			closed = true
+			break
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return change, fmt.Errorf("invalid front matter line %q", line)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "title":
+			change.Title = value
+		case "date":
+			date, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return change, err
+			}
+			change.Date = date
+		default:
+			return change, fmt.Errorf("unknown front matter %q", key)
+		}
+	}
+	if !closed {
+		return change, fmt.Errorf("unterminated front matter")
+	}
+	if change.Title == "" || change.Date.IsZero() {
+		return change, fmt.Errorf("a title and date are required")
+	}
+
+	body := []string{}
+	for scanner.Scan() {
+		body = append(body, scanner.Text())
+	}
+	change.Body = strings.TrimSpace(strings.Join(body, "\n"))
+
+	return change, scanner.Err()
+}
+
+// Since is the changes made after the time, newest first.
+func Since(t time.Time) []Change {
+	ret := []Change{}
+	for _, change := range Changes {
+		if change.Date.After(t) {
+			ret = append(ret, change)
+		}
+	}
+	return ret
+}
+
 type changeList []Change
 
 func (s changeList) Len() int {
@@ -0,0 +1,49 @@
+package changelog
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntriesParse(t *testing.T) {
+	assert.NotEmpty(t, Changes)
+	for _, change := range Changes {
+		assert.NotEmpty(t, change.Slug)
+		assert.NotEmpty(t, change.Title)
+		assert.NotEmpty(t, change.Body)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	changes, err := load(fstest.MapFS{
+		"entries/older.md": {Data: []byte("---\ntitle: Older\ndate: 2020-02-10T01:18:18+00:00\n---\nFirst\n\nSecond\n")},
+		"entries/newer.md": {Data: []byte("---\ntitle: Newer\ndate: 2021-02-10T01:18:18+00:00\n---\nHello\n")},
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, "newer", changes[0].Slug)
+	assert.Equal(t, "Older", changes[1].Title)
+	assert.Equal(t, "First\n\nSecond", changes[1].Body)
+	assert.Equal(t, time.Date(2020, 2, 10, 1, 18, 18, 0, time.UTC), changes[1].Date.UTC())
+}
+
+func TestLoadRejectsBadEntries(t *testing.T) {
+	for name, raw := range map[string]string{
+		"no front matter": "Hello",
+		"unterminated":    "---\ntitle: Hello\n",
+		"bad date":        "---\ntitle: Hello\ndate: yesterday\n---\nHello",
+		"no title":        "---\ndate: 2020-02-10T01:18:18+00:00\n---\nHello",
+		"unknown key":     "---\ntitle: Hello\nauthor: me\ndate: 2020-02-10T01:18:18+00:00\n---\nHello",
+	} {
+		_, err := load(fstest.MapFS{"entries/bad.md": {Data: []byte(raw)}})
+		assert.NotNil(t, err, name)
+	}
+}
+
+func TestSince(t *testing.T) {
+	assert.Equal(t, len(Changes), len(Since(time.Time{})))
+	assert.Empty(t, Since(Changes[0].Date))
+}
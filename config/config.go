@@ -45,8 +45,18 @@ var GOOGLE_PROJECT_ID string
 var RECAPTCHA_SITE_KEY string
 var SAMPLEORG_ID string
 var START_WORKERS bool
+var STORAGE_BACKEND string
+var LOCAL_STORAGE_DIR string
+var ATTACHMENT_QUOTA_BYTES int64
+var MEMBER_QUOTA int
+var SOME_THING_QUOTA int
+var EMAIL_QUOTA_PER_DAY int
+var BILLING_PROVIDER string
 
 var SEND_EMAIL_QUEUE_NAME string
+var DATA_EXPORT_QUEUE_NAME string
+var ORGANISATION_DELETION_QUEUE_NAME string
+var FILE_DELETION_QUEUE_NAME string
 
 var MAX_TIME, _ = time.Parse(time.RFC3339, "9999-05-05T15:04:05Z")
 var MIN_TIME = time.Unix(0, 0)
@@ -87,6 +97,13 @@ func init() {
 		"GOOGLE_PROJECT_ID":     "",
 		"SAMPLEORG_ID":          "3f815ebd-2eb7-4dae-be2d-460c726438e2",
 		"START_WORKERS":         "",
+		"STORAGE_BACKEND":       "gcs",
+		"LOCAL_STORAGE_DIR":     "./files",
+		"ATTACHMENT_QUOTA":      "1073741824",
+		"MEMBER_QUOTA":          "1000",
+		"SOME_THING_QUOTA":      "100000",
+		"EMAIL_QUOTA":           "5000",
+		"BILLING_PROVIDER":      "fake",
 	})
 
 	PORT = os.Getenv("PORT")
@@ -142,9 +159,15 @@ func init() {
 	STAGE = os.Getenv("STAGE")
 
 	SEND_EMAIL_QUEUE_NAME = fmt.Sprintf(queueNameTemplate, STAGE, "send_email")
+	DATA_EXPORT_QUEUE_NAME = fmt.Sprintf(queueNameTemplate, STAGE, "data_export")
+	ORGANISATION_DELETION_QUEUE_NAME = fmt.Sprintf(queueNameTemplate, STAGE, "organisation_deletion")
+	FILE_DELETION_QUEUE_NAME = fmt.Sprintf(queueNameTemplate, STAGE, "file_deletion")
 
 	allQueues := []string{
 		SEND_EMAIL_QUEUE_NAME,
+		DATA_EXPORT_QUEUE_NAME,
+		ORGANISATION_DELETION_QUEUE_NAME,
+		FILE_DELETION_QUEUE_NAME,
 	}
 
 	QUEUE.AddPublishMiddleware(func(ctx context.Context, t *kewpie.Task, queueName string) error {
@@ -195,6 +218,34 @@ func init() {
 
 	GOOGLE_PROJECT_ID = os.Getenv("GOOGLE_PROJECT_ID")
 
+	STORAGE_BACKEND = os.Getenv("STORAGE_BACKEND")
+	LOCAL_STORAGE_DIR = os.Getenv("LOCAL_STORAGE_DIR")
+
+	ATTACHMENT_QUOTA_BYTES, err = strconv.ParseInt(os.Getenv("ATTACHMENT_QUOTA"), 10, 64)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	MEMBER_QUOTA, err = strconv.Atoi(os.Getenv("MEMBER_QUOTA"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	SOME_THING_QUOTA, err = strconv.Atoi(os.Getenv("SOME_THING_QUOTA"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	EMAIL_QUOTA_PER_DAY, err = strconv.Atoi(os.Getenv("EMAIL_QUOTA"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	BILLING_PROVIDER = os.Getenv("BILLING_PROVIDER")
+	if BILLING_PROVIDER == "fake" && STAGE == "production" {
+		log.Fatal("The fake billing provider can't be used in production. Set BILLING_PROVIDER to none to run without billing")
+	}
+
 	RECAPTCHA_SITE_KEY = os.Getenv("RECAPTCHA_SITE_KEY")
 
 	AntiSpam = recaptcha.New(os.Getenv("RECAPTCHA_SECRET"))
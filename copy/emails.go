@@ -3,6 +3,8 @@ package copy
 import (
 	"doubleboiler/config"
 	"fmt"
+	"html/template"
+	"strings"
 )
 
 func VerificationEmail(verificationUrl string, orgName string) (html, text string) {
@@ -151,3 +153,176 @@ The team at %s
 
 	return
 }
+
+func BroadcastEmail(organisationName, body string) (html, text string) {
+	escaped := strings.ReplaceAll(template.HTMLEscapeString(body), "\n", "\n\t<br>")
+
+	html = fmt.Sprintf(`
+	%s
+	<br><br>
+	--
+	<br>
+	Sent by %s via <a href="%s">%s</a>
+	`, escaped, organisationName, config.URI, config.NAME)
+
+	text = fmt.Sprintf(`
+%s
+
+--
+Sent by %s via %s
+	`, body, organisationName, config.NAME)
+
+	return
+}
+
+func MentionEmail(author, entityLabel, body, link string) (html, text string) {
+	escaped := strings.ReplaceAll(template.HTMLEscapeString(body), "\n", "\n\t<br>")
+
+	html = fmt.Sprintf(`
+	Hi there! %s mentioned you in a comment on <a href="%s">%s</a>:
+	<br><br>
+	%s
+	<br><br>
+	Cheers,
+	<br><br>
+	The team at %s
+	`, author, link, template.HTMLEscapeString(entityLabel), escaped, config.NAME)
+
+	text = fmt.Sprintf(`
+Hi there! %s mentioned you in a comment on %s:
+
+%s
+
+To reply, visit this URL:
+
+%s
+
+Cheers,
+
+The team at %s
+	`, author, entityLabel, body, link, config.NAME)
+
+	return
+}
+
+func AccountDeletionEmail(deleteOn, cancelUrl string) (html, text string) {
+	html = fmt.Sprintf(`
+	Hi there! We've received a request to delete your %s account.
+	<br><br>
+	Your personal details will be permanently removed on %s. Until then you can change your mind by logging in and cancelling the deletion <a href="%s">here</a>.
+	<br><br>
+	If you didn't ask for this, please cancel the deletion, change your password and reply to this email to let us know.
+	<br><br>
+	Cheers,
+	<br><br>
+	The team at %s
+	`, config.NAME, deleteOn, cancelUrl, config.NAME)
+
+	text = fmt.Sprintf(`
+Hi there! We've received a request to delete your %s account.
+
+Your personal details will be permanently removed on %s. Until then you can change your mind by logging in and cancelling the deletion at this URL:
+
+%s
+
+If you didn't ask for this, please cancel the deletion, change your password and reply to this email to let us know.
+
+Cheers,
+
+The team at %s
+	`, config.NAME, deleteOn, cancelUrl, config.NAME)
+
+	return
+}
+
+func DataExportEmail(description, downloadUrl, expiresOn string) (html, text string) {
+	html = fmt.Sprintf(`
+	Hi there! The copy of %s you asked for from %s is ready.
+	<br><br>
+	You can download it <a href="%s">here</a> until %s. You'll need to be logged in.
+	<br><br>
+	If you didn't ask for this, please change your password and reply to this email to let us know.
+	<br><br>
+	Cheers,
+	<br><br>
+	The team at %s
+	`, description, config.NAME, downloadUrl, expiresOn, config.NAME)
+
+	text = fmt.Sprintf(`
+Hi there! The copy of %s you asked for from %s is ready.
+
+You can download it from this URL until %s. You'll need to be logged in.
+
+%s
+
+If you didn't ask for this, please change your password and reply to this email to let us know.
+
+Cheers,
+
+The team at %s
+	`, description, config.NAME, expiresOn, downloadUrl, config.NAME)
+
+	return
+}
+
+func OrganisationDeletionEmail(orgName, deleteOn, cancelUrl string) (html, text string) {
+	html = fmt.Sprintf(`
+	Hi there! We've received a request to delete %s from %s.
+	<br><br>
+	Everything it holds will be permanently removed on %s. Before that happens we'll email you a copy of it all. Until then any admin can change their mind by cancelling the deletion <a href="%s">here</a>.
+	<br><br>
+	If you didn't ask for this, please cancel the deletion, change your password and reply to this email to let us know.
+	<br><br>
+	Cheers,
+	<br><br>
+	The team at %s
+	`, orgName, config.NAME, deleteOn, cancelUrl, config.NAME)
+
+	text = fmt.Sprintf(`
+Hi there! We've received a request to delete %s from %s.
+
+Everything it holds will be permanently removed on %s. Before that happens we'll email you a copy of it all. Until then any admin can change their mind by cancelling the deletion at this URL:
+
+%s
+
+If you didn't ask for this, please cancel the deletion, change your password and reply to this email to let us know.
+
+Cheers,
+
+The team at %s
+	`, orgName, config.NAME, deleteOn, cancelUrl, config.NAME)
+
+	return
+}
+
+// BrandedEmail puts an organisation's logo, accent colour and footer around an email sent on its
+// behalf. Any of them can be blank.
+func BrandedEmail(html, text, orgName, logoURL, accentColour, footer string) (string, string) {
+	if logoURL != "" {
+		html = fmt.Sprintf(`
+	<img src="%s" alt="%s" style="max-height: 48px; max-width: 240px;">
+	<br><br>
+	%s`, template.HTMLEscapeString(logoURL), template.HTMLEscapeString(orgName), html)
+	}
+
+	if footer != "" {
+		html = fmt.Sprintf(`%s
+	<br><br>
+	--
+	<br>
+	%s
+	`, html, strings.ReplaceAll(template.HTMLEscapeString(footer), "\n", "\n\t<br>"))
+
+		text = fmt.Sprintf(`%s
+
+--
+%s
+	`, text, footer)
+	}
+
+	if accentColour != "" {
+		html = fmt.Sprintf(`<div style="border-top: 4px solid %s; padding-top: 16px;">%s</div>`, template.HTMLEscapeString(accentColour), html)
+	}
+
+	return html, text
+}
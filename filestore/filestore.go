@@ -0,0 +1,44 @@
+package filestore
+
+import (
+	"context"
+	"doubleboiler/config"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"time"
+)
+
+// Store is somewhere uploaded files live. Files are never served by the app directly,
+// instead the user is sent to a short lived signed URL for the object.
+type Store interface {
+	Put(ctx context.Context, key, contentType string, body io.Reader) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	SignedURL(key, filename string, expiry time.Duration) (string, error)
+}
+
+var ErrNotFound = errors.New("File not found")
+
+var Default Store
+
+func init() {
+	switch config.STORAGE_BACKEND {
+	case "gcs":
+		Default = GCS{Bucket: config.Bucket}
+	case "local":
+		Default = Local{Dir: config.LOCAL_STORAGE_DIR}
+	default:
+		log.Fatalf("Unknown storage backend %s", config.STORAGE_BACKEND)
+	}
+}
+
+// ContentDisposition is the header value to send a file as a download under its original name.
+func ContentDisposition(filename string) string {
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	if disposition == "" {
+		return "attachment"
+	}
+	return disposition
+}
@@ -0,0 +1,54 @@
+package filestore
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+type GCS struct {
+	Bucket *storage.BucketHandle
+}
+
+func (this GCS) Put(ctx context.Context, key, contentType string, body io.Reader) error {
+	w := this.Bucket.Object(key).NewWriter(ctx)
+	w.ContentType = contentType
+
+	if _, err := io.Copy(w, body); err != nil {
+		w.Close()
+		return err
+	}
+
+	return w.Close()
+}
+
+func (this GCS) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	r, err := this.Bucket.Object(key).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, ErrNotFound
+	}
+	return r, err
+}
+
+func (this GCS) Delete(ctx context.Context, key string) error {
+	err := this.Bucket.Object(key).Delete(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (this GCS) SignedURL(key, filename string, expiry time.Duration) (string, error) {
+	return this.Bucket.SignedURL(key, &storage.SignedURLOptions{
+		Method:  "GET",
+		Expires: time.Now().Add(expiry),
+		Scheme:  storage.SigningSchemeV4,
+		QueryParameters: url.Values{
+			"response-content-disposition": {ContentDisposition(filename)},
+		},
+	})
+}
@@ -0,0 +1,62 @@
+package filestore
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := Local{Dir: t.TempDir()}
+
+	assert.Nil(t, store.Put(ctx, "attachments/org/file", "text/plain", bytes.NewBufferString("hello")))
+
+	file, err := store.Open(ctx, "attachments/org/file")
+	assert.Nil(t, err)
+	contents, err := io.ReadAll(file)
+	assert.Nil(t, err)
+	file.Close()
+	assert.Equal(t, "hello", string(contents))
+
+	assert.Nil(t, store.Delete(ctx, "attachments/org/file"))
+	_, err = store.Open(ctx, "attachments/org/file")
+	assert.Equal(t, ErrNotFound, err)
+
+	assert.Nil(t, store.Delete(ctx, "attachments/org/file"))
+}
+
+func TestLocalRejectsTraversal(t *testing.T) {
+	store := Local{Dir: t.TempDir()}
+	_, err := store.Open(context.Background(), "../../etc/passwd")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestLocalSignedURL(t *testing.T) {
+	store := Local{Dir: t.TempDir()}
+
+	signed, err := store.SignedURL("attachments/org/file", "report.pdf", time.Minute)
+	assert.Nil(t, err)
+
+	parsed, err := url.Parse(signed)
+	assert.Nil(t, err)
+	assert.True(t, strings.HasSuffix(parsed.Path, "/files/attachments/org/file"))
+
+	q := parsed.Query()
+	assert.Nil(t, store.Verify("attachments/org/file", q.Get("filename"), q.Get("expires"), q.Get("signature")))
+	assert.Equal(t, ErrInvalidSignature, store.Verify("attachments/org/other", q.Get("filename"), q.Get("expires"), q.Get("signature")))
+	assert.Equal(t, ErrInvalidSignature, store.Verify("attachments/org/file", "other.pdf", q.Get("expires"), q.Get("signature")))
+
+	expired, err := store.SignedURL("attachments/org/file", "report.pdf", -time.Minute)
+	assert.Nil(t, err)
+	parsed, err = url.Parse(expired)
+	assert.Nil(t, err)
+	q = parsed.Query()
+	assert.Equal(t, ErrInvalidSignature, store.Verify("attachments/org/file", q.Get("filename"), q.Get("expires"), q.Get("signature")))
+}
@@ -5,8 +5,11 @@ import (
 	"crypto/tls"
 	"doubleboiler/config"
 	"doubleboiler/logger"
+	"doubleboiler/models"
 	"doubleboiler/routes"
 	"doubleboiler/workers"
+	"doubleboiler/workers/outbox"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -22,6 +25,8 @@ func main() {
 
 	app := routes.Init()
 
+	outbox.Init()
+
 	if config.START_WORKERS {
 		fmt.Println("INFO Starting workers")
 		workers.Init()
@@ -35,6 +40,8 @@ func main() {
 
 		if r.URL.Path == "/health" {
 			healthHandler.ServeHTTP(w, r)
+		} else if r.URL.Path == "/readyz" {
+			readyHandler.ServeHTTP(w, r)
 		} else {
 			app.ServeHTTP(w, r)
 		}
@@ -119,6 +126,54 @@ var healthHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request
 	w.Write([]byte("ok"))
 })
 
+type readiness struct {
+	Status      string `json:"status"`
+	DeadLetters int    `json:"dead_letters"`
+	Outbox      int    `json:"outbox"`
+}
+
+var readyHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	ctx := context.WithValue(r.Context(), "tx", config.Db)
+
+	if err := config.Db.PingContext(ctx); err != nil {
+		logger.Log(ctx, logger.Error, fmt.Sprintf("db connection error: %+v \n", err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(readiness{Status: "db connection error"})
+		return
+	}
+
+	if err := config.QUEUE.Healthy(ctx); err != nil {
+		logger.Log(ctx, logger.Error, fmt.Sprintf("queue connection error: %+v \n", err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(readiness{Status: "queue connection error"})
+		return
+	}
+
+	deadLetters, err := models.CountDeadLetters(ctx)
+	if err != nil {
+		logger.Log(ctx, logger.Error, fmt.Sprintf("counting dead letters: %+v \n", err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(readiness{Status: "error counting dead letters"})
+		return
+	}
+
+	unpublished, err := models.CountUnpublishedOutbox(ctx)
+	if err != nil {
+		logger.Log(ctx, logger.Error, fmt.Sprintf("counting outbox: %+v \n", err))
+		w.WriteHeader(http.StatusServiceUnavailable)
+		json.NewEncoder(w).Encode(readiness{Status: "error counting outbox"})
+		return
+	}
+
+	json.NewEncoder(w).Encode(readiness{
+		Status:      "ok",
+		DeadLetters: deadLetters,
+		Outbox:      unpublished,
+	})
+})
+
 type maintPageData struct {
 	Context context.Context
 }
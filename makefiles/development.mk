@@ -1,5 +1,6 @@
 .PHONY: live_reload
 live_reload: export TEST_MOCKS_ON := false
+live_reload: export STORAGE_BACKEND := local
 live_reload: export DB_URI := $(DEV_DB_URI)
 live_reload: assets/css/main.css
 live_reload: logs
@@ -11,8 +12,8 @@ rummage:
 
 .PHONY: change
 change:
-	cat changelog/template.go | sed 's/{{now}}/$(now)/' > "changelog/$(now_no_colons).go"
-	vim "changelog/$(now_no_colons).go"
+	cat changelog/template.md | sed 's/{{now}}/$(now)/' > "changelog/entries/$(now_no_colons).md"
+	vim "changelog/entries/$(now_no_colons).md"
 
 logs:
 	mkfifo logs
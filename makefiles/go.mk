@@ -25,6 +25,8 @@ reset_test_db:
 .PHONY: test
 test: export TEST_MOCKS_ON := true
 test: export KEWPIE_BACKEND=memory
+test: export STORAGE_BACKEND=local
+test: export LOCAL_STORAGE_DIR=/tmp/doubleboiler_test_files
 test: export DB_URI := $(TESTING_DB_URI)
 test: reset_test_db
 test: .db_init
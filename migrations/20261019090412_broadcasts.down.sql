@@ -0,0 +1,2 @@
+ALTER TABLE communications DROP COLUMN broadcast_id;
+DROP TABLE broadcasts;
@@ -0,0 +1,24 @@
+CREATE TABLE broadcasts (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  user_id UUID REFERENCES users (id),
+  subject TEXT NOT NULL DEFAULT '',
+  body TEXT NOT NULL DEFAULT '',
+  recipient_count INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+ALTER TABLE broadcasts ADD COLUMN ts tsvector
+  GENERATED ALWAYS AS
+    (  to_tsvector('english', coalesce(subject, ''))
+    || to_tsvector('english', coalesce(body, ''))
+  ) STORED;
+
+CREATE INDEX broadcasts_ts_idx ON broadcasts USING GIN (ts);
+CREATE INDEX broadcasts_organisation_id ON broadcasts (organisation_id);
+
+ALTER TABLE communications ADD COLUMN broadcast_id UUID REFERENCES broadcasts (id) ON DELETE SET NULL;
+
+CREATE INDEX communications_broadcast_id ON communications (broadcast_id);
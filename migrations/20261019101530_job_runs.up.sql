@@ -0,0 +1,15 @@
+CREATE TABLE job_runs (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  name TEXT NOT NULL,
+  scheduled_for TIMESTAMPTZ NOT NULL,
+  started_at TIMESTAMPTZ NOT NULL,
+  finished_at TIMESTAMPTZ,
+  succeeded BOOLEAN NOT NULL DEFAULT false,
+  error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (name, scheduled_for)
+);
+
+CREATE INDEX job_runs_name_started_at ON job_runs (name, started_at DESC);
@@ -0,0 +1,14 @@
+CREATE TABLE dead_letters (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  queue_name TEXT NOT NULL,
+  task_id TEXT NOT NULL DEFAULT '',
+  body TEXT NOT NULL DEFAULT '',
+  tags JSONB NOT NULL DEFAULT '{}',
+  error TEXT NOT NULL DEFAULT '',
+  attempts INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX dead_letters_queue_name ON dead_letters (queue_name);
@@ -0,0 +1,13 @@
+CREATE TABLE outbox (
+  id UUID PRIMARY KEY,
+  queue_name TEXT NOT NULL,
+  task JSONB NOT NULL,
+  attempts INT NOT NULL DEFAULT 0,
+  last_error TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  published_at TIMESTAMPTZ,
+  delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX outbox_unpublished ON outbox (created_at) WHERE published_at IS NULL;
+CREATE INDEX outbox_published_at ON outbox (published_at);
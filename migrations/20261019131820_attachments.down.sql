@@ -0,0 +1,2 @@
+ALTER TABLE organisations DROP COLUMN attachment_quota;
+DROP TABLE attachments;
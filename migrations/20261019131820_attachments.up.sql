@@ -0,0 +1,19 @@
+CREATE TABLE attachments (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  some_thing_id UUID REFERENCES some_things (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+  name TEXT NOT NULL,
+  size BIGINT NOT NULL,
+  content_type TEXT NOT NULL,
+  checksum TEXT NOT NULL,
+  storage_key TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX attachments_organisation_id ON attachments (organisation_id);
+CREATE INDEX attachments_some_thing_id ON attachments (some_thing_id);
+
+ALTER TABLE organisations ADD COLUMN attachment_quota BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+CREATE TABLE comments (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  entity_type TEXT NOT NULL,
+  entity_id UUID NOT NULL,
+  user_id UUID REFERENCES users (id) ON DELETE SET NULL,
+  body TEXT NOT NULL,
+  mentions TEXT[] NOT NULL DEFAULT '{}',
+  edited BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX comments_entity_id ON comments (entity_id);
+CREATE INDEX comments_organisation_id ON comments (organisation_id);
@@ -0,0 +1,14 @@
+ALTER TABLE some_things DROP COLUMN ts;
+ALTER TABLE some_things DROP COLUMN tags;
+ALTER TABLE some_things DROP COLUMN custom_fields;
+
+ALTER TABLE some_things ADD COLUMN ts tsvector
+  GENERATED ALWAYS AS
+    (  to_tsvector('english', coalesce(name, ''))
+    || to_tsvector('english', coalesce(description, ''))
+  ) STORED;
+
+CREATE INDEX some_things_ts_idx ON some_things USING GIN (ts);
+
+DROP TABLE custom_fields;
+DROP TABLE tags;
@@ -0,0 +1,39 @@
+CREATE TABLE tags (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  name TEXT NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (organisation_id, name)
+);
+
+CREATE TABLE custom_fields (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  key TEXT NOT NULL,
+  label TEXT NOT NULL,
+  field_type TEXT NOT NULL CHECK (field_type IN ('text', 'number', 'date', 'select', 'boolean')),
+  options TEXT[] NOT NULL DEFAULT '{}',
+  required BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  UNIQUE (organisation_id, key)
+);
+
+ALTER TABLE some_things ADD COLUMN tags JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE some_things ADD COLUMN custom_fields JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE some_things DROP COLUMN ts;
+ALTER TABLE some_things ADD COLUMN ts tsvector
+  GENERATED ALWAYS AS
+    (  to_tsvector('english', coalesce(name, ''))
+    || to_tsvector('english', coalesce(description, ''))
+    || jsonb_to_tsvector('english', tags, '["string"]')
+    || jsonb_to_tsvector('english', custom_fields, '["string", "numeric", "boolean"]')
+  ) STORED;
+
+CREATE INDEX some_things_ts_idx ON some_things USING GIN (ts);
+CREATE INDEX some_things_tags_idx ON some_things USING GIN (tags);
+CREATE INDEX some_things_custom_fields_idx ON some_things USING GIN (custom_fields);
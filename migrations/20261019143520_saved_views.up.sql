@@ -0,0 +1,16 @@
+CREATE TABLE saved_views (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  user_id UUID REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE,
+  list TEXT NOT NULL,
+  name TEXT NOT NULL,
+  query TEXT NOT NULL DEFAULT '',
+  shared BOOLEAN NOT NULL DEFAULT false,
+  is_default BOOLEAN NOT NULL DEFAULT false,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX saved_views_user_id ON saved_views (user_id, list);
+CREATE INDEX saved_views_organisation_id ON saved_views (organisation_id, list);
@@ -0,0 +1,3 @@
+DROP INDEX communications_organisation_id_created_at;
+DROP INDEX audit_log_entity_id_stamp;
+DROP INDEX audit_log_organisation_id_stamp;
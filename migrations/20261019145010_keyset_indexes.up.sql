@@ -0,0 +1,3 @@
+CREATE INDEX audit_log_organisation_id_stamp ON audit_log (organisation_id, stamp, id);
+CREATE INDEX audit_log_entity_id_stamp ON audit_log (entity_id, stamp);
+CREATE INDEX communications_organisation_id_created_at ON communications (organisation_id, created_at, id);
@@ -0,0 +1,5 @@
+DROP TRIGGER users_email_organisations_users_ts ON users;
+DROP FUNCTION users_email_organisations_users_ts();
+DROP TRIGGER organisations_users_ts ON organisations_users;
+DROP FUNCTION organisations_users_ts();
+ALTER TABLE organisations_users DROP COLUMN ts;
@@ -0,0 +1,32 @@
+ALTER TABLE organisations_users ADD COLUMN ts tsvector;
+
+-- Email lives on users, which a generated column can't reach, so ts is kept up to date by triggers.
+CREATE FUNCTION organisations_users_ts() RETURNS trigger AS $$
+DECLARE
+  member_email TEXT;
+BEGIN
+  SELECT email INTO member_email FROM users WHERE id = NEW.user_id;
+  NEW.ts := to_tsvector('english', coalesce(NEW.name, ''))
+    || to_tsvector('english', coalesce(NEW.family_name, ''))
+    || to_tsvector('simple', coalesce(member_email, ''))
+    || to_tsvector('simple', translate(coalesce(member_email, ''), '@._+-', '     '));
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER organisations_users_ts BEFORE INSERT OR UPDATE ON organisations_users
+  FOR EACH ROW EXECUTE FUNCTION organisations_users_ts();
+
+CREATE FUNCTION users_email_organisations_users_ts() RETURNS trigger AS $$
+BEGIN
+  UPDATE organisations_users SET user_id = user_id WHERE user_id = NEW.id;
+  RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_email_organisations_users_ts AFTER UPDATE OF email ON users
+  FOR EACH ROW WHEN (OLD.email IS DISTINCT FROM NEW.email) EXECUTE FUNCTION users_email_organisations_users_ts();
+
+UPDATE organisations_users SET user_id = user_id;
+
+CREATE INDEX organisations_users_ts_idx ON organisations_users USING GIN (ts);
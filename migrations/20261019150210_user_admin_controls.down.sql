@@ -0,0 +1,2 @@
+ALTER TABLE audit_log DROP COLUMN reason;
+ALTER TABLE users DROP COLUMN disabled;
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT false;
+
+-- Why a superadmin stepped in on someone's account.
+ALTER TABLE audit_log ADD COLUMN reason TEXT;
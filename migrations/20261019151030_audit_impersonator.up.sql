@@ -0,0 +1,2 @@
+-- The superadmin who was really behind a change made while impersonating user_id.
+ALTER TABLE audit_log ADD COLUMN impersonator_id TEXT;
@@ -0,0 +1,3 @@
+DROP INDEX users_deletion_requested_at;
+ALTER TABLE users DROP COLUMN anonymised_at;
+ALTER TABLE users DROP COLUMN deletion_requested_at;
@@ -0,0 +1,4 @@
+ALTER TABLE users ADD COLUMN deletion_requested_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN anonymised_at TIMESTAMPTZ;
+
+CREATE INDEX users_deletion_requested_at ON users (deletion_requested_at) WHERE deletion_requested_at IS NOT NULL AND anonymised_at IS NULL;
@@ -0,0 +1,13 @@
+CREATE TABLE data_exports (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  user_id UUID REFERENCES users (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  status TEXT NOT NULL CHECK (status IN ('pending', 'ready')),
+  storage_key TEXT NOT NULL UNIQUE,
+  expires_at TIMESTAMPTZ,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX data_exports_user_id ON data_exports (user_id);
+CREATE INDEX data_exports_expires_at ON data_exports (expires_at);
@@ -0,0 +1,7 @@
+ALTER TABLE data_exports DROP COLUMN filename;
+ALTER TABLE data_exports DROP COLUMN organisation_id;
+
+DROP INDEX organisations_deletion_requested_at;
+ALTER TABLE organisations DROP COLUMN deletion_requested_by;
+ALTER TABLE organisations DROP COLUMN deletion_requested_at;
+ALTER TABLE organisations DROP COLUMN owner_id;
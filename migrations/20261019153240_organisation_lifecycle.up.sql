@@ -0,0 +1,15 @@
+ALTER TABLE organisations ADD COLUMN owner_id UUID REFERENCES users (id) ON UPDATE CASCADE;
+ALTER TABLE organisations ADD COLUMN deletion_requested_at TIMESTAMPTZ;
+ALTER TABLE organisations ADD COLUMN deletion_requested_by UUID REFERENCES users (id) ON UPDATE CASCADE;
+
+-- The longest standing admin is the closest thing existing organisations have to an owner.
+UPDATE organisations SET owner_id = (
+  SELECT user_id FROM organisations_users
+  WHERE organisation_id = organisations.id AND roles @> '[{"name": "admin"}]'
+  ORDER BY created_at LIMIT 1
+);
+
+CREATE INDEX organisations_deletion_requested_at ON organisations (deletion_requested_at) WHERE deletion_requested_at IS NOT NULL;
+
+ALTER TABLE data_exports ADD COLUMN organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE SET NULL;
+ALTER TABLE data_exports ADD COLUMN filename TEXT NOT NULL DEFAULT 'personal-data.zip';
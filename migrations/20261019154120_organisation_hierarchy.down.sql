@@ -0,0 +1,2 @@
+DROP INDEX organisations_parent_id;
+ALTER TABLE organisations DROP COLUMN parent_id;
@@ -0,0 +1,3 @@
+ALTER TABLE organisations ADD COLUMN parent_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE SET NULL CHECK (parent_id <> id);
+
+CREATE INDEX organisations_parent_id ON organisations (parent_id);
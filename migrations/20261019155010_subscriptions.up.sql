@@ -0,0 +1,13 @@
+CREATE TABLE subscriptions (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL UNIQUE,
+  plan_id TEXT NOT NULL,
+  status TEXT NOT NULL CHECK (status IN ('trialing', 'active', 'past_due', 'cancelled')),
+  trial_ends_at TIMESTAMPTZ,
+  renews_at TIMESTAMPTZ,
+  provider_customer_id TEXT NOT NULL DEFAULT '',
+  provider_subscription_id TEXT UNIQUE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
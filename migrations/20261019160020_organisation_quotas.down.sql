@@ -0,0 +1,21 @@
+DROP TRIGGER attachments_usage_changed ON attachments;
+DROP TRIGGER attachments_usage ON attachments;
+DROP FUNCTION attachments_usage();
+
+DROP TRIGGER some_things_usage_changed ON some_things;
+DROP TRIGGER some_things_usage ON some_things;
+DROP FUNCTION some_things_usage();
+
+DROP TRIGGER organisations_users_usage_moved ON organisations_users;
+DROP TRIGGER organisations_users_usage ON organisations_users;
+DROP FUNCTION organisations_users_usage();
+
+DROP TRIGGER organisations_usage_row ON organisations;
+DROP FUNCTION organisations_usage_row();
+
+DROP TABLE organisation_email_usage;
+DROP TABLE organisation_usage;
+
+ALTER TABLE organisations DROP COLUMN email_quota;
+ALTER TABLE organisations DROP COLUMN some_thing_quota;
+ALTER TABLE organisations DROP COLUMN member_quota;
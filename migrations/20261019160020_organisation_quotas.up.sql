@@ -0,0 +1,96 @@
+ALTER TABLE organisations ADD COLUMN member_quota INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE organisations ADD COLUMN some_thing_quota INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE organisations ADD COLUMN email_quota INTEGER NOT NULL DEFAULT 0;
+
+-- Usage is counted by triggers as rows come and go, so quotas can be checked without counting
+-- every member, SomeThing and attachment on each save.
+CREATE TABLE organisation_usage (
+  organisation_id UUID PRIMARY KEY REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE,
+  members INTEGER NOT NULL DEFAULT 0,
+  some_things INTEGER NOT NULL DEFAULT 0,
+  storage_bytes BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE organisation_email_usage (
+  organisation_id UUID REFERENCES organisations (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  day DATE NOT NULL,
+  sent INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (organisation_id, day)
+);
+
+CREATE FUNCTION organisations_usage_row() RETURNS trigger AS $$
+BEGIN
+  INSERT INTO organisation_usage (organisation_id) VALUES (NEW.id) ON CONFLICT DO NOTHING;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER organisations_usage_row AFTER INSERT ON organisations
+  FOR EACH ROW EXECUTE FUNCTION organisations_usage_row();
+
+CREATE FUNCTION organisations_users_usage() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    UPDATE organisation_usage SET members = members - 1 WHERE organisation_id = OLD.organisation_id;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    UPDATE organisation_usage SET members = members + 1 WHERE organisation_id = NEW.organisation_id;
+  END IF;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER organisations_users_usage AFTER INSERT OR DELETE ON organisations_users
+  FOR EACH ROW EXECUTE FUNCTION organisations_users_usage();
+
+CREATE TRIGGER organisations_users_usage_moved AFTER UPDATE ON organisations_users
+  FOR EACH ROW WHEN (OLD.organisation_id IS DISTINCT FROM NEW.organisation_id) EXECUTE FUNCTION organisations_users_usage();
+
+-- Soft deleted SomeThings don't count.
+CREATE FUNCTION some_things_usage() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    IF NOT OLD.soft_deleted THEN
+      UPDATE organisation_usage SET some_things = some_things - 1 WHERE organisation_id = OLD.organisation_id;
+    END IF;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    IF NOT NEW.soft_deleted THEN
+      UPDATE organisation_usage SET some_things = some_things + 1 WHERE organisation_id = NEW.organisation_id;
+    END IF;
+  END IF;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER some_things_usage AFTER INSERT OR DELETE ON some_things
+  FOR EACH ROW EXECUTE FUNCTION some_things_usage();
+
+CREATE TRIGGER some_things_usage_changed AFTER UPDATE ON some_things
+  FOR EACH ROW WHEN (OLD.organisation_id IS DISTINCT FROM NEW.organisation_id OR OLD.soft_deleted IS DISTINCT FROM NEW.soft_deleted) EXECUTE FUNCTION some_things_usage();
+
+CREATE FUNCTION attachments_usage() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP <> 'INSERT' THEN
+    UPDATE organisation_usage SET storage_bytes = storage_bytes - OLD.size WHERE organisation_id = OLD.organisation_id;
+  END IF;
+  IF TG_OP <> 'DELETE' THEN
+    UPDATE organisation_usage SET storage_bytes = storage_bytes + NEW.size WHERE organisation_id = NEW.organisation_id;
+  END IF;
+  RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER attachments_usage AFTER INSERT OR DELETE ON attachments
+  FOR EACH ROW EXECUTE FUNCTION attachments_usage();
+
+CREATE TRIGGER attachments_usage_changed AFTER UPDATE ON attachments
+  FOR EACH ROW WHEN (OLD.organisation_id IS DISTINCT FROM NEW.organisation_id OR OLD.size IS DISTINCT FROM NEW.size) EXECUTE FUNCTION attachments_usage();
+
+INSERT INTO organisation_usage (organisation_id, members, some_things, storage_bytes)
+SELECT
+  organisations.id,
+  (SELECT COUNT(*) FROM organisations_users WHERE organisation_id = organisations.id),
+  (SELECT COUNT(*) FROM some_things WHERE organisation_id = organisations.id AND NOT soft_deleted),
+  (SELECT COALESCE(SUM(size), 0) FROM attachments WHERE organisation_id = organisations.id)
+FROM organisations;
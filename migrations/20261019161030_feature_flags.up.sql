@@ -0,0 +1,12 @@
+CREATE TABLE feature_flags (
+  id UUID PRIMARY KEY,
+  revision TEXT NOT NULL UNIQUE,
+  key TEXT NOT NULL UNIQUE,
+  description TEXT NOT NULL DEFAULT '',
+  enabled BOOLEAN NOT NULL DEFAULT false,
+  organisation_ids TEXT[] NOT NULL DEFAULT '{}',
+  user_ids TEXT[] NOT NULL DEFAULT '{}',
+  rollout_percent INTEGER NOT NULL DEFAULT 0 CHECK (rollout_percent BETWEEN 0 AND 100),
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1 @@
+ALTER TABLE organisations ADD COLUMN settings JSONB NOT NULL DEFAULT '[]'::jsonb;
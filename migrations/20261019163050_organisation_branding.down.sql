@@ -0,0 +1,2 @@
+ALTER TABLE organisations DROP COLUMN logo_content_type;
+ALTER TABLE organisations DROP COLUMN logo_key;
@@ -0,0 +1,2 @@
+ALTER TABLE organisations ADD COLUMN logo_key TEXT;
+ALTER TABLE organisations ADD COLUMN logo_content_type TEXT NOT NULL DEFAULT '';
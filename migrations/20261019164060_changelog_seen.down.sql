@@ -0,0 +1,2 @@
+ALTER TABLE users DROP COLUMN changelog_notified_at;
+ALTER TABLE users DROP COLUMN changelog_seen_at;
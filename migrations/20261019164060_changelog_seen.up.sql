@@ -0,0 +1,2 @@
+ALTER TABLE users ADD COLUMN changelog_seen_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN changelog_notified_at TIMESTAMPTZ;
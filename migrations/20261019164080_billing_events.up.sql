@@ -0,0 +1,6 @@
+CREATE TABLE billing_events (
+  id TEXT PRIMARY KEY,
+  subscription_id UUID REFERENCES subscriptions (id) ON UPDATE CASCADE ON DELETE CASCADE NOT NULL,
+  occurred_at TIMESTAMPTZ NOT NULL,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
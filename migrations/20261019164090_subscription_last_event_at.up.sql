@@ -0,0 +1 @@
+ALTER TABLE subscriptions ADD COLUMN last_event_at TIMESTAMPTZ;
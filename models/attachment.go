@@ -0,0 +1,249 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"doubleboiler/filestore"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// SignedURLExpiry is how long a download link for an attachment stays valid.
+var SignedURLExpiry = 5 * time.Minute
+
+// MaxAttachmentBytes is the largest single file that can be attached, whatever the quota.
+const MaxAttachmentBytes = 100 << 20
+
+var ErrAttachmentTooBig = ClientSafeError{Message: fmt.Sprintf("Attachments can be at most %s", HumanBytes(MaxAttachmentBytes))}
+
+type Attachment struct {
+	ID             string
+	Revision       string
+	OrganisationID string
+	SomeThingID    string
+	UserID         sql.NullString
+	Name           string
+	Size           int64
+	ContentType    string
+	Checksum       string
+	StorageKey     string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (this *Attachment) colmap() *Colmap {
+	return &Colmap{
+		"id":              &this.ID,
+		"revision":        &this.Revision,
+		"organisation_id": &this.OrganisationID,
+		"some_thing_id":   &this.SomeThingID,
+		"user_id":         &this.UserID,
+		"name":            &this.Name,
+		"size":            &this.Size,
+		"content_type":    &this.ContentType,
+		"checksum":        &this.Checksum,
+		"storage_key":     &this.StorageKey,
+		"created_at":      &this.CreatedAt,
+		"updated_at":      &this.UpdatedAt,
+	}
+}
+
+func (this *Attachment) New(organisationID, someThingID, userID, name, contentType string, size int64) {
+	this.ID = uuid.NewV4().String()
+	this.OrganisationID = organisationID
+	this.SomeThingID = someThingID
+	this.UserID = sql.NullString{
+		Valid:  userID != "",
+		String: userID,
+	}
+	this.Name = name
+	this.ContentType = contentType
+	if this.ContentType == "" {
+		this.ContentType = "application/octet-stream"
+	}
+	this.Size = size
+	this.StorageKey = fmt.Sprintf("attachments/%s/%s", organisationID, this.ID)
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+func (this *Attachment) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "attachments", this.ID, this.OrganisationID)
+}
+
+func (this *Attachment) Save(ctx context.Context) error {
+	q, props, newRev := StandardSave("attachments", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *Attachment) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("attachments", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *Attachment) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this Attachment) Label() string {
+	return this.Name
+}
+
+// Upload checks the organisation has room for the file, writes it to the store and records it.
+// The organisation row is locked for the rest of the transaction so concurrent uploads can't
+// both squeeze under the quota. If anything goes wrong the file is removed again.
+func (this *Attachment) Upload(ctx context.Context, store filestore.Store, body io.Reader) error {
+	if this.Size > MaxAttachmentBytes {
+		return ErrAttachmentTooBig
+	}
+
+	org, quotas, usage, err := quotasForUpdate(ctx, this.OrganisationID)
+	if err != nil {
+		return err
+	}
+
+	quota, used := quotas.StorageBytes, usage.StorageBytes
+
+	if quota > 0 && used+this.Size > quota {
+		return errOverAttachmentQuota(org, quota)
+	}
+
+	hash := sha256.New()
+	counter := &countingWriter{}
+	if err := store.Put(ctx, this.StorageKey, this.ContentType, io.TeeReader(body, io.MultiWriter(hash, counter))); err != nil {
+		store.Delete(ctx, this.StorageKey)
+		return err
+	}
+
+	this.Checksum = hex.EncodeToString(hash.Sum(nil))
+	this.Size = counter.n
+
+	if this.Size > MaxAttachmentBytes {
+		store.Delete(ctx, this.StorageKey)
+		return ErrAttachmentTooBig
+	}
+
+	if quota > 0 && used+this.Size > quota {
+		store.Delete(ctx, this.StorageKey)
+		return errOverAttachmentQuota(org, quota)
+	}
+
+	if err := this.Save(ctx); err != nil {
+		store.Delete(ctx, this.StorageKey)
+		return err
+	}
+
+	return nil
+}
+
+func (this Attachment) SignedURL(store filestore.Store) (string, error) {
+	return store.SignedURL(this.StorageKey, this.Name, SignedURLExpiry)
+}
+
+// Delete removes the record, and the file once the transaction has committed.
+func (this Attachment) Delete(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	if _, err := db.ExecContext(ctx, this.auditQuery(ctx, "D")+"DELETE FROM attachments WHERE id = $1 AND revision = $2", this.ID, this.Revision); err != nil {
+		return err
+	}
+
+	return DeleteFilesAfterCommit(ctx, this.StorageKey)
+}
+
+func AttachmentBytesUsed(ctx context.Context, organisationID string) (int64, error) {
+	db := ctx.Value("tx").(Querier)
+
+	var used int64
+	err := db.QueryRowContext(ctx, "SELECT COALESCE((SELECT storage_bytes FROM organisation_usage WHERE organisation_id = $1), 0)", organisationID).Scan(&used)
+	return used, err
+}
+
+func errOverAttachmentQuota(org Organisation, quota int64) error {
+	return ClientSafeError{Message: fmt.Sprintf("This file would take %s over its storage quota of %s", org.Name, HumanBytes(quota))}
+}
+
+func HumanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (this *countingWriter) Write(p []byte) (int, error) {
+	this.n += int64(len(p))
+	return len(p), nil
+}
+
+type Attachments struct {
+	Data     []Attachment
+	Criteria Criteria
+}
+
+func (this Attachments) colmap() *Colmap {
+	r := Attachment{}
+	return r.colmap()
+}
+
+func (Attachments) AvailableFilters() Filters {
+	return standardFilters("attachments")
+}
+
+func (this *Attachments) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "attachments"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "attachments"}
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "attachments", criteria.Filters, criteria.Pagination, Order{By: "created_at", Desc: true}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		attachment := Attachment{}
+		props := attachment.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, attachment)
+	}
+	return err
+}
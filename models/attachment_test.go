@@ -0,0 +1,183 @@
+package models
+
+import (
+	"bytes"
+	"doubleboiler/filestore"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, attachmentFix())
+	modelCollectionsUnderTest = append(modelCollectionsUnderTest, attachmentsFix())
+}
+
+func attachmentFixture(organisationID, someThingID, userID string) (a Attachment) {
+	a.New(organisationID, someThingID, userID, randString()+".txt", "text/plain", 5)
+	a.Checksum = randString()
+	return
+}
+
+func (Attachment) blank() model {
+	return &Attachment{}
+}
+
+func (a Attachment) id() string {
+	return a.ID
+}
+
+func (a *Attachment) nullDynamicValues() {
+	a.CreatedAt = time.Time{}
+	a.UpdatedAt = time.Time{}
+	a.Revision = ""
+}
+
+func (Attachment) tablename() string {
+	return "attachments"
+}
+
+func (Attachments) tablename() string {
+	return "attachments"
+}
+
+func (Attachments) blank() models {
+	return &Attachments{}
+}
+
+func attachmentsFix() modelCollectionFixture {
+	org := organisationFixture()
+	user := userFixture()
+	someThing := someThingFixture(org.ID)
+
+	return modelCollectionFixture{
+		deps: []model{&org, &user, &someThing},
+		collection: &Attachments{
+			Data: []Attachment{
+				attachmentFixture(org.ID, someThing.ID, user.ID),
+				attachmentFixture(org.ID, someThing.ID, user.ID),
+			},
+		},
+	}
+}
+
+func attachmentFix() []model {
+	org := organisationFixture()
+	user := userFixture()
+	someThing := someThingFixture(org.ID)
+
+	fix := attachmentFixture(org.ID, someThing.ID, user.ID)
+	return []model{
+		&org,
+		&user,
+		&someThing,
+		&fix,
+	}
+}
+
+func (this Attachments) data() []model {
+	ret := []model{}
+	for _, m := range this.Data {
+		ret = append(ret, &m)
+	}
+	return ret
+}
+
+func TestAttachmentUpload(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	store := filestore.Local{Dir: t.TempDir()}
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	someThing := someThingFixture(org.ID)
+	assert.Nil(t, someThing.Save(ctx))
+
+	fix := Attachment{}
+	fix.New(org.ID, someThing.ID, "", "hello.txt", "text/plain", 5)
+	assert.Nil(t, fix.Upload(ctx, store, bytes.NewBufferString("hello")))
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", fix.Checksum)
+
+	found := Attachment{}
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.Equal(t, int64(5), found.Size)
+
+	file, err := store.Open(ctx, fix.StorageKey)
+	assert.Nil(t, err)
+	contents, err := io.ReadAll(file)
+	assert.Nil(t, err)
+	file.Close()
+	assert.Equal(t, "hello", string(contents))
+
+	used, err := AttachmentBytesUsed(ctx, org.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), used)
+
+	assert.Nil(t, found.Delete(ctx))
+
+	// The file stays until the deletion has committed
+	_, err = store.Open(ctx, fix.StorageKey)
+	assert.Nil(t, err)
+
+	deletion := pendingFileDeletion(ctx, t)
+	assert.Equal(t, []string{fix.StorageKey}, deletion.Keys)
+	assert.Nil(t, deletion.Run(ctx, store))
+
+	_, err = store.Open(ctx, fix.StorageKey)
+	assert.Equal(t, filestore.ErrNotFound, err)
+
+	closeTx(t, ctx)
+}
+
+func TestAttachmentQuota(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	store := filestore.Local{Dir: t.TempDir()}
+
+	org := organisationFixture()
+	org.AttachmentQuota = 8
+	assert.Nil(t, org.Save(ctx))
+	someThing := someThingFixture(org.ID)
+	assert.Nil(t, someThing.Save(ctx))
+
+	first := Attachment{}
+	first.New(org.ID, someThing.ID, "", "first.txt", "text/plain", 5)
+	assert.Nil(t, first.Upload(ctx, store, bytes.NewBufferString("hello")))
+
+	second := Attachment{}
+	second.New(org.ID, someThing.ID, "", "second.txt", "text/plain", 5)
+	err := second.Upload(ctx, store, bytes.NewBufferString("world"))
+	assert.IsType(t, ClientSafeError{}, err)
+
+	_, err = store.Open(ctx, second.StorageKey)
+	assert.Equal(t, filestore.ErrNotFound, err)
+
+	closeTx(t, ctx)
+}
+
+func TestAttachmentTooBig(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	store := filestore.Local{Dir: t.TempDir()}
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	someThing := someThingFixture(org.ID)
+	assert.Nil(t, someThing.Save(ctx))
+
+	fix := Attachment{}
+	fix.New(org.ID, someThing.ID, "", "big.bin", "", MaxAttachmentBytes+1)
+	assert.Equal(t, ErrAttachmentTooBig, fix.Upload(ctx, store, bytes.NewBufferString("hello")))
+}
+
+func TestHumanBytes(t *testing.T) {
+	assert.Equal(t, "512 B", HumanBytes(512))
+	assert.Equal(t, "1.5 KB", HumanBytes(1536))
+	assert.Equal(t, "1.0 GB", HumanBytes(1073741824))
+}
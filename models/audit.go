@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"doubleboiler/util"
+	"log"
 	"time"
 
 	"github.com/davidbanham/scum/query"
+	scumutil "github.com/davidbanham/scum/util"
 )
 
 type Audit struct {
@@ -23,6 +25,10 @@ type Audit struct {
 	NewRowData      string
 	maybeNewRowData sql.NullString
 	Diff            string
+	Reason          string
+	ImpersonatorID  string
+	// ImpersonatorName is who was really behind the change, when a superadmin made it as UserName.
+	ImpersonatorName string
 }
 
 type Audits struct {
@@ -30,6 +36,56 @@ type Audits struct {
 	Criteria Criteria
 }
 
+func (Audits) Sortables() Sortables {
+	return Sortables{
+		{Key: "stamp", Label: "When", Col: "audit_log.stamp"},
+		{Key: "table", Label: "Type", Col: "audit_log.table_name"},
+		{Key: "action", Label: "Action", Col: "audit_log.action"},
+	}
+}
+
+func (Audits) AvailableFilters() Filters {
+	between := CreatedBetween{}
+	if err := between.Hydrate(DateFilterOpts{
+		Label: "Changed Between",
+		ID:    "changed-between",
+		Table: "audit_log",
+		Col:   "stamp",
+		Period: scumutil.Period{
+			Start: time.Now().Add(-24 * time.Hour),
+			End:   time.Now().Add(24 * time.Hour),
+		},
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	updates := HasProp{}
+	if err := updates.Hydrate(HasPropOpts{
+		Label: "Updates",
+		ID:    "updates",
+		Table: "audit_log",
+		Col:   "action",
+		Value: "U",
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	deletions := HasProp{}
+	if err := deletions.Hydrate(HasPropOpts{
+		Label: "Deletions",
+		ID:    "deletions",
+		Table: "audit_log",
+		Col:   "action",
+		Value: "D",
+	}); err != nil {
+		log.Fatal(err)
+	}
+
+	return Filters{&between, &updates, &deletions}
+}
+
+const auditJoins = "audit_log LEFT JOIN users ON audit_log.user_id = users.id::text LEFT JOIN users impersonators ON audit_log.impersonator_id = impersonators.id::text"
+
 func (this *Audits) FindAll(ctx context.Context, criteria Criteria) error {
 	this.Criteria = criteria
 
@@ -48,7 +104,11 @@ func (this *Audits) FindAll(ctx context.Context, criteria Criteria) error {
 		"action",
 		"old_row_data - 'revision' - 'updated_at'",
 		"users.email",
-		"lead(old_row_data - 'revision' - 'updated_at', 1) OVER (PARTITION BY entity_id ORDER BY stamp) new_row_data",
+		// A subquery rather than lead() so the next change is found even when it's on another page.
+		"(SELECT later.old_row_data - 'revision' - 'updated_at' FROM audit_log later WHERE later.entity_id = audit_log.entity_id AND later.stamp > audit_log.stamp ORDER BY later.stamp LIMIT 1) new_row_data",
+		"audit_log.reason",
+		"audit_log.impersonator_id",
+		"impersonators.email",
 	})
 
 	switch v := criteria.Query.(type) {
@@ -61,21 +121,30 @@ func (this *Audits) FindAll(ctx context.Context, criteria Criteria) error {
 		case ByEntityID:
 			rows, err = db.QueryContext(ctx, `SELECT
 		audit_log.id, entity_id, organisation_id, table_name, stamp, user_id, action, old_row_data - 'revision' - 'updated_at' - 'password' - 'totp_secret' - 'recovery_codes', users.email,
-		lead(old_row_data - 'revision' - 'updated_at' - 'password' - 'totp_secret' - 'recovery_codes', 1) OVER (PARTITION BY entity_id ORDER BY stamp) new_row_data
-		FROM audit_log LEFT JOIN users ON audit_log.user_id = users.id::text WHERE entity_id = $1 ORDER BY stamp DESC`+criteria.Pagination.PaginationQuery(), v.EntityID)
+		lead(old_row_data - 'revision' - 'updated_at' - 'password' - 'totp_secret' - 'recovery_codes', 1) OVER (PARTITION BY entity_id ORDER BY stamp) new_row_data,
+		audit_log.reason, audit_log.impersonator_id, impersonators.email
+		FROM `+auditJoins+` WHERE entity_id = $1 ORDER BY stamp DESC`+criteria.Pagination.PaginationQuery(), v.EntityID)
 		}
 	case query.Query:
-		rows, err = db.QueryContext(ctx, v.Construct(cols, "audit_log LEFT JOIN users ON audit_log.user_id = users.id::text", criteria.Filters, criteria.Pagination, Order{By: "stamp"}), v.Args()...)
+		if err := this.Criteria.Cursor.count(ctx, v, "audit_log", criteria.Filters); err != nil {
+			return err
+		}
+		rows, err = db.QueryContext(ctx, construct(v, cols, auditJoins, criteria, this.Sortables(), Order{By: "stamp"}), v.Args()...)
 	}
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	positions := []string{}
 	for rows.Next() {
 		audit := Audit{}
 		maybeUserName := sql.NullString{}
-		if err := rows.Scan(
+		maybeReason := sql.NullString{}
+		maybeImpersonatorID := sql.NullString{}
+		maybeImpersonatorName := sql.NullString{}
+		position := ""
+		props := []any{
 			&audit.ID,
 			&audit.EntityID,
 			&audit.OrganisationID,
@@ -86,7 +155,14 @@ func (this *Audits) FindAll(ctx context.Context, criteria Criteria) error {
 			&audit.maybeOldRowData,
 			&maybeUserName,
 			&audit.maybeNewRowData,
-		); err != nil {
+			&maybeReason,
+			&maybeImpersonatorID,
+			&maybeImpersonatorName,
+		}
+		if criteria.Cursor.Enabled {
+			props = append(props, &position)
+		}
+		if err := rows.Scan(props...); err != nil {
 			return err
 		}
 		audit.OldRowData = "{}"
@@ -97,13 +173,21 @@ func (this *Audits) FindAll(ctx context.Context, criteria Criteria) error {
 		if audit.maybeNewRowData.Valid {
 			audit.NewRowData = audit.maybeNewRowData.String
 		}
+		audit.Reason = maybeReason.String
+		audit.ImpersonatorID = maybeImpersonatorID.String
+		audit.ImpersonatorName = maybeImpersonatorID.String
+		if maybeImpersonatorName.Valid {
+			audit.ImpersonatorName = maybeImpersonatorName.String
+		}
 		audit.UserName = audit.UserID
 		if maybeUserName.Valid {
 			audit.UserName = maybeUserName.String
 		}
 
 		(*this).Data = append((*this).Data, audit)
+		positions = append(positions, position)
 	}
+	this.Data = keysetPage(this.Data, positions, &this.Criteria.Cursor)
 
 	for i, audit := range (*this).Data {
 		if !audit.maybeNewRowData.Valid && audit.Action != "D" {
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,3 +40,34 @@ func TestAuditsByEntity(t *testing.T) {
 
 	closeTx(t, ctx)
 }
+
+func TestAuditsRecordImpersonator(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	admin := userFixture()
+	admin.SuperAdmin = true
+	assert.Nil(t, admin.Save(ctx))
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	impersonated := userFixture()
+	assert.Nil(t, impersonated.Save(ctx))
+
+	ctx = context.WithValue(ctx, "user", impersonated)
+	ctx = context.WithValue(ctx, "impersonator", admin)
+
+	org.Name = randString()
+	assert.Nil(t, org.Save(ctx))
+
+	audits := Audits{}
+	criteria := Criteria{}
+	AddCustomQuery(ByEntityID{EntityID: org.ID}, &criteria)
+	assert.Nil(t, audits.FindAll(ctx, criteria))
+
+	assert.Equal(t, impersonated.ID, audits.Data[0].UserID)
+	assert.Equal(t, admin.ID, audits.Data[0].ImpersonatorID)
+	assert.Equal(t, admin.Email, audits.Data[0].ImpersonatorName)
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/copy"
+	"doubleboiler/filestore"
+	"doubleboiler/util"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"path"
+
+	"github.com/davidbanham/notifications"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Branding is how an organisation looks in the app while it's active, and in the emails sent on
+// its behalf. Blank fields fall back to the app's own.
+type Branding struct {
+	Name         string
+	LogoURL      string
+	AccentColour string
+	EmailFooter  string
+	ReplyTo      string
+}
+
+func (this Organisation) Branding() Branding {
+	branding := Branding{
+		Name:         this.Name,
+		AccentColour: this.Settings.ByKey(AccentColour.Key).Current(),
+		EmailFooter:  this.Settings.ByKey(EmailFooter.Key).Current(),
+		ReplyTo:      this.Settings.ByKey(EmailReplyTo.Key).Current(),
+	}
+	if name := this.Settings.ByKey(EmailSenderName.Key).Current(); name != "" {
+		branding.Name = name
+	}
+	if this.LogoKey.Valid {
+		// The key changes with every upload, so it's enough to stop a cached logo hanging around.
+		branding.LogoURL = fmt.Sprintf("%s/logos/%s?v=%s", config.URI, this.ID, path.Base(this.LogoKey.String))
+	}
+	return branding
+}
+
+// Email sends the email as the organisation, replacing the app's name as the sender, sending
+// replies that would have come to us to the organisation instead and adding its logo and footer.
+// Replies meant for a particular person are left alone.
+func (this Branding) Email(email notifications.Email) notifications.Email {
+	email.From = (&mail.Address{Name: this.Name, Address: config.SYSTEM_EMAIL_ONLY}).String()
+
+	if this.ReplyTo != "" && isSystemAddress(email.ReplyTo) {
+		email.ReplyTo = this.ReplyTo
+	}
+
+	email.HTML, email.Text = copy.BrandedEmail(email.HTML, email.Text, this.Name, this.LogoURL, this.AccentColour, this.EmailFooter)
+
+	return email
+}
+
+func isSystemAddress(address string) bool {
+	if address == "" {
+		return true
+	}
+	parsed, err := mail.ParseAddress(address)
+	if err != nil {
+		return false
+	}
+	for _, system := range []string{config.SYSTEM_EMAIL, config.SUPPORT_EMAIL} {
+		if s, err := mail.ParseAddress(system); err == nil && s.Address == parsed.Address {
+			return true
+		}
+	}
+	return false
+}
+
+// MaxLogoBytes is the largest logo an organisation can upload.
+const MaxLogoBytes = 1 << 20
+
+// Logos are served to anyone, including email clients, so only images browsers won't run anything in
+// are allowed. That rules out SVG.
+var LogoContentTypes = []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+
+var ErrInvalidLogo = ClientSafeError{Message: "A logo must be a PNG, JPEG, GIF or WebP image of 1MB or less"}
+
+// UploadLogo replaces the organisation's logo. The type is worked out from the file itself rather
+// than trusting what the browser says it is. The old logo is removed once the change commits, and
+// the new one is cleaned up later if it never does.
+func (this *Organisation) UploadLogo(ctx context.Context, store filestore.Store, body io.Reader) error {
+	data, err := io.ReadAll(io.LimitReader(body, MaxLogoBytes+1))
+	if err != nil {
+		return err
+	}
+
+	contentType := http.DetectContentType(data)
+	if len(data) == 0 || len(data) > MaxLogoBytes || !util.Contains(LogoContentTypes, contentType) {
+		return ErrInvalidLogo
+	}
+
+	previous := this.LogoKey
+
+	key := fmt.Sprintf("logos/%s/%s", this.ID, uuid.NewV4().String())
+	if err := deleteLogoUnlessUsed(ctx, this.ID, key); err != nil {
+		return err
+	}
+	if err := store.Put(ctx, key, contentType, bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	this.LogoKey = sql.NullString{Valid: true, String: key}
+	this.LogoContentType = contentType
+	if err := this.Save(ctx); err != nil {
+		store.Delete(ctx, key)
+		return err
+	}
+
+	if previous.Valid {
+		return DeleteFilesAfterCommit(ctx, previous.String)
+	}
+
+	return nil
+}
+
+func (this *Organisation) RemoveLogo(ctx context.Context) error {
+	if !this.LogoKey.Valid {
+		return nil
+	}
+
+	previous := this.LogoKey
+
+	this.LogoKey = sql.NullString{}
+	this.LogoContentType = ""
+	if err := this.Save(ctx); err != nil {
+		return err
+	}
+
+	return DeleteFilesAfterCommit(ctx, previous.String)
+}
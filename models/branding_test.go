@@ -0,0 +1,110 @@
+package models
+
+import (
+	"bytes"
+	"doubleboiler/filestore"
+	"strings"
+	"testing"
+
+	"github.com/davidbanham/notifications"
+	"github.com/stretchr/testify/assert"
+)
+
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+func TestBrandingEmail(t *testing.T) {
+	t.Parallel()
+
+	org := organisationFixture()
+	org.Settings.Populate(ValidSettings)
+
+	plain := org.Branding().Email(notifications.Email{ReplyTo: "someone@example.com", HTML: "Hello", Text: "Hello"})
+	assert.Contains(t, plain.From, org.Name)
+	assert.Equal(t, "someone@example.com", plain.ReplyTo)
+	assert.Equal(t, "Hello", plain.HTML)
+	assert.Equal(t, "Hello", plain.Text)
+
+	assert.Nil(t, org.Settings.Set(EmailSenderName.Key, "Acme Support"))
+	assert.Nil(t, org.Settings.Set(EmailReplyTo.Key, "help@acme.example.com"))
+	assert.Nil(t, org.Settings.Set(EmailFooter.Key, "Acme <Pty> Ltd"))
+	assert.Nil(t, org.Settings.Set(AccentColour.Key, "#FF0000"))
+
+	branded := org.Branding().Email(notifications.Email{HTML: "Hello", Text: "Hello"})
+	assert.True(t, strings.HasPrefix(branded.From, `"Acme Support" <`), branded.From)
+	assert.Equal(t, "help@acme.example.com", branded.ReplyTo)
+	assert.Contains(t, branded.HTML, "Acme &lt;Pty&gt; Ltd")
+	assert.Contains(t, branded.HTML, "#ff0000")
+	assert.Contains(t, branded.Text, "Acme <Pty> Ltd")
+
+	personal := org.Branding().Email(notifications.Email{ReplyTo: "someone@example.com"})
+	assert.Equal(t, "someone@example.com", personal.ReplyTo)
+}
+
+func TestOrganisationLogo(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	store := filestore.Local{Dir: t.TempDir()}
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	assert.Equal(t, "", org.Branding().LogoURL)
+
+	assert.Equal(t, ErrInvalidLogo, org.UploadLogo(ctx, store, strings.NewReader("<svg onload=alert(1)></svg>")))
+	assert.Equal(t, ErrInvalidLogo, org.UploadLogo(ctx, store, bytes.NewReader(append(pngHeader, make([]byte, MaxLogoBytes)...))))
+
+	assert.Nil(t, org.UploadLogo(ctx, store, bytes.NewReader(pngHeader)))
+	first := org.LogoKey.String
+	assert.Equal(t, "image/png", org.LogoContentType)
+	assert.Contains(t, org.Branding().LogoURL, "/logos/"+org.ID)
+
+	assert.Nil(t, org.UploadLogo(ctx, store, bytes.NewReader(pngHeader)))
+	assert.NotEqual(t, first, org.LogoKey.String)
+	_, err := store.Open(ctx, first)
+	assert.Nil(t, err, "the old logo stays until the change commits")
+
+	deletion := pendingFileDeletion(ctx, t)
+	assert.Equal(t, []string{first}, deletion.Keys)
+	assert.Nil(t, deletion.Run(ctx, store))
+	_, err = store.Open(ctx, first)
+	assert.Equal(t, filestore.ErrNotFound, err, "the old logo is removed")
+
+	found := Organisation{}
+	assert.Nil(t, found.FindByID(ctx, org.ID))
+	assert.Equal(t, org.LogoKey, found.LogoKey)
+
+	// The cleanup queued for an upload leaves it alone once it's the logo
+	second := found.LogoKey.String
+	assert.Nil(t, FileDeletion{Keys: []string{second}, UnlessLogoOf: org.ID}.Run(ctx, store))
+	_, err = store.Open(ctx, second)
+	assert.Nil(t, err)
+
+	assert.Nil(t, found.RemoveLogo(ctx))
+	assert.False(t, found.LogoKey.Valid)
+	_, err = store.Open(ctx, second)
+	assert.Nil(t, err)
+
+	assert.Nil(t, pendingFileDeletion(ctx, t).Run(ctx, store))
+	_, err = store.Open(ctx, second)
+	assert.Equal(t, filestore.ErrNotFound, err)
+}
+
+func TestUnusedLogoCleanup(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	store := filestore.Local{Dir: t.TempDir()}
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	// An upload whose transaction never committed
+	key := "logos/" + org.ID + "/abandoned"
+	assert.Nil(t, store.Put(ctx, key, "image/png", bytes.NewReader(pngHeader)))
+
+	assert.Nil(t, FileDeletion{Keys: []string{key}, UnlessLogoOf: org.ID}.Run(ctx, store))
+	_, err := store.Open(ctx, key)
+	assert.Equal(t, filestore.ErrNotFound, err)
+}
@@ -0,0 +1,254 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/copy"
+	"fmt"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/davidbanham/notifications"
+	"github.com/davidbanham/scum/search"
+	uuid "github.com/satori/go.uuid"
+)
+
+func init() {
+	SearchTargets = append(SearchTargets, (Broadcasts{}).Searchable())
+}
+
+type Broadcast struct {
+	ID             string
+	Revision       string
+	OrganisationID string
+	UserID         sql.NullString
+	Subject        string
+	Body           string
+	RecipientCount int
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (this *Broadcast) colmap() *Colmap {
+	return &Colmap{
+		"id":              &this.ID,
+		"revision":        &this.Revision,
+		"organisation_id": &this.OrganisationID,
+		"user_id":         &this.UserID,
+		"subject":         &this.Subject,
+		"body":            &this.Body,
+		"recipient_count": &this.RecipientCount,
+		"created_at":      &this.CreatedAt,
+		"updated_at":      &this.UpdatedAt,
+	}
+}
+
+func (this *Broadcast) New(organisationID, userID, subject, body string) {
+	this.ID = uuid.NewV4().String()
+	this.OrganisationID = organisationID
+	this.UserID = sql.NullString{
+		Valid:  userID != "",
+		String: userID,
+	}
+	this.Subject = subject
+	this.Body = body
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+func (this *Broadcast) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "broadcasts", this.ID, this.OrganisationID)
+}
+
+func (this *Broadcast) Save(ctx context.Context) error {
+	q, props, newRev := StandardSave("broadcasts", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *Broadcast) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("broadcasts", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *Broadcast) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this Broadcast) Label() string {
+	return this.Subject
+}
+
+// Send records the broadcast and enqueues one email per recipient onto the send_email queue.
+// The send_email worker logs a Communication against the broadcast as each one goes out.
+func (this *Broadcast) Send(ctx context.Context, org Organisation, sender User, recipients OrganisationUsers) error {
+	if this.Subject == "" || this.Body == "" {
+		return ClientSafeError{Message: "A message needs both a subject and a body"}
+	}
+
+	if len(recipients.Data) == 0 {
+		return ClientSafeError{Message: "No recipients matched the selection"}
+	}
+
+	this.RecipientCount = len(recipients.Data)
+
+	if err := this.Save(ctx); err != nil {
+		return err
+	}
+
+	emailHTML, emailText := copy.BroadcastEmail(org.Name, this.Body)
+
+	replyTo := config.SUPPORT_EMAIL
+	if sender.HasEmail() && org.Settings.ByKey(BroadcastReplyTo.Key).Current() == "sender" {
+		replyTo = sender.Email
+	}
+
+	for _, recipient := range recipients.Data {
+		mail := notifications.Email{
+			To:      recipient.Email,
+			From:    fmt.Sprintf("%s <%s>", org.Branding().Name, config.SYSTEM_EMAIL_ONLY),
+			ReplyTo: replyTo,
+			Text:    emailText,
+			HTML:    emailHTML,
+			Subject: this.Subject,
+		}
+
+		task := kewpie.Task{}
+		if err := task.Marshal(mail); err != nil {
+			return err
+		}
+
+		task.Tags.Set("user_id", recipient.UserID)
+		task.Tags.Set("organisation_id", org.ID)
+		task.Tags.Set("broadcast_id", this.ID)
+
+		if err := Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delivered counts the recipients the send_email worker has successfully sent to so far.
+func (this Broadcast) Delivered(ctx context.Context) (int, error) {
+	db := ctx.Value("tx").(Querier)
+
+	count := 0
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM communications WHERE broadcast_id = $1", this.ID).Scan(&count)
+	return count, err
+}
+
+// BroadcastAudience describes who a broadcast should go to. Roles and hand-picked
+// organisation users are additive, and the filters narrow the pool they are drawn from.
+// With no roles or organisation users given, everyone matching the filters is included.
+type BroadcastAudience struct {
+	Roles               []string
+	OrganisationUserIDs []string
+	Filters             Filters
+}
+
+func (this BroadcastAudience) Recipients(ctx context.Context, organisationID string) (OrganisationUsers, error) {
+	pool := OrganisationUsers{}
+	if err := pool.FindAll(ctx, Criteria{
+		Query:   &ByOrg{ID: organisationID},
+		Filters: this.Filters,
+	}); err != nil {
+		return OrganisationUsers{}, err
+	}
+
+	everyone := len(this.Roles) == 0 && len(this.OrganisationUserIDs) == 0
+
+	ret := OrganisationUsers{}
+	for _, ou := range pool.Data {
+		if ou.Email == "" {
+			continue
+		}
+
+		included := everyone
+		for _, role := range this.Roles {
+			if ou.Roles.Can(role) {
+				included = true
+			}
+		}
+		for _, id := range this.OrganisationUserIDs {
+			if ou.ID == id {
+				included = true
+			}
+		}
+
+		if included {
+			ret.Data = append(ret.Data, ou)
+		}
+	}
+
+	return ret, nil
+}
+
+type Broadcasts struct {
+	Data     []Broadcast
+	Criteria Criteria
+}
+
+func (this Broadcasts) colmap() *Colmap {
+	r := Broadcast{}
+	return r.colmap()
+}
+
+func (Broadcasts) AvailableFilters() Filters {
+	return standardFilters("broadcasts")
+}
+
+func (Broadcasts) Searchable() Searchable {
+	return Searchable{
+		EntityType: "Broadcast",
+		Label:      "subject",
+		Path:       "broadcasts",
+		Tablename:  "broadcasts",
+		Permitted:  search.BasicRoleCheck("admin"),
+	}
+}
+
+func (this *Broadcasts) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "broadcasts"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "broadcasts"}
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "broadcasts", criteria.Filters, criteria.Pagination, Order{By: "created_at", Desc: true}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		broadcast := Broadcast{}
+		props := broadcast.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, broadcast)
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, broadcastFix())
+	modelCollectionsUnderTest = append(modelCollectionsUnderTest, broadcastsFix())
+}
+
+func broadcastFixture(organisationID, userID string) (b Broadcast) {
+	b.New(organisationID, userID, randString(), randString())
+	return
+}
+
+func (Broadcast) blank() model {
+	return &Broadcast{}
+}
+
+func (b Broadcast) id() string {
+	return b.ID
+}
+
+func (b *Broadcast) nullDynamicValues() {
+	b.CreatedAt = time.Time{}
+	b.UpdatedAt = time.Time{}
+	b.Revision = ""
+}
+
+func (Broadcast) tablename() string {
+	return "broadcasts"
+}
+
+func (Broadcasts) tablename() string {
+	return "broadcasts"
+}
+
+func (Broadcasts) blank() models {
+	return &Broadcasts{}
+}
+
+func broadcastsFix() modelCollectionFixture {
+	org := organisationFixture()
+	user := userFixture()
+
+	return modelCollectionFixture{
+		deps: []model{&org, &user},
+		collection: &Broadcasts{
+			Data: []Broadcast{
+				broadcastFixture(org.ID, user.ID),
+				broadcastFixture(org.ID, user.ID),
+			},
+		},
+	}
+}
+
+func broadcastFix() []model {
+	org := organisationFixture()
+	user := userFixture()
+
+	fix := broadcastFixture(org.ID, user.ID)
+	return []model{
+		&org,
+		&user,
+		&fix,
+	}
+}
+
+func (this Broadcasts) data() []model {
+	ret := []model{}
+	for _, m := range this.Data {
+		ret = append(ret, &m)
+	}
+	return ret
+}
+
+func TestBroadcastRecipients(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	admin := userFixture()
+	assert.Nil(t, admin.Save(ctx))
+	adminOU := organisationUserFixture(admin.ID, org.ID)
+	assert.Nil(t, adminOU.Save(ctx))
+
+	member := userFixture()
+	assert.Nil(t, member.Save(ctx))
+	memberOU := OrganisationUser{}
+	memberOU.New(member.ID, org.ID, Roles{})
+	assert.Nil(t, memberOU.Save(ctx))
+
+	everyone, err := BroadcastAudience{}.Recipients(ctx, org.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(everyone.Data))
+
+	admins, err := BroadcastAudience{Roles: []string{"admin"}}.Recipients(ctx, org.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(admins.Data))
+	assert.Equal(t, adminOU.ID, admins.Data[0].ID)
+
+	picked, err := BroadcastAudience{OrganisationUserIDs: []string{memberOU.ID}}.Recipients(ctx, org.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(picked.Data))
+	assert.Equal(t, memberOU.ID, picked.Data[0].ID)
+
+	closeTx(t, ctx)
+}
+
+func TestBroadcastSendRequiresRecipients(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	user := userFixture()
+	assert.Nil(t, user.Save(ctx))
+
+	fix := broadcastFixture(org.ID, user.ID)
+	assert.Error(t, fix.Send(ctx, org, user, OrganisationUsers{}))
+
+	closeTx(t, ctx)
+}
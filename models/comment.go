@@ -0,0 +1,359 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/copy"
+	"doubleboiler/flashes"
+	"doubleboiler/util"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/davidbanham/notifications"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Comment is a note left against any entity in CommentTargets. Bodies are Markdown.
+type Comment struct {
+	ID             string
+	Revision       string
+	OrganisationID string
+	EntityType     string
+	EntityID       string
+	UserID         sql.NullString
+	UserEmail      sql.NullString
+	Body           string
+	Mentions       NullStringList
+	Edited         bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (this *Comment) colmap() *Colmap {
+	return &Colmap{
+		"comments.id":              &this.ID,
+		"comments.revision":        &this.Revision,
+		"comments.organisation_id": &this.OrganisationID,
+		"comments.entity_type":     &this.EntityType,
+		"comments.entity_id":       &this.EntityID,
+		"comments.user_id":         &this.UserID,
+		"users.email":              &this.UserEmail,
+		"comments.body":            &this.Body,
+		"comments.mentions":        &this.Mentions,
+		"comments.edited":          &this.Edited,
+		"comments.created_at":      &this.CreatedAt,
+		"comments.updated_at":      &this.UpdatedAt,
+	}
+}
+
+func (this *Comment) New(organisationID, entityType, entityID, userID, body string) {
+	this.ID = uuid.NewV4().String()
+	this.OrganisationID = organisationID
+	this.EntityType = entityType
+	this.EntityID = entityID
+	this.UserID = sql.NullString{
+		Valid:  userID != "",
+		String: userID,
+	}
+	this.Body = body
+	this.Mentions = NullStringList{Valid: true, Strings: []string{}}
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+func (this *Comment) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "comments", this.ID, this.OrganisationID)
+}
+
+// CommentTargets are the things comments can be left on: everything registered in SearchTargets
+// that belongs to an organisation, and organisations themselves. Users belong to no organisation
+// in particular, so they're left out.
+func CommentTargets() Searchables {
+	ret := Searchables{}
+	for _, target := range SearchTargets {
+		if target.Tablename != "users" {
+			ret = append(ret, target)
+		}
+	}
+	return append(ret, (Organisations{}).Searchable())
+}
+
+// Target finds the target the comment hangs off and checks the entity belongs to the same
+// organisation as the comment.
+func (this Comment) Target(ctx context.Context) (Searchable, error) {
+	target, ok := CommentTargets().ByEntityType()[this.EntityType]
+	if !ok {
+		return Searchable{}, ClientSafeError{Message: fmt.Sprintf("Comments can't be left on %s", this.EntityType)}
+	}
+
+	db := ctx.Value("tx").(Querier)
+
+	// An organisation belongs to itself.
+	organisationCol := "organisation_id"
+	if target.Tablename == "organisations" {
+		organisationCol = "id"
+	}
+
+	organisationID := ""
+	if err := db.QueryRowContext(ctx, "SELECT "+organisationCol+" FROM "+target.Tablename+" WHERE id = $1", this.EntityID).Scan(&organisationID); err != nil {
+		if err == sql.ErrNoRows {
+			return Searchable{}, ClientSafeError{Message: "The thing being commented on could not be found"}
+		}
+		return Searchable{}, err
+	}
+
+	if organisationID != this.OrganisationID {
+		return Searchable{}, ClientSafeError{Message: "The thing being commented on belongs to a different organisation"}
+	}
+
+	return target, nil
+}
+
+func (this Comment) EntityLabel(ctx context.Context) (string, error) {
+	target, err := this.Target(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	db := ctx.Value("tx").(Querier)
+
+	label := ""
+	err = db.QueryRowContext(ctx, "SELECT "+target.Label+" FROM "+target.Tablename+" WHERE id = $1", this.EntityID).Scan(&label)
+	return label, err
+}
+
+func (this *Comment) Save(ctx context.Context) error {
+	if strings.TrimSpace(this.Body) == "" {
+		return ClientSafeError{Message: "A comment can't be empty"}
+	}
+
+	if _, err := this.Target(ctx); err != nil {
+		return err
+	}
+
+	q, props, newRev := StandardSave("comments", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *Comment) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("comments LEFT JOIN users ON comments.user_id = users.id", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *Comment) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "comments.id", id)
+}
+
+func (this Comment) Label() string {
+	return strings.SplitN(this.Body, "\n", 2)[0]
+}
+
+func (this Comment) Delete(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, this.auditQuery(ctx, "D")+"DELETE FROM comments WHERE id = $1 AND revision = $2", this.ID, this.Revision)
+	return err
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|\s)@([^\s@]+@[^\s@]+\.[^\s@.,;:!?)]+)`)
+
+// MentionedEmails picks out the @someone@example.com mentions in a comment body.
+func MentionedEmails(body string) []string {
+	ret := []string{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		ret = append(ret, strings.ToLower(match[1]))
+	}
+	return ret
+}
+
+// ResolveMentions matches the emails mentioned in the body against members of the organisation,
+// records them on the comment and returns the ones that weren't already mentioned before this edit.
+func (this *Comment) ResolveMentions(members OrganisationUsers) OrganisationUsers {
+	mentioned := map[string]bool{}
+	for _, email := range MentionedEmails(this.Body) {
+		mentioned[email] = true
+	}
+
+	previously := map[string]bool{}
+	for _, id := range this.Mentions.Strings {
+		previously[id] = true
+	}
+
+	fresh := OrganisationUsers{}
+	ids := NullStringList{Valid: true, Strings: []string{}}
+	for _, member := range members.Data {
+		if !mentioned[strings.ToLower(member.Email)] {
+			continue
+		}
+		if util.Contains(ids.Strings, member.UserID) {
+			continue
+		}
+		ids.Strings = append(ids.Strings, member.UserID)
+		if !previously[member.UserID] {
+			fresh.Data = append(fresh.Data, member)
+		}
+	}
+
+	this.Mentions = ids
+	return fresh
+}
+
+// NotifyMentions lets each newly mentioned member know with a flash next time they load a page
+// and an email via the send_email queue.
+func (this Comment) NotifyMentions(ctx context.Context, author User, org Organisation, mentioned OrganisationUsers) error {
+	target, err := this.Target(ctx)
+	if err != nil {
+		return err
+	}
+
+	entityLabel, err := this.EntityLabel(ctx)
+	if err != nil {
+		return err
+	}
+
+	link := fmt.Sprintf("%s/%s/%s", config.URI, target.Path, this.EntityID)
+
+	for _, member := range mentioned.Data {
+		if member.UserID == author.ID {
+			continue
+		}
+
+		user := User{}
+		if err := user.FindByID(ctx, member.UserID); err != nil {
+			return err
+		}
+
+		if _, err := user.PersistFlash(ctx, flashes.Flash{
+			Persistent:  true,
+			Type:        flashes.Info,
+			Text:        fmt.Sprintf("%s mentioned you on %s", author.Email, entityLabel),
+			OnceOnlyKey: "comment-mention-" + this.ID,
+			Actions: []flashes.FlashAction{
+				{Url: "/" + target.Path + "/" + this.EntityID, Text: "View"},
+			},
+		}); err != nil {
+			return err
+		}
+
+		if !user.HasEmail() {
+			continue
+		}
+
+		emailHTML, emailText := copy.MentionEmail(author.Email, entityLabel, this.Body, link)
+
+		mail := notifications.Email{
+			To:      user.Email,
+			From:    fmt.Sprintf("%s <%s>", config.NAME, config.SYSTEM_EMAIL_ONLY),
+			ReplyTo: config.SYSTEM_EMAIL_ONLY,
+			Text:    emailText,
+			HTML:    emailHTML,
+			Subject: fmt.Sprintf("%s mentioned you on %s", author.Email, entityLabel),
+		}
+
+		task := kewpie.Task{}
+		if err := task.Marshal(mail); err != nil {
+			return err
+		}
+
+		task.Tags.Set("user_id", user.ID)
+		task.Tags.Set("organisation_id", org.ID)
+
+		if err := Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type Comments struct {
+	Data     []Comment
+	Criteria Criteria
+}
+
+func (this Comments) colmap() *Colmap {
+	r := Comment{}
+	return r.colmap()
+}
+
+func (Comments) AvailableFilters() Filters {
+	return standardFilters("comments")
+}
+
+func (this *Comments) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "comments"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "comments"}
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "comments LEFT JOIN users ON comments.user_id = users.id", criteria.Filters, criteria.Pagination, Order{By: "comments.created_at"}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		comment := Comment{}
+		props := comment.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, comment)
+	}
+	return err
+}
+
+// TimelineEntry is one item in an entity's activity thread, either a comment or a change
+// recorded in the audit log.
+type TimelineEntry struct {
+	Stamp   time.Time
+	Comment Comment
+	Audit   Audit
+}
+
+func (this TimelineEntry) IsComment() bool {
+	return this.Comment.ID != ""
+}
+
+type Timeline []TimelineEntry
+
+// BuildTimeline interleaves comments with audit entries, newest first.
+func BuildTimeline(comments Comments, audits Audits) Timeline {
+	ret := Timeline{}
+	for _, comment := range comments.Data {
+		ret = append(ret, TimelineEntry{Stamp: comment.CreatedAt, Comment: comment})
+	}
+	for _, audit := range audits.Data {
+		ret = append(ret, TimelineEntry{Stamp: audit.Stamp, Audit: audit})
+	}
+	sort.SliceStable(ret, func(i, j int) bool {
+		return ret[i].Stamp.After(ret[j].Stamp)
+	})
+	return ret
+}
@@ -0,0 +1,205 @@
+package models
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, commentFix())
+	modelCollectionsUnderTest = append(modelCollectionsUnderTest, commentsFix())
+}
+
+func commentFixture(organisationID, entityID, userID string) (c Comment) {
+	c.New(organisationID, "SomeThing", entityID, userID, randString())
+	return
+}
+
+func (Comment) blank() model {
+	return &Comment{}
+}
+
+func (c Comment) id() string {
+	return c.ID
+}
+
+func (c *Comment) nullDynamicValues() {
+	c.CreatedAt = time.Time{}
+	c.UpdatedAt = time.Time{}
+	c.Revision = ""
+	c.UserEmail = sql.NullString{}
+}
+
+func (Comment) tablename() string {
+	return "comments"
+}
+
+func (Comments) tablename() string {
+	return "comments"
+}
+
+func (Comments) blank() models {
+	return &Comments{}
+}
+
+func commentsFix() modelCollectionFixture {
+	org := organisationFixture()
+	user := userFixture()
+	someThing := someThingFixture(org.ID)
+
+	return modelCollectionFixture{
+		deps: []model{&org, &user, &someThing},
+		collection: &Comments{
+			Data: []Comment{
+				commentFixture(org.ID, someThing.ID, user.ID),
+				commentFixture(org.ID, someThing.ID, user.ID),
+			},
+		},
+	}
+}
+
+func commentFix() []model {
+	org := organisationFixture()
+	user := userFixture()
+	someThing := someThingFixture(org.ID)
+
+	fix := commentFixture(org.ID, someThing.ID, user.ID)
+	return []model{
+		&org,
+		&user,
+		&someThing,
+		&fix,
+	}
+}
+
+func (this Comments) data() []model {
+	ret := []model{}
+	for _, m := range this.Data {
+		ret = append(ret, &m)
+	}
+	return ret
+}
+
+func TestCommentRejectsUnknownEntityType(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	fix := Comment{}
+	fix.New(org.ID, "NotAThing", org.ID, "", randString())
+	assert.IsType(t, ClientSafeError{}, fix.Save(ctx))
+
+	closeTx(t, ctx)
+}
+
+func TestCommentTargetsBelongToOrganisations(t *testing.T) {
+	for _, target := range CommentTargets() {
+		assert.NotEqual(t, "users", target.Tablename)
+	}
+}
+
+func TestCommentRejectsOtherOrganisationsEntities(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	other := organisationFixture()
+	assert.Nil(t, other.Save(ctx))
+	someThing := someThingFixture(other.ID)
+	assert.Nil(t, someThing.Save(ctx))
+
+	fix := commentFixture(org.ID, someThing.ID, "")
+	assert.IsType(t, ClientSafeError{}, fix.Save(ctx))
+
+	closeTx(t, ctx)
+}
+
+func TestCommentOnOrganisation(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	other := organisationFixture()
+	assert.Nil(t, other.Save(ctx))
+
+	fix := Comment{}
+	fix.New(org.ID, "Organisation", org.ID, "", randString())
+	assert.Nil(t, fix.Save(ctx))
+
+	label, err := fix.EntityLabel(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, org.Name, label)
+
+	elsewhere := Comment{}
+	elsewhere.New(org.ID, "Organisation", other.ID, "", randString())
+	assert.IsType(t, ClientSafeError{}, elsewhere.Save(ctx))
+}
+
+func TestCommentOnMember(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	user := userFixture()
+	assert.Nil(t, user.Save(ctx))
+	member := organisationUserFixture(user.ID, org.ID)
+	assert.Nil(t, member.Save(ctx))
+
+	fix := Comment{}
+	fix.New(org.ID, "Member", member.ID, "", randString())
+	assert.Nil(t, fix.Save(ctx))
+
+	label, err := fix.EntityLabel(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, user.Email, label)
+}
+
+func TestMentionedEmails(t *testing.T) {
+	assert.Equal(t, []string{"jo@example.com", "sam@example.org"}, MentionedEmails("hey @Jo@Example.com, can you and @sam@example.org look? not.me@example.com"))
+	assert.Equal(t, []string{}, MentionedEmails("nobody here"))
+}
+
+func TestResolveMentions(t *testing.T) {
+	members := OrganisationUsers{Data: []OrganisationUser{
+		{UserID: "a", Email: "a@example.com"},
+		{UserID: "b", Email: "b@example.com"},
+	}}
+
+	fix := Comment{}
+	fix.New("org", "SomeThing", "thing", "author", "@a@example.com and @stranger@example.com")
+
+	fresh := fix.ResolveMentions(members)
+	assert.Equal(t, 1, len(fresh.Data))
+	assert.Equal(t, []string{"a"}, []string(fix.Mentions.Strings))
+
+	fix.Body = "@a@example.com and now @b@example.com"
+	fresh = fix.ResolveMentions(members)
+	assert.Equal(t, 1, len(fresh.Data))
+	assert.Equal(t, "b", fresh.Data[0].UserID)
+	assert.Equal(t, []string{"a", "b"}, []string(fix.Mentions.Strings))
+}
+
+func TestBuildTimeline(t *testing.T) {
+	now := time.Now()
+
+	timeline := BuildTimeline(
+		Comments{Data: []Comment{{ID: "old", CreatedAt: now.Add(-2 * time.Hour)}, {ID: "new", CreatedAt: now}}},
+		Audits{Data: []Audit{{ID: "middle", Stamp: now.Add(-time.Hour)}}},
+	)
+
+	assert.Equal(t, 3, len(timeline))
+	assert.Equal(t, "new", timeline[0].Comment.ID)
+	assert.Equal(t, "middle", timeline[1].Audit.ID)
+	assert.False(t, timeline[1].IsComment())
+	assert.Equal(t, "old", timeline[2].Comment.ID)
+}
@@ -20,6 +20,7 @@ type Communication struct {
 	UserID         sql.NullString
 	Channel        string
 	Subject        string
+	BroadcastID    sql.NullString
 }
 
 func (this *Communication) colmap() *Colmap {
@@ -32,6 +33,7 @@ func (this *Communication) colmap() *Colmap {
 		"user_id":         &this.UserID,
 		"channel":         &this.Channel,
 		"subject":         &this.Subject,
+		"broadcast_id":    &this.BroadcastID,
 	}
 }
 
@@ -54,6 +56,20 @@ func LogUserCommunication(ctx context.Context, organisationID string, user User,
 	return communication.Save(ctx)
 }
 
+func LogBroadcastCommunication(ctx context.Context, organisationID, broadcastID string, user User, channel, subject string) error {
+	communication := Communication{}
+	communication.New(organisationID, channel, subject)
+	communication.UserID = sql.NullString{
+		Valid:  true,
+		String: user.ID,
+	}
+	communication.BroadcastID = sql.NullString{
+		Valid:  true,
+		String: broadcastID,
+	}
+	return communication.Save(ctx)
+}
+
 func (communication *Communication) auditQuery(ctx context.Context, action string) string {
 	return auditQuery(ctx, action, "communications", communication.ID, communication.OrganisationID)
 }
@@ -79,6 +95,29 @@ func (this *Communication) FindByColumn(ctx context.Context, col, val string) er
 	return StandardExecFindByColumn(ctx, q, val, props)
 }
 
+// PruneCommunications removes the record of emails sent by organisations that only keep them for a
+// while, as set by CommunicationRetentionDays.
+func PruneCommunications(ctx context.Context, now time.Time) error {
+	db := ctx.Value("tx").(Querier)
+
+	orgs := Organisations{}
+	if err := orgs.FindAll(ctx, Criteria{Query: &All{}}); err != nil {
+		return err
+	}
+
+	for _, org := range orgs.Data {
+		days := org.Settings.ByKey(CommunicationRetentionDays.Key).Int()
+		if days <= 0 {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, "DELETE FROM communications WHERE organisation_id = $1 AND created_at < $2", org.ID, now.AddDate(0, 0, -days)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type Communications struct {
 	Data     []Communication
 	Criteria Criteria
@@ -119,6 +158,14 @@ func (Communications) AvailableFilters() Filters {
 	}
 }
 
+func (Communications) Sortables() Sortables {
+	return Sortables{
+		{Key: "sent", Label: "Sent", Col: "communications.created_at"},
+		{Key: "channel", Label: "Channel", Col: "communications.channel"},
+		{Key: "subject", Label: "Subject", Col: "communications.subject"},
+	}
+}
+
 func (Communications) Searchable() Searchable {
 	return Searchable{
 		EntityType: "Communication",
@@ -148,21 +195,31 @@ func (this *Communications) FindAll(ctx context.Context, criteria Criteria) erro
 			return ErrInvalidQuery{Query: v, Model: "communications"}
 		}
 	case Query:
-		rows, err = db.QueryContext(ctx, v.Construct(cols, "communications", criteria.Filters, criteria.Pagination, Order{By: "created_at"}), v.Args()...)
+		if err := this.Criteria.Cursor.count(ctx, v, "communications", criteria.Filters); err != nil {
+			return err
+		}
+		rows, err = db.QueryContext(ctx, construct(v, cols, "communications", criteria, this.Sortables(), Order{By: "created_at"}), v.Args()...)
 	}
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
+	positions := []string{}
 	for rows.Next() {
 		communication := Communication{}
+		position := ""
 		props := communication.colmap().ByKeys(cols)
+		if criteria.Cursor.Enabled {
+			props = append(props, &position)
+		}
 		if err := rows.Scan(props...); err != nil {
 			return err
 		}
 		(*this).Data = append((*this).Data, communication)
+		positions = append(positions, position)
 	}
+	this.Data = keysetPage(this.Data, positions, &this.Criteria.Cursor)
 	return err
 }
 
@@ -0,0 +1,182 @@
+package models
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Cursor is keyset pagination. Rather than skipping rows with OFFSET, each page picks up after the
+// last row of the one before, so it stays fast however deep into a large table you go. The position
+// is handed around as an opaque token holding that row's sort values and ID.
+type Cursor struct {
+	Enabled         bool
+	Limit           int
+	DefaultPageSize int
+	After           []any
+	// Next is the token for the following page, set by FindAll. It's empty on the last page.
+	Next string
+	// Count asks FindAll to also count every matching row into Total. It costs a scan of all of them.
+	Count bool
+	Total int
+}
+
+var ErrInvalidCursor = ClientSafeError{Message: "That page link isn't valid"}
+
+// Paginate switches the criteria to keyset pagination and reads the page size, position and
+// whether to count from the form.
+func (this *Cursor) Paginate(form url.Values) error {
+	this.Enabled = true
+	this.Limit = this.DefaultPageSize
+	if limit, err := strconv.Atoi(form.Get("limit")); err == nil && limit > 0 {
+		this.Limit = limit
+	}
+	this.Count = form.Get("count") == "true"
+
+	token := form.Get("cursor")
+	if token == "" {
+		return nil
+	}
+	after, err := decodeCursor(token)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+	this.After = after
+	return nil
+}
+
+func (this Cursor) NextPage() url.Values {
+	return url.Values{"cursor": {this.Next}}
+}
+
+func (this Cursor) FirstPage() url.Values {
+	return url.Values{}
+}
+
+// Counted is the current page again, with a total.
+func (this Cursor) Counted() url.Values {
+	values := url.Values{"count": {"true"}}
+	if this.After != nil {
+		raw, _ := json.Marshal(this.After)
+		values.Set("cursor", base64.RawURLEncoding.EncodeToString(raw))
+	}
+	return values
+}
+
+func decodeCursor(token string) ([]any, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(raw)))
+	decoder.UseNumber()
+	after := []any{}
+	if err := decoder.Decode(&after); err != nil {
+		return nil, err
+	}
+	return after, nil
+}
+
+// construct builds a list query for the page after the cursor. It selects one extra column, the
+// row's position, and one extra row to find out whether there's a page after this one.
+func (this Cursor) construct(q Query, cols []string, table string, criteria Criteria, sortables Sortables, fallback Order) string {
+	terms := criteria.Sort.terms(sortables)
+	if len(terms) == 0 {
+		terms = []orderTerm{{Col: fallback.By, Desc: fallback.Desc}}
+	}
+	terms = append(terms, idTerm(table))
+
+	filters := append(Filters{}, criteria.Filters...)
+	// A cursor from a differently sorted list doesn't mean anything here, so start from the top.
+	if len(this.After) == len(terms) {
+		filters = append(filters, keyset{terms: terms, after: this.After})
+	}
+
+	positionCols := []string{}
+	for _, term := range terms {
+		positionCols = append(positionCols, term.Col)
+	}
+	selected := append(append([]string{}, cols...), "json_build_array("+strings.Join(positionCols, ", ")+")::text")
+
+	ret := q.Construct(selected, table, filters, Pagination{}, Order{}) + orderBy(terms)
+	if this.Limit > 0 {
+		ret += fmt.Sprintf(" LIMIT %d", this.Limit+1)
+	}
+	return ret
+}
+
+// count fills in Total if it was asked for. q is copied first because Construct accumulates args
+// on the query it's called on.
+func (this *Cursor) count(ctx context.Context, q Query, table string, filters Filters) error {
+	if !this.Enabled || !this.Count {
+		return nil
+	}
+
+	db := ctx.Value("tx").(Querier)
+
+	fresh := reflect.New(reflect.TypeOf(q).Elem())
+	fresh.Elem().Set(reflect.ValueOf(q).Elem())
+	counter := fresh.Interface().(Query)
+
+	return db.QueryRowContext(ctx, counter.Construct([]string{"count(*)"}, table, filters, Pagination{}, Order{}), counter.Args()...).Scan(&this.Total)
+}
+
+// keysetPage drops the extra row a cursor query fetches and sets Next from the last row kept.
+// positions are the rows' position columns, in the same order as data.
+func keysetPage[T any](data []T, positions []string, cursor *Cursor) []T {
+	cursor.Next = ""
+	if !cursor.Enabled || cursor.Limit == 0 || len(data) <= cursor.Limit {
+		return data
+	}
+	cursor.Next = base64.RawURLEncoding.EncodeToString([]byte(positions[cursor.Limit-1]))
+	return data[:cursor.Limit]
+}
+
+// keyset matches the rows that sort after a position. With mixed directions a row comparison
+// won't do, so it's spelled out term by term: a > x OR (a = x AND b > y) OR ...
+type keyset struct {
+	terms []orderTerm
+	after []any
+}
+
+func (this keyset) Query(propIndex int) (string, []any) {
+	clauses := []string{}
+	for i, term := range this.terms {
+		parts := []string{}
+		for j, prior := range this.terms[:i] {
+			parts = append(parts, fmt.Sprintf("%s = $%d", prior.Col, propIndex+j))
+		}
+		op := ">"
+		if term.Desc {
+			op = "<"
+		}
+		parts = append(parts, fmt.Sprintf("%s %s $%d", term.Col, op, propIndex+i))
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return "(" + strings.Join(clauses, " OR ") + ")", this.after
+}
+
+func (keyset) Label() string {
+	return ""
+}
+
+func (keyset) ID() string {
+	return ""
+}
+
+func (keyset) Populate(url.Values) error {
+	return nil
+}
+
+func (keyset) Inputs() []string {
+	return []string{}
+}
+
+func (keyset) TableName() string {
+	return ""
+}
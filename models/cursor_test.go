@@ -0,0 +1,119 @@
+package models
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysetQuery(t *testing.T) {
+	t.Parallel()
+
+	filter := keyset{
+		terms: []orderTerm{{Col: "communications.channel", Desc: true}, {Col: "communications.id"}},
+		after: []any{"sms", "abc"},
+	}
+
+	q, props := filter.Query(3)
+	assert.Equal(t, "((communications.channel < $3) OR (communications.channel = $3 AND communications.id > $4))", q)
+	assert.Equal(t, []any{"sms", "abc"}, props)
+}
+
+func TestCursorPaginate(t *testing.T) {
+	t.Parallel()
+
+	cursor := Cursor{DefaultPageSize: 50}
+	assert.Nil(t, cursor.Paginate(url.Values{}))
+	assert.True(t, cursor.Enabled)
+	assert.Equal(t, 50, cursor.Limit)
+	assert.Nil(t, cursor.After)
+
+	data := keysetPage([]int{1, 2, 3}, []string{`["a", "1"]`, `["b", "2"]`, `["c", "3"]`}, &Cursor{Enabled: true, Limit: 2})
+	assert.Equal(t, []int{1, 2}, data)
+
+	next := Cursor{Enabled: true, Limit: 2}
+	keysetPage([]int{1, 2, 3}, []string{`["a", "1"]`, `["b", "2"]`, `["c", "3"]`}, &next)
+
+	following := Cursor{}
+	assert.Nil(t, following.Paginate(next.NextPage()))
+	assert.Equal(t, []any{"b", "2"}, following.After)
+
+	last := Cursor{Enabled: true, Limit: 5}
+	keysetPage([]int{1, 2, 3}, []string{`["a", "1"]`, `["b", "2"]`, `["c", "3"]`}, &last)
+	assert.Equal(t, "", last.Next)
+
+	assert.Equal(t, ErrInvalidCursor, (&Cursor{}).Paginate(url.Values{"cursor": {"not a cursor"}}))
+}
+
+func TestCursorConstruct(t *testing.T) {
+	t.Parallel()
+
+	criteria := Criteria{
+		Query:   &ByOrg{ID: "org"},
+		Filters: Filters{},
+		Sort:    Sort{{Key: "sent", Desc: true}},
+		Cursor:  Cursor{Enabled: true, Limit: 10, After: []any{"2026-10-19T00:00:00+00:00", "abc"}},
+	}
+
+	q := construct(criteria.Query, []string{"communications.id"}, "communications", criteria, (Communications{}).Sortables(), Order{By: "created_at"})
+	assert.Contains(t, q, "json_build_array(communications.created_at, communications.id)::text")
+	assert.Contains(t, q, "((communications.created_at < $2) OR (communications.created_at = $2 AND communications.id > $3))")
+	assert.Contains(t, q, " ORDER BY communications.created_at DESC, communications.id ASC LIMIT 11")
+	assert.NotContains(t, q, "OFFSET")
+	assert.Equal(t, []any{"org", "2026-10-19T00:00:00+00:00", "abc"}, criteria.Query.Args())
+
+	// A cursor from a list sorted some other way is ignored rather than misapplied
+	criteria.Query = &ByOrg{ID: "org"}
+	criteria.Sort = Sort{}
+	criteria.Cursor.After = []any{"sms", "email", "abc"}
+	q = construct(criteria.Query, []string{"communications.id"}, "communications", criteria, (Communications{}).Sortables(), Order{By: "created_at"})
+	assert.Contains(t, q, " ORDER BY created_at ASC, communications.id ASC LIMIT 11")
+	assert.Equal(t, []any{"org"}, criteria.Query.Args())
+}
+
+func TestCommunicationsKeysetPagination(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	user := userFixture()
+	assert.Nil(t, user.Save(ctx))
+
+	stamp := time.Now()
+	for i := 0; i < 5; i++ {
+		comm := communicationFixture(user, org)
+		comm.Sent = stamp.Add(time.Duration(i) * time.Minute)
+		assert.Nil(t, comm.Save(ctx))
+	}
+
+	seen := map[string]bool{}
+	form := url.Values{"limit": {"2"}, "count": {"true"}}
+	for page := 0; page < 3; page++ {
+		criteria := Criteria{Query: &ByOrg{ID: org.ID}}
+		assert.Nil(t, criteria.Cursor.Paginate(form))
+
+		comms := Communications{}
+		assert.Nil(t, comms.FindAll(ctx, criteria))
+		assert.Equal(t, 5, comms.Criteria.Cursor.Total)
+
+		for _, comm := range comms.Data {
+			assert.False(t, seen[comm.ID])
+			seen[comm.ID] = true
+		}
+
+		if page < 2 {
+			assert.Len(t, comms.Data, 2)
+			assert.NotEqual(t, "", comms.Criteria.Cursor.Next)
+		} else {
+			assert.Len(t, comms.Data, 1)
+			assert.Equal(t, "", comms.Criteria.Cursor.Next)
+		}
+		form.Set("cursor", comms.Criteria.Cursor.Next)
+	}
+	assert.Len(t, seen, 5)
+
+	closeTx(t, ctx)
+}
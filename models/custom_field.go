@@ -0,0 +1,455 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	scumutil "github.com/davidbanham/scum/util"
+	uuid "github.com/satori/go.uuid"
+)
+
+const (
+	FieldTypeText    = "text"
+	FieldTypeNumber  = "number"
+	FieldTypeDate    = "date"
+	FieldTypeSelect  = "select"
+	FieldTypeBoolean = "boolean"
+)
+
+var ValidFieldTypes = []string{
+	FieldTypeText,
+	FieldTypeNumber,
+	FieldTypeDate,
+	FieldTypeSelect,
+	FieldTypeBoolean,
+}
+
+// CustomField defines an extra attribute an organisation records against its SomeThings.
+// Values live in the custom_fields JSONB column on some_things, keyed by Key.
+type CustomField struct {
+	ID             string
+	Revision       string
+	OrganisationID string
+	Key            string
+	FieldLabel     string
+	FieldType      string
+	Options        NullStringList
+	Required       bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (this *CustomField) colmap() *Colmap {
+	return &Colmap{
+		"id":              &this.ID,
+		"revision":        &this.Revision,
+		"organisation_id": &this.OrganisationID,
+		"key":             &this.Key,
+		"label":           &this.FieldLabel,
+		"field_type":      &this.FieldType,
+		"options":         &this.Options,
+		"required":        &this.Required,
+		"created_at":      &this.CreatedAt,
+		"updated_at":      &this.UpdatedAt,
+	}
+}
+
+func (this *CustomField) New(organisationID, label, fieldType string, options []string, required bool) {
+	this.ID = uuid.NewV4().String()
+	this.OrganisationID = organisationID
+	this.Key = FieldKey(label)
+	this.FieldLabel = strings.TrimSpace(label)
+	this.FieldType = fieldType
+	this.Options = NullStringList{Valid: true, Strings: []string{}}
+	for _, option := range options {
+		if option = strings.TrimSpace(option); option != "" {
+			this.Options.Strings = append(this.Options.Strings, option)
+		}
+	}
+	this.Required = required
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+var fieldKeyPattern = regexp.MustCompile(`[^a-z0-9]+`)
+
+// FieldKey derives the JSON key a field's values are stored under from its label.
+func FieldKey(label string) string {
+	return strings.Trim(fieldKeyPattern.ReplaceAllString(strings.ToLower(label), "_"), "_")
+}
+
+func (this *CustomField) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "custom_fields", this.ID, this.OrganisationID)
+}
+
+func (this *CustomField) Save(ctx context.Context) error {
+	if this.Key == "" || this.FieldLabel == "" {
+		return ClientSafeError{Message: "A field needs a label made of letters or numbers"}
+	}
+
+	valid := false
+	for _, t := range ValidFieldTypes {
+		if this.FieldType == t {
+			valid = true
+		}
+	}
+	if !valid {
+		return ClientSafeError{Message: fmt.Sprintf("%s is not a valid field type", this.FieldType)}
+	}
+
+	if this.FieldType == FieldTypeSelect && len(this.Options.Strings) == 0 {
+		return ClientSafeError{Message: "A select field needs at least one option"}
+	}
+
+	q, props, newRev := StandardSave("custom_fields", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		if isUniqueViolation(err, "custom_fields_organisation_id_key_key") {
+			return ClientSafeError{Message: "A field with that label already exists"}
+		}
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *CustomField) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("custom_fields", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *CustomField) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this CustomField) Label() string {
+	return this.FieldLabel
+}
+
+// Delete removes the definition along with any values recorded against it.
+func (this CustomField) Delete(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	if _, err := db.ExecContext(ctx, this.auditQuery(ctx, "D")+"DELETE FROM custom_fields WHERE id = $1 AND revision = $2", this.ID, this.Revision); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, "UPDATE some_things SET custom_fields = custom_fields - $2::text WHERE organisation_id = $1 AND custom_fields ? $2::text", this.OrganisationID, this.Key)
+	return err
+}
+
+// Parse turns a form value into the JSON value stored for this field.
+func (this CustomField) Parse(input string) (any, error) {
+	input = strings.TrimSpace(input)
+
+	switch this.FieldType {
+	case FieldTypeNumber:
+		n, err := strconv.ParseFloat(input, 64)
+		if err != nil {
+			return nil, ClientSafeError{Message: fmt.Sprintf("%s must be a number", this.FieldLabel)}
+		}
+		return n, nil
+	case FieldTypeBoolean:
+		return input == "true" || input == "on", nil
+	}
+
+	return input, this.Validate(input)
+}
+
+// Validate checks a stored value is the right shape for the field.
+func (this CustomField) Validate(value any) error {
+	switch this.FieldType {
+	case FieldTypeText:
+		if _, ok := value.(string); !ok {
+			return ClientSafeError{Message: fmt.Sprintf("%s must be text", this.FieldLabel)}
+		}
+	case FieldTypeNumber:
+		if _, ok := value.(float64); !ok {
+			return ClientSafeError{Message: fmt.Sprintf("%s must be a number", this.FieldLabel)}
+		}
+	case FieldTypeDate:
+		s, ok := value.(string)
+		if !ok {
+			return ClientSafeError{Message: fmt.Sprintf("%s must be a date", this.FieldLabel)}
+		}
+		if _, err := time.Parse("2006-01-02", s); err != nil {
+			return ClientSafeError{Message: fmt.Sprintf("%s must be a date", this.FieldLabel)}
+		}
+	case FieldTypeSelect:
+		s, ok := value.(string)
+		if !ok || !this.hasOption(s) {
+			return ClientSafeError{Message: fmt.Sprintf("%s must be one of %s", this.FieldLabel, strings.Join(this.Options.Strings, ", "))}
+		}
+	case FieldTypeBoolean:
+		if _, ok := value.(bool); !ok {
+			return ClientSafeError{Message: fmt.Sprintf("%s must be yes or no", this.FieldLabel)}
+		}
+	default:
+		return ClientSafeError{Message: fmt.Sprintf("%s is not a valid field type", this.FieldType)}
+	}
+	return nil
+}
+
+func (this CustomField) hasOption(option string) bool {
+	for _, o := range this.Options.Strings {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
+
+// Filters are the list filters generated for the field, shaped by its type.
+func (this CustomField) Filters(table string) Filters {
+	id := "field-" + this.Key
+
+	switch this.FieldType {
+	case FieldTypeBoolean:
+		return Filters{
+			&JSONBContains{FilterID: id + "-yes", FilterLabel: this.FieldLabel + ": Yes", Table: table, Col: "custom_fields", Document: map[string]any{this.Key: true}},
+			&JSONBContains{FilterID: id + "-no", FilterLabel: this.FieldLabel + ": No", Table: table, Col: "custom_fields", Document: map[string]any{this.Key: false}},
+		}
+	case FieldTypeSelect:
+		ret := Filters{}
+		for _, option := range this.Options.Strings {
+			ret = append(ret, &JSONBContains{FilterID: id + "-" + FieldKey(option), FilterLabel: this.FieldLabel + ": " + option, Table: table, Col: "custom_fields", Document: map[string]any{this.Key: option}})
+		}
+		return ret
+	case FieldTypeDate:
+		return Filters{
+			&JSONBDateBetween{FilterID: id, FilterLabel: this.FieldLabel + " Between", Table: table, Col: "custom_fields", Key: this.Key, Range: scumutil.Period{
+				Start: time.Now().AddDate(0, -1, 0),
+				End:   time.Now().AddDate(0, 1, 0),
+			}},
+		}
+	}
+
+	return Filters{
+		&JSONBHasKey{FilterID: id + "-set", FilterLabel: "Has " + this.FieldLabel, Table: table, Col: "custom_fields", Key: this.Key},
+	}
+}
+
+type CustomFields struct {
+	Data     []CustomField
+	Criteria Criteria
+}
+
+func (this CustomFields) colmap() *Colmap {
+	r := CustomField{}
+	return r.colmap()
+}
+
+func (CustomFields) AvailableFilters() Filters {
+	return standardFilters("custom_fields")
+}
+
+func (this CustomFields) ByKey() map[string]CustomField {
+	ret := map[string]CustomField{}
+	for _, field := range this.Data {
+		ret[field.Key] = field
+	}
+	return ret
+}
+
+func (this *CustomFields) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "custom_fields"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "custom_fields"}
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "custom_fields", criteria.Filters, criteria.Pagination, Order{By: "created_at"}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		field := CustomField{}
+		props := field.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, field)
+	}
+	return err
+}
+
+// CustomFieldValues holds a row's custom field values keyed by CustomField.Key, stored as a JSONB object.
+type CustomFieldValues map[string]any
+
+func (this CustomFieldValues) Value() (driver.Value, error) {
+	if len(this) == 0 {
+		return "{}", nil
+	}
+	return json.Marshal(this)
+}
+
+func (this *CustomFieldValues) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(b, &this)
+}
+
+// Display formats a value for showing in a form input.
+func (this CustomFieldValues) Display(key string) string {
+	switch v := this[key].(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// JSONBContains matches rows where the JSONB column contains the given document.
+type JSONBContains struct {
+	FilterID    string
+	FilterLabel string
+	Table       string
+	Col         string
+	Document    any
+}
+
+func (this JSONBContains) Query(propIndex int) (string, []any) {
+	doc, _ := json.Marshal(this.Document)
+	return fmt.Sprintf("%s.%s @> $%d::jsonb", this.Table, this.Col, propIndex), []any{string(doc)}
+}
+
+func (this JSONBContains) Label() string {
+	return this.FilterLabel
+}
+
+func (this JSONBContains) ID() string {
+	return this.FilterID
+}
+
+func (this *JSONBContains) Populate(url.Values) error {
+	return nil
+}
+
+func (this JSONBContains) Inputs() []string {
+	return []string{}
+}
+
+func (this JSONBContains) TableName() string {
+	return this.Table
+}
+
+// JSONBHasKey matches rows where the JSONB object has a value for the key.
+type JSONBHasKey struct {
+	FilterID    string
+	FilterLabel string
+	Table       string
+	Col         string
+	Key         string
+}
+
+func (this JSONBHasKey) Query(propIndex int) (string, []any) {
+	return fmt.Sprintf("(%s.%s->>$%d::text) IS NOT NULL", this.Table, this.Col, propIndex), []any{this.Key}
+}
+
+func (this JSONBHasKey) Label() string {
+	return this.FilterLabel
+}
+
+func (this JSONBHasKey) ID() string {
+	return this.FilterID
+}
+
+func (this *JSONBHasKey) Populate(url.Values) error {
+	return nil
+}
+
+func (this JSONBHasKey) Inputs() []string {
+	return []string{}
+}
+
+func (this JSONBHasKey) TableName() string {
+	return this.Table
+}
+
+// JSONBDateBetween matches rows where the date stored under the key falls within the period.
+type JSONBDateBetween struct {
+	FilterID    string
+	FilterLabel string
+	Table       string
+	Col         string
+	Key         string
+	Range       scumutil.Period
+}
+
+func (this JSONBDateBetween) Query(propIndex int) (string, []any) {
+	return fmt.Sprintf("(%s.%s->>$%d::text)::date BETWEEN $%d AND $%d", this.Table, this.Col, propIndex, propIndex+1, propIndex+2), []any{this.Key, this.Range.Start, this.Range.End}
+}
+
+func (this JSONBDateBetween) Label() string {
+	return this.FilterLabel
+}
+
+func (this JSONBDateBetween) ID() string {
+	return this.FilterID
+}
+
+func (this *JSONBDateBetween) Populate(form url.Values) error {
+	start := form.Get(this.FilterID + "-start")
+	end := form.Get(this.FilterID + "-end")
+	if start == "" || end == "" {
+		return nil
+	}
+	s, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return err
+	}
+	e, err := time.Parse("2006-01-02", end)
+	if err != nil {
+		return err
+	}
+	this.Range.Start = s
+	this.Range.End = e
+	return nil
+}
+
+func (this JSONBDateBetween) Period() scumutil.Period {
+	return this.Range
+}
+
+func (this JSONBDateBetween) Inputs() []string {
+	return []string{"start_end_date"}
+}
+
+func (this JSONBDateBetween) TableName() string {
+	return this.Table
+}
@@ -0,0 +1,146 @@
+package models
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, customFieldFix())
+	modelCollectionsUnderTest = append(modelCollectionsUnderTest, customFieldsFix())
+}
+
+func customFieldFixture(organisationID string) (f CustomField) {
+	f.New(organisationID, randString(), FieldTypeSelect, []string{"red", "green"}, false)
+	return
+}
+
+func (CustomField) blank() model {
+	return &CustomField{}
+}
+
+func (f CustomField) id() string {
+	return f.ID
+}
+
+func (f *CustomField) nullDynamicValues() {
+	f.CreatedAt = time.Time{}
+	f.UpdatedAt = time.Time{}
+	f.Revision = ""
+}
+
+func (CustomField) tablename() string {
+	return "custom_fields"
+}
+
+func (CustomFields) tablename() string {
+	return "custom_fields"
+}
+
+func (CustomFields) blank() models {
+	return &CustomFields{}
+}
+
+func customFieldsFix() modelCollectionFixture {
+	org := organisationFixture()
+	return modelCollectionFixture{
+		deps: []model{&org},
+		collection: &CustomFields{
+			Data: []CustomField{
+				customFieldFixture(org.ID),
+				customFieldFixture(org.ID),
+			},
+		},
+	}
+}
+
+func customFieldFix() []model {
+	org := organisationFixture()
+	fix := customFieldFixture(org.ID)
+	return []model{
+		&org,
+		&fix,
+	}
+}
+
+func (this CustomFields) data() []model {
+	ret := []model{}
+	for _, m := range this.Data {
+		ret = append(ret, &m)
+	}
+	return ret
+}
+
+func TestFieldKey(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "due_date", FieldKey("Due Date"))
+	assert.Equal(t, "cost_aud", FieldKey("  Cost (AUD) "))
+}
+
+func TestCustomFieldParse(t *testing.T) {
+	t.Parallel()
+
+	number := CustomField{FieldLabel: "Cost", FieldType: FieldTypeNumber}
+	n, err := number.Parse("12.5")
+	assert.Nil(t, err)
+	assert.Equal(t, 12.5, n)
+	_, err = number.Parse("lots")
+	assert.NotNil(t, err)
+
+	date := CustomField{FieldLabel: "Due", FieldType: FieldTypeDate}
+	d, err := date.Parse("2026-10-19")
+	assert.Nil(t, err)
+	assert.Equal(t, "2026-10-19", d)
+	_, err = date.Parse("next tuesday")
+	assert.NotNil(t, err)
+
+	sel := CustomField{FieldLabel: "Colour", FieldType: FieldTypeSelect, Options: NullStringList{Valid: true, Strings: []string{"red"}}}
+	_, err = sel.Parse("red")
+	assert.Nil(t, err)
+	_, err = sel.Parse("blue")
+	assert.NotNil(t, err)
+
+	boolean := CustomField{FieldLabel: "Urgent", FieldType: FieldTypeBoolean}
+	b, err := boolean.Parse("")
+	assert.Nil(t, err)
+	assert.Equal(t, false, b)
+
+	assert.NotNil(t, number.Validate("12.5"))
+	assert.NotNil(t, boolean.Validate("true"))
+}
+
+func TestCustomFieldFilterQueries(t *testing.T) {
+	t.Parallel()
+
+	contains := JSONBContains{Table: "some_things", Col: "custom_fields", Document: map[string]any{"urgent": true}}
+	q, props := contains.Query(3)
+	assert.Equal(t, "some_things.custom_fields @> $3::jsonb", q)
+	assert.Equal(t, []any{`{"urgent":true}`}, props)
+
+	between := JSONBDateBetween{FilterID: "field-due", Table: "some_things", Col: "custom_fields", Key: "due"}
+	assert.Nil(t, between.Populate(url.Values{"field-due-start": {"2026-01-01"}, "field-due-end": {"2026-02-01"}}))
+	q, props = between.Query(1)
+	assert.Equal(t, "(some_things.custom_fields->>$1::text)::date BETWEEN $2 AND $3", q)
+	assert.Equal(t, 3, len(props))
+	assert.Equal(t, 2026, between.Range.Start.Year())
+}
+
+func TestCustomFieldKeyIsUnique(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	first := customFieldFixture(org.ID)
+	assert.Nil(t, first.Save(ctx))
+
+	second := CustomField{}
+	second.New(org.ID, first.FieldLabel, FieldTypeText, nil, false)
+	assert.Equal(t, ClientSafeError{Message: "A field with that label already exists"}, second.Save(ctx))
+}
@@ -0,0 +1,290 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/copy"
+	"doubleboiler/filestore"
+	"fmt"
+	"io"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/davidbanham/notifications"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DataExportExpiry is how long a finished export can be downloaded before it's removed.
+var DataExportExpiry = 7 * 24 * time.Hour
+
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+)
+
+type DataExport struct {
+	ID       string
+	Revision string
+	UserID   string
+	// OrganisationID is set when the export is of an organisation rather than the user's own data.
+	OrganisationID sql.NullString
+	Filename       string
+	Status         string
+	StorageKey     string
+	ExpiresAt      sql.NullTime
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (this *DataExport) colmap() *Colmap {
+	return &Colmap{
+		"id":              &this.ID,
+		"revision":        &this.Revision,
+		"user_id":         &this.UserID,
+		"organisation_id": &this.OrganisationID,
+		"filename":        &this.Filename,
+		"status":          &this.Status,
+		"storage_key":     &this.StorageKey,
+		"expires_at":      &this.ExpiresAt,
+		"created_at":      &this.CreatedAt,
+		"updated_at":      &this.UpdatedAt,
+	}
+}
+
+func (this *DataExport) New(userID string) {
+	this.ID = uuid.NewV4().String()
+	this.UserID = userID
+	this.Filename = "personal-data.zip"
+	this.Status = DataExportPending
+	this.StorageKey = fmt.Sprintf("exports/%s/%s.zip", userID, this.ID)
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+// NewForOrganisation is an export of everything held by the organisation, for one of its admins.
+func (this *DataExport) NewForOrganisation(userID string, org Organisation) {
+	this.New(userID)
+	this.OrganisationID = sql.NullString{Valid: true, String: org.ID}
+	this.Filename = "organisation-data.zip"
+	this.StorageKey = fmt.Sprintf("exports/organisations/%s/%s.zip", org.ID, this.ID)
+}
+
+func (this *DataExport) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "data_exports", this.ID, this.UserID)
+}
+
+func (this *DataExport) Save(ctx context.Context) error {
+	q, props, newRev := StandardSave("data_exports", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *DataExport) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("data_exports", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *DataExport) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this DataExport) Label() string {
+	return "Data export " + this.CreatedAt.Format("2 January 2006")
+}
+
+func (this DataExport) Downloadable(now time.Time) bool {
+	return this.Status == DataExportReady && this.ExpiresAt.Valid && this.ExpiresAt.Time.After(now)
+}
+
+func (this DataExport) SignedURL(store filestore.Store) (string, error) {
+	return store.SignedURL(this.StorageKey, this.Filename, SignedURLExpiry)
+}
+
+// Request saves the export and queues it up to be built in the background.
+func (this *DataExport) Request(ctx context.Context) error {
+	if err := this.Save(ctx); err != nil {
+		return err
+	}
+
+	task := kewpie.Task{}
+	if err := task.Marshal(map[string]string{"id": this.ID}); err != nil {
+		return err
+	}
+
+	return Enqueue(ctx, config.DATA_EXPORT_QUEUE_NAME, &task)
+}
+
+// Each section of a personal export is a JSON array built by the database. The user's secrets, and the
+// search vectors that would repeat what's already there, are left out. Audit entries are limited
+// to what the user did, without the snapshot of the row, which may well be someone else's data.
+type dataExportSection struct {
+	filename string
+	query    string
+}
+
+var personalDataSections = []dataExportSection{
+	{"user.json", `SELECT to_jsonb(users) - '{password,totp_secret,recovery_codes,flashes,has_flashes}'::text[] FROM users WHERE id = $1`},
+	{"memberships.json", `SELECT to_jsonb(organisations_users) - 'ts' || jsonb_build_object('organisation_name', organisations.name) FROM organisations_users INNER JOIN organisations ON organisations.id = organisations_users.organisation_id WHERE organisations_users.user_id = $1 ORDER BY organisations_users.created_at`},
+	{"communications.json", `SELECT to_jsonb(communications) FROM communications WHERE user_id = $1 ORDER BY created_at`},
+	{"activity.json", `SELECT jsonb_build_object('id', id, 'stamp', stamp, 'action', action, 'table_name', table_name, 'entity_id', entity_id, 'organisation_id', organisation_id, 'reason', reason) FROM audit_log WHERE user_id = $1::text ORDER BY stamp`},
+	{"broadcasts.json", `SELECT to_jsonb(broadcasts) - 'ts' FROM broadcasts WHERE user_id = $1 ORDER BY created_at`},
+	{"comments.json", `SELECT to_jsonb(comments) FROM comments WHERE user_id = $1 ORDER BY created_at`},
+	{"saved_views.json", `SELECT to_jsonb(saved_views) FROM saved_views WHERE user_id = $1 ORDER BY created_at`},
+	{"attachments.json", `SELECT to_jsonb(attachments) - 'storage_key' FROM attachments WHERE user_id = $1 ORDER BY created_at`},
+}
+
+// An organisation's export is everything it holds, audit history included.
+var organisationDataSections = []dataExportSection{
+	{"organisation.json", `SELECT to_jsonb(organisations) - 'ts' FROM organisations WHERE id = $1`},
+	{"members.json", `SELECT to_jsonb(organisations_users) - 'ts' || jsonb_build_object('email', users.email) FROM organisations_users INNER JOIN users ON users.id = organisations_users.user_id WHERE organisations_users.organisation_id = $1 ORDER BY organisations_users.created_at`},
+	{"some_things.json", `SELECT to_jsonb(some_things) - 'ts' FROM some_things WHERE organisation_id = $1 ORDER BY created_at`},
+	{"tags.json", `SELECT to_jsonb(tags) FROM tags WHERE organisation_id = $1 ORDER BY name`},
+	{"custom_fields.json", `SELECT to_jsonb(custom_fields) FROM custom_fields WHERE organisation_id = $1 ORDER BY created_at`},
+	{"comments.json", `SELECT to_jsonb(comments) FROM comments WHERE organisation_id = $1 ORDER BY created_at`},
+	{"broadcasts.json", `SELECT to_jsonb(broadcasts) - 'ts' FROM broadcasts WHERE organisation_id = $1 ORDER BY created_at`},
+	{"communications.json", `SELECT to_jsonb(communications) FROM communications WHERE organisation_id = $1 ORDER BY created_at`},
+	{"saved_views.json", `SELECT to_jsonb(saved_views) FROM saved_views WHERE organisation_id = $1 ORDER BY created_at`},
+	{"attachments.json", `SELECT to_jsonb(attachments) - 'storage_key' FROM attachments WHERE organisation_id = $1 ORDER BY created_at`},
+	{"audit_log.json", `SELECT to_jsonb(audit_log) FROM audit_log WHERE organisation_id = $1 ORDER BY stamp`},
+}
+
+// Build gathers everything held about the user, or their organisation, into a ZIP of JSON files
+// along with any uploaded files, puts it in the store and emails them a link to it.
+func (this *DataExport) Build(ctx context.Context, store filestore.Store) error {
+	db := ctx.Value("tx").(Querier)
+
+	user := User{}
+	if err := user.FindByID(ctx, this.UserID); err != nil {
+		return err
+	}
+
+	sections, subjectID, ownerCol, description := personalDataSections, this.UserID, "attachments.user_id", "your personal data"
+	lifetime := DataExportExpiry
+	if this.OrganisationID.Valid {
+		org := Organisation{}
+		if err := org.FindByID(ctx, this.OrganisationID.String); err != nil {
+			return err
+		}
+		sections, subjectID, ownerCol, description = organisationDataSections, org.ID, "attachments.organisation_id", "the data held for "+org.Name
+		lifetime = org.Settings.ByKey(DataExportLifetime.Key).Duration()
+	}
+
+	buf := &bytes.Buffer{}
+	archive := zip.NewWriter(buf)
+
+	for _, section := range sections {
+		var data []byte
+		if err := db.QueryRowContext(ctx, fmt.Sprintf("SELECT COALESCE(jsonb_pretty(jsonb_agg(data)), '[]') FROM (%s) AS sections(data)", section.query), subjectID).Scan(&data); err != nil {
+			return err
+		}
+		f, err := archive.Create(section.filename)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(data); err != nil {
+			return err
+		}
+	}
+
+	attachments := Attachments{}
+	if err := attachments.FindAll(ctx, Criteria{
+		Query:   &All{},
+		Filters: Filters{&Custom{Col: ownerCol, Values: []string{subjectID}}},
+	}); err != nil {
+		return err
+	}
+	for _, attachment := range attachments.Data {
+		if err := addToArchive(ctx, archive, store, fmt.Sprintf("attachments/%s/%s", attachment.ID, attachment.Name), attachment.StorageKey); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+
+	if err := store.Put(ctx, this.StorageKey, "application/zip", buf); err != nil {
+		return err
+	}
+
+	this.Status = DataExportReady
+	this.ExpiresAt = sql.NullTime{Valid: true, Time: time.Now().Add(lifetime)}
+	if err := this.Save(ctx); err != nil {
+		store.Delete(ctx, this.StorageKey)
+		return err
+	}
+
+	emailHTML, emailText := copy.DataExportEmail(description, fmt.Sprintf("%s/data-exports/%s/download", config.URI, this.ID), this.ExpiresAt.Time.Format("2 January 2006"))
+
+	mail := notifications.Email{
+		To:      user.Email,
+		From:    config.SYSTEM_EMAIL,
+		ReplyTo: config.SUPPORT_EMAIL,
+		Text:    emailText,
+		HTML:    emailHTML,
+		Subject: fmt.Sprintf("Your %s data export is ready", config.NAME),
+	}
+
+	task := kewpie.Task{}
+	if err := task.Marshal(mail); err != nil {
+		return err
+	}
+
+	return Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task)
+}
+
+func addToArchive(ctx context.Context, archive *zip.Writer, store filestore.Store, name, key string) error {
+	src, err := store.Open(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(dst, src)
+	return err
+}
+
+// PruneDataExports removes exports, and their files, once they can no longer be downloaded.
+func PruneDataExports(ctx context.Context, store filestore.Store, now time.Time) error {
+	db := ctx.Value("tx").(Querier)
+
+	rows, err := db.QueryContext(ctx, "DELETE FROM data_exports WHERE expires_at < $1 RETURNING storage_key", now)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	keys := []string{}
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if err := store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
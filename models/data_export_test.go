@@ -0,0 +1,111 @@
+package models
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"doubleboiler/filestore"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, dataExportFix())
+}
+
+func dataExportFixture(userID string) (export DataExport) {
+	export.New(userID)
+	return
+}
+
+func (DataExport) blank() model {
+	return &DataExport{}
+}
+
+func (export DataExport) id() string {
+	return export.ID
+}
+
+func (export *DataExport) nullDynamicValues() {
+	export.CreatedAt = time.Time{}
+	export.UpdatedAt = time.Time{}
+	export.Revision = ""
+}
+
+func (DataExport) tablename() string {
+	return "data_exports"
+}
+
+func dataExportFix() []model {
+	user := userFixture()
+	fix := dataExportFixture(user.ID)
+	return []model{
+		&user,
+		&fix,
+	}
+}
+
+func TestDataExportBuild(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	store := filestore.Local{Dir: t.TempDir()}
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	user := userFixture()
+	assert.Nil(t, user.Save(ctx))
+	ou := organisationUserFixture(user.ID, org.ID)
+	assert.Nil(t, ou.Save(ctx))
+	someThing := someThingFixture(org.ID)
+	assert.Nil(t, someThing.Save(ctx))
+
+	attachment := Attachment{}
+	attachment.New(org.ID, someThing.ID, user.ID, "hello.txt", "text/plain", 5)
+	assert.Nil(t, attachment.Upload(ctx, store, bytes.NewBufferString("hello")))
+
+	fix := dataExportFixture(user.ID)
+	assert.Nil(t, fix.Request(ctx))
+	assert.False(t, fix.Downloadable(time.Now()))
+
+	assert.Nil(t, fix.Build(ctx, store))
+	assert.True(t, fix.Downloadable(time.Now()))
+	assert.False(t, fix.Downloadable(time.Now().Add(DataExportExpiry+time.Hour)))
+
+	file, err := store.Open(ctx, fix.StorageKey)
+	assert.Nil(t, err)
+	contents, err := io.ReadAll(file)
+	assert.Nil(t, err)
+	file.Close()
+
+	archive, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+	assert.Nil(t, err)
+	files := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		assert.Nil(t, err)
+		b, err := io.ReadAll(r)
+		assert.Nil(t, err)
+		r.Close()
+		files[f.Name] = string(b)
+	}
+
+	assert.Contains(t, files["user.json"], user.Email)
+	assert.NotContains(t, files["user.json"], user.Password)
+	assert.NotContains(t, files["user.json"], "totp_secret")
+	assert.Contains(t, files["memberships.json"], org.Name)
+	assert.Contains(t, files["attachments.json"], attachment.ID)
+	assert.Equal(t, "hello", files["attachments/"+attachment.ID+"/hello.txt"])
+
+	assert.Nil(t, PruneDataExports(ctx, store, time.Now()))
+	assert.Nil(t, fix.FindByID(ctx, fix.ID))
+
+	assert.Nil(t, PruneDataExports(ctx, store, time.Now().Add(DataExportExpiry+time.Hour)))
+	assert.Equal(t, sql.ErrNoRows, fix.FindByID(ctx, fix.ID))
+	_, err = store.Open(ctx, fix.StorageKey)
+	assert.Equal(t, filestore.ErrNotFound, err)
+}
@@ -0,0 +1,157 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	uuid "github.com/satori/go.uuid"
+)
+
+// DeadLetter is a queue task that failed and was marked as not worth retrying.
+// It is kept so a superadmin can inspect it, then edit and retry or discard it.
+type DeadLetter struct {
+	ID        string
+	Revision  string
+	QueueName string
+	TaskID    string
+	Body      string
+	Tags      kewpie.Tags
+	Error     string
+	Attempts  int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (this *DeadLetter) colmap() *Colmap {
+	return &Colmap{
+		"id":         &this.ID,
+		"revision":   &this.Revision,
+		"queue_name": &this.QueueName,
+		"task_id":    &this.TaskID,
+		"body":       &this.Body,
+		"tags":       &this.Tags,
+		"error":      &this.Error,
+		"attempts":   &this.Attempts,
+		"created_at": &this.CreatedAt,
+		"updated_at": &this.UpdatedAt,
+	}
+}
+
+func (this *DeadLetter) New(queueName string, task kewpie.Task, taskErr error) {
+	this.ID = uuid.NewV4().String()
+	this.QueueName = queueName
+	this.TaskID = task.ID
+	this.Body = task.Body
+	this.Tags = task.Tags
+	if this.Tags == nil {
+		this.Tags = kewpie.Tags{}
+	}
+	if taskErr != nil {
+		this.Error = taskErr.Error()
+	}
+	this.Attempts = task.Attempts
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+// Dead letters aren't owned by an organisation, so they don't write to the audit log.
+func (this *DeadLetter) Save(ctx context.Context) error {
+	q, props, newRev := StandardSave("dead_letters", this.colmap(), "")
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *DeadLetter) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("dead_letters", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *DeadLetter) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this DeadLetter) Label() string {
+	return this.QueueName + " - " + this.Error
+}
+
+// Task rebuilds a fresh task from the dead letter, ready to be published again.
+func (this DeadLetter) Task() kewpie.Task {
+	return kewpie.Task{
+		Body: this.Body,
+		Tags: this.Tags,
+	}
+}
+
+func (this DeadLetter) Delete(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, "DELETE FROM dead_letters WHERE id = $1", this.ID)
+	return err
+}
+
+func CountDeadLetters(ctx context.Context) (int, error) {
+	db := ctx.Value("tx").(Querier)
+
+	count := 0
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM dead_letters").Scan(&count)
+	return count, err
+}
+
+type DeadLetters struct {
+	Data     []DeadLetter
+	Criteria Criteria
+}
+
+func (this DeadLetters) colmap() *Colmap {
+	r := DeadLetter{}
+	return r.colmap()
+}
+
+func (DeadLetters) AvailableFilters() Filters {
+	return standardFilters("dead_letters")
+}
+
+func (this *DeadLetters) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "dead_letters"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "dead_letters"}
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "dead_letters", criteria.Filters, criteria.Pagination, Order{By: "created_at", Desc: true}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		deadLetter := DeadLetter{}
+		props := deadLetter.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, deadLetter)
+	}
+	return err
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, deadLetterFix())
+	modelCollectionsUnderTest = append(modelCollectionsUnderTest, deadLettersFix())
+}
+
+func deadLetterFixture() (d DeadLetter) {
+	task := kewpie.Task{
+		Body: `{"to":"` + randString() + `"}`,
+	}
+	task.Tags.Set("user_id", randString())
+	d.New(randString(), task, errors.New(randString()))
+	return
+}
+
+func (DeadLetter) blank() model {
+	return &DeadLetter{}
+}
+
+func (d DeadLetter) id() string {
+	return d.ID
+}
+
+func (d *DeadLetter) nullDynamicValues() {
+	d.CreatedAt = time.Time{}
+	d.UpdatedAt = time.Time{}
+	d.Revision = ""
+}
+
+func (DeadLetter) tablename() string {
+	return "dead_letters"
+}
+
+func (DeadLetters) tablename() string {
+	return "dead_letters"
+}
+
+func (DeadLetters) blank() models {
+	return &DeadLetters{}
+}
+
+func deadLettersFix() modelCollectionFixture {
+	return modelCollectionFixture{
+		collection: &DeadLetters{
+			Data: []DeadLetter{
+				deadLetterFixture(),
+				deadLetterFixture(),
+			},
+		},
+	}
+}
+
+func deadLetterFix() []model {
+	fix := deadLetterFixture()
+	return []model{
+		&fix,
+	}
+}
+
+func (this DeadLetters) data() []model {
+	ret := []model{}
+	for _, m := range this.Data {
+		ret = append(ret, &m)
+	}
+	return ret
+}
+
+func TestDeadLetterDelete(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	fix := deadLetterFixture()
+	assert.Nil(t, fix.Save(ctx))
+
+	before, err := CountDeadLetters(ctx)
+	assert.Nil(t, err)
+
+	assert.Nil(t, fix.Delete(ctx))
+
+	after, err := CountDeadLetters(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, before-1, after)
+
+	closeTx(t, ctx)
+}
@@ -0,0 +1,202 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/util"
+	"hash/fnv"
+	"regexp"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// FeatureFlag turns a feature on for everyone, for particular organisations or users, or for a
+// percentage of organisations. Flags are managed by superadmins, and a flag nobody has created
+// yet is off.
+type FeatureFlag struct {
+	ID              string
+	Revision        string
+	Key             string
+	Description     string
+	Enabled         bool
+	OrganisationIDs NullStringList
+	UserIDs         NullStringList
+	RolloutPercent  int
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+func (this *FeatureFlag) colmap() *Colmap {
+	return &Colmap{
+		"id":               &this.ID,
+		"revision":         &this.Revision,
+		"key":              &this.Key,
+		"description":      &this.Description,
+		"enabled":          &this.Enabled,
+		"organisation_ids": &this.OrganisationIDs,
+		"user_ids":         &this.UserIDs,
+		"rollout_percent":  &this.RolloutPercent,
+		"created_at":       &this.CreatedAt,
+		"updated_at":       &this.UpdatedAt,
+	}
+}
+
+func (this *FeatureFlag) New(key, description string) {
+	this.ID = uuid.NewV4().String()
+	this.Key = key
+	this.Description = description
+	this.OrganisationIDs = NullStringList{Valid: true, Strings: []string{}}
+	this.UserIDs = NullStringList{Valid: true, Strings: []string{}}
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+func (this *FeatureFlag) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "feature_flags", this.ID, this.ID)
+}
+
+var featureFlagKey = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+func (this *FeatureFlag) Save(ctx context.Context) error {
+	if !featureFlagKey.MatchString(this.Key) {
+		return ClientSafeError{Message: "A feature flag's key can only have lowercase letters, numbers, dashes and underscores"}
+	}
+	if this.RolloutPercent < 0 || this.RolloutPercent > 100 {
+		return ClientSafeError{Message: "The rollout must be between 0 and 100 percent"}
+	}
+
+	q, props, newRev := StandardSave("feature_flags", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *FeatureFlag) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("feature_flags", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *FeatureFlag) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this FeatureFlag) Label() string {
+	return this.Key
+}
+
+func (this FeatureFlag) Delete(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, this.auditQuery(ctx, "D")+"DELETE FROM feature_flags WHERE id = $1 AND revision = $2", this.ID, this.Revision)
+	return err
+}
+
+// EnabledFor is whether the flag is on for the user in the organisation. Either may be blank.
+func (this FeatureFlag) EnabledFor(organisationID, userID string) bool {
+	if this.Enabled {
+		return true
+	}
+	if organisationID != "" && util.Contains(this.OrganisationIDs.Strings, organisationID) {
+		return true
+	}
+	if userID != "" && util.Contains(this.UserIDs.Strings, userID) {
+		return true
+	}
+
+	// Rollouts are by organisation so everyone in it sees the same thing, falling back to the user
+	// where there's no organisation.
+	subject := organisationID
+	if subject == "" {
+		subject = userID
+	}
+	if subject == "" || this.RolloutPercent == 0 {
+		return false
+	}
+	return rolloutBucket(this.Key, subject) < this.RolloutPercent
+}
+
+// rolloutBucket puts the subject somewhere from 0 to 99 for the flag. The same subject always lands
+// in the same place, so raising a rollout only ever adds to who has the feature.
+func rolloutBucket(key, subject string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(key + ":" + subject))
+	return int(hash.Sum32() % 100)
+}
+
+// FeatureEnabled looks the flag up and evaluates it, for code without the flags already to hand.
+func FeatureEnabled(ctx context.Context, key, organisationID, userID string) (bool, error) {
+	flag := FeatureFlag{}
+	if err := flag.FindByColumn(ctx, "key", key); err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+	return flag.EnabledFor(organisationID, userID), nil
+}
+
+type FeatureFlags struct {
+	Data     []FeatureFlag
+	Criteria Criteria
+}
+
+func (this FeatureFlags) colmap() *Colmap {
+	r := FeatureFlag{}
+	return r.colmap()
+}
+
+func (FeatureFlags) AvailableFilters() Filters {
+	return standardFilters("feature_flags")
+}
+
+func (this FeatureFlags) Enabled(key, organisationID, userID string) bool {
+	for _, flag := range this.Data {
+		if flag.Key == key {
+			return flag.EnabledFor(organisationID, userID)
+		}
+	}
+	return false
+}
+
+func (this *FeatureFlags) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "feature_flags"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "feature_flags"}
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "feature_flags", criteria.Filters, criteria.Pagination, Order{By: "key"}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		flag := FeatureFlag{}
+		props := flag.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, flag)
+	}
+	return err
+}
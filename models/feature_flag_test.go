@@ -0,0 +1,122 @@
+package models
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, featureFlagFix())
+}
+
+func featureFlagFixture() (flag FeatureFlag) {
+	flag.New("flag-"+uuid.NewV4().String(), "A test flag")
+	return
+}
+
+func (FeatureFlag) blank() model {
+	return &FeatureFlag{}
+}
+
+func (flag FeatureFlag) id() string {
+	return flag.ID
+}
+
+func (flag *FeatureFlag) nullDynamicValues() {
+	flag.CreatedAt = time.Time{}
+	flag.UpdatedAt = time.Time{}
+	flag.Revision = ""
+}
+
+func (FeatureFlag) tablename() string {
+	return "feature_flags"
+}
+
+func featureFlagFix() []model {
+	fix := featureFlagFixture()
+	return []model{
+		&fix,
+	}
+}
+
+func TestFeatureFlagEnabledFor(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.NewV4().String()
+	userID := uuid.NewV4().String()
+
+	flag := featureFlagFixture()
+	assert.False(t, flag.EnabledFor(orgID, userID))
+
+	flag.OrganisationIDs.Strings = []string{orgID}
+	assert.True(t, flag.EnabledFor(orgID, ""))
+	assert.False(t, flag.EnabledFor("", userID))
+
+	flag.OrganisationIDs.Strings = []string{}
+	flag.UserIDs.Strings = []string{userID}
+	assert.True(t, flag.EnabledFor(orgID, userID))
+	assert.False(t, flag.EnabledFor(orgID, ""))
+
+	flag.UserIDs.Strings = []string{}
+	flag.Enabled = true
+	assert.True(t, flag.EnabledFor("", ""))
+}
+
+func TestFeatureFlagRollout(t *testing.T) {
+	t.Parallel()
+
+	flag := featureFlagFixture()
+
+	enabled := func(percent int) map[string]bool {
+		flag.RolloutPercent = percent
+		on := map[string]bool{}
+		for i := 0; i < 1000; i++ {
+			id := uuid.NewV5(uuid.NamespaceOID, strconv.Itoa(i)).String()
+			if flag.EnabledFor(id, "") {
+				on[id] = true
+			}
+		}
+		return on
+	}
+
+	assert.Len(t, enabled(0), 0)
+	assert.Len(t, enabled(100), 1000)
+
+	ten := enabled(10)
+	fifty := enabled(50)
+	assert.InDelta(t, 100, len(ten), 50)
+	assert.InDelta(t, 500, len(fifty), 100)
+	for id := range ten {
+		assert.True(t, fifty[id], "raising a rollout shouldn't take the feature away from anyone")
+	}
+}
+
+func TestFeatureFlagSaveValidation(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	flag := featureFlagFixture()
+	flag.Key = "Not A Key"
+	assert.IsType(t, ClientSafeError{}, flag.Save(ctx))
+
+	flag = featureFlagFixture()
+	flag.RolloutPercent = 101
+	assert.IsType(t, ClientSafeError{}, flag.Save(ctx))
+
+	flag = featureFlagFixture()
+	flag.UserIDs.Strings = []string{"someone"}
+	assert.Nil(t, flag.Save(ctx))
+
+	enabled, err := FeatureEnabled(ctx, flag.Key, "", "someone")
+	assert.Nil(t, err)
+	assert.True(t, enabled)
+
+	enabled, err = FeatureEnabled(ctx, "no-such-flag", "", "someone")
+	assert.Nil(t, err)
+	assert.False(t, enabled)
+}
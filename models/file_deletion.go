@@ -0,0 +1,70 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/filestore"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+)
+
+// FileDeletion is files to remove from the store once the transaction that stopped using them has
+// committed. Removing them straight away would leave rows pointing at nothing if it rolled back.
+type FileDeletion struct {
+	Keys []string `json:"keys"`
+	// UnlessLogoOf keeps any of the files that organisation has since made its logo.
+	UnlessLogoOf string `json:"unless_logo_of,omitempty"`
+}
+
+// UnusedUploadGrace is how long a fresh upload is given for the transaction that uses it to commit.
+const UnusedUploadGrace = time.Hour
+
+// DeleteFilesAfterCommit queues the files for deletion through the outbox, so nothing happens to
+// them unless the current transaction commits.
+func DeleteFilesAfterCommit(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	task := kewpie.Task{}
+	if err := task.Marshal(FileDeletion{Keys: keys}); err != nil {
+		return err
+	}
+
+	return Enqueue(ctx, config.FILE_DELETION_QUEUE_NAME, &task)
+}
+
+// deleteLogoUnlessUsed queues a fresh logo's removal outside the current transaction, so it still
+// happens if that transaction rolls back. It waits out UnusedUploadGrace and then leaves the file
+// alone if the organisation is using it.
+func deleteLogoUnlessUsed(ctx context.Context, organisationID, key string) error {
+	task := kewpie.Task{Delay: UnusedUploadGrace}
+	if err := task.Marshal(FileDeletion{Keys: []string{key}, UnlessLogoOf: organisationID}); err != nil {
+		return err
+	}
+
+	return Enqueue(context.WithValue(ctx, "tx", config.Db), config.FILE_DELETION_QUEUE_NAME, &task)
+}
+
+func (this FileDeletion) Run(ctx context.Context, store filestore.Store) error {
+	logo := ""
+	if this.UnlessLogoOf != "" {
+		org := Organisation{}
+		if err := org.FindByID(ctx, this.UnlessLogoOf); err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		logo = org.LogoKey.String
+	}
+
+	for _, key := range this.Keys {
+		if key == logo {
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"context"
+	"doubleboiler/config"
+	"doubleboiler/filestore"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// pendingFileDeletion gathers the file deletions queued in the test's transaction.
+func pendingFileDeletion(ctx context.Context, t *testing.T) FileDeletion {
+	entries, err := PendingOutbox(ctx, 1000)
+	assert.Nil(t, err)
+
+	deletion := FileDeletion{}
+	for _, entry := range entries {
+		if entry.QueueName == config.FILE_DELETION_QUEUE_NAME {
+			queued := FileDeletion{}
+			assert.Nil(t, entry.Task.Unmarshal(&queued))
+			// Unused upload cleanups are committed straight away, so they could be anyone's
+			if queued.UnlessLogoOf == "" {
+				deletion.Keys = append(deletion.Keys, queued.Keys...)
+			}
+		}
+	}
+	return deletion
+}
+
+func TestDeleteFilesAfterCommit(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	store := filestore.Local{Dir: t.TempDir()}
+	assert.Nil(t, store.Put(ctx, "attachments/org/file", "text/plain", strings.NewReader("hello")))
+
+	assert.Nil(t, DeleteFilesAfterCommit(ctx))
+	assert.Empty(t, pendingFileDeletion(ctx, t).Keys)
+
+	assert.Nil(t, DeleteFilesAfterCommit(ctx, "attachments/org/file"))
+
+	_, err := store.Open(ctx, "attachments/org/file")
+	assert.Nil(t, err)
+
+	deletion := pendingFileDeletion(ctx, t)
+	assert.Equal(t, []string{"attachments/org/file"}, deletion.Keys)
+
+	assert.Nil(t, deletion.Run(ctx, store))
+	_, err = store.Open(ctx, "attachments/org/file")
+	assert.Equal(t, filestore.ErrNotFound, err)
+
+	// Already gone is fine
+	assert.Nil(t, deletion.Run(ctx, store))
+}
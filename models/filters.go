@@ -1,6 +1,10 @@
 package models
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
+
 	scumfilter "github.com/davidbanham/scum/filter"
 )
 
@@ -15,3 +19,41 @@ type FilterSet = scumfilter.FilterSet
 type DateFilterOpts = scumfilter.DateFilterOpts
 
 var standardFilters = scumfilter.CommonFilters
+
+// Matching finds rows where any of the columns contain the phrase, ignoring case.
+type Matching struct {
+	Cols   []string
+	Phrase string
+}
+
+func (this Matching) Query(propIndex int) (string, []any) {
+	if this.Phrase == "" || len(this.Cols) == 0 {
+		return "true = true", []any{}
+	}
+	clauses := []string{}
+	for _, col := range this.Cols {
+		clauses = append(clauses, fmt.Sprintf("%s ILIKE $%d", col, propIndex))
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(this.Phrase)
+	return "(" + strings.Join(clauses, " OR ") + ")", []any{"%" + escaped + "%"}
+}
+
+func (this Matching) Label() string {
+	return this.Phrase
+}
+
+func (Matching) ID() string {
+	return "matching"
+}
+
+func (Matching) Populate(url.Values) error {
+	return nil
+}
+
+func (Matching) Inputs() []string {
+	return []string{}
+}
+
+func (Matching) TableName() string {
+	return ""
+}
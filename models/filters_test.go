@@ -0,0 +1,19 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchingQuery(t *testing.T) {
+	t.Parallel()
+
+	q, props := Matching{Cols: []string{"users.email", "users.id::text"}, Phrase: "50%_off"}.Query(2)
+	assert.Equal(t, "(users.email ILIKE $2 OR users.id::text ILIKE $2)", q)
+	assert.Equal(t, []any{`%50\%\_off%`}, props)
+
+	q, props = Matching{Cols: []string{"users.email"}}.Query(1)
+	assert.Equal(t, "true = true", q)
+	assert.Empty(t, props)
+}
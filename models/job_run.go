@@ -0,0 +1,202 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type JobRun struct {
+	ID           string
+	Revision     string
+	Name         string
+	ScheduledFor time.Time
+	StartedAt    time.Time
+	FinishedAt   sql.NullTime
+	Succeeded    bool
+	Error        string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+func (this *JobRun) colmap() *Colmap {
+	return &Colmap{
+		"id":            &this.ID,
+		"revision":      &this.Revision,
+		"name":          &this.Name,
+		"scheduled_for": &this.ScheduledFor,
+		"started_at":    &this.StartedAt,
+		"finished_at":   &this.FinishedAt,
+		"succeeded":     &this.Succeeded,
+		"error":         &this.Error,
+		"created_at":    &this.CreatedAt,
+		"updated_at":    &this.UpdatedAt,
+	}
+}
+
+func (this *JobRun) New(name string, scheduledFor time.Time) {
+	this.ID = uuid.NewV4().String()
+	this.Revision = uuid.NewV4().String()
+	this.Name = name
+	this.ScheduledFor = scheduledFor
+	this.StartedAt = time.Now()
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+// Job runs aren't owned by an organisation, so they don't write to the audit log.
+// The run history is its own record.
+func (this *JobRun) Save(ctx context.Context) error {
+	q, props, newRev := StandardSave("job_runs", this.colmap(), "")
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+// Claim inserts the run, returning false if another instance has already claimed
+// this job for the same scheduled time.
+func (this *JobRun) Claim(ctx context.Context) (bool, error) {
+	db := ctx.Value("tx").(Querier)
+
+	result, err := db.ExecContext(ctx, `INSERT INTO job_runs (id, revision, name, scheduled_for, started_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7) ON CONFLICT (name, scheduled_for) DO NOTHING`, this.ID, this.Revision, this.Name, this.ScheduledFor, this.StartedAt, this.CreatedAt, this.UpdatedAt)
+	if err != nil {
+		return false, err
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return num == 1, nil
+}
+
+func (this *JobRun) Finish(ctx context.Context, runErr error) error {
+	this.FinishedAt = sql.NullTime{
+		Valid: true,
+		Time:  time.Now(),
+	}
+	this.Succeeded = runErr == nil
+	if runErr != nil {
+		this.Error = runErr.Error()
+	}
+	return this.Save(ctx)
+}
+
+func (this *JobRun) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("job_runs", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *JobRun) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this JobRun) Label() string {
+	return this.Name
+}
+
+func (this JobRun) Duration() time.Duration {
+	if !this.FinishedAt.Valid {
+		return 0
+	}
+	return this.FinishedAt.Time.Sub(this.StartedAt)
+}
+
+type JobRuns struct {
+	Data     []JobRun
+	Criteria Criteria
+}
+
+func (this JobRuns) colmap() *Colmap {
+	r := JobRun{}
+	return r.colmap()
+}
+
+func (JobRuns) AvailableFilters() Filters {
+	failed := HasProp{}
+	if err := failed.Hydrate(HasPropOpts{
+		Label: "Failed",
+		ID:    "job-run-failed",
+		Table: "job_runs",
+		Col:   "succeeded",
+		Value: "false",
+	}); err != nil {
+		log.Fatal(err)
+	}
+	return append(standardFilters("job_runs"), &failed)
+}
+
+// Latest returns the most recent run of each named job.
+func (this JobRuns) Latest() map[string]JobRun {
+	ret := map[string]JobRun{}
+	for _, run := range this.Data {
+		if existing, ok := ret[run.Name]; !ok || run.StartedAt.After(existing.StartedAt) {
+			ret[run.Name] = run
+		}
+	}
+	return ret
+}
+
+// Failures counts the failed runs of each named job.
+func (this JobRuns) Failures() map[string]int {
+	ret := map[string]int{}
+	for _, run := range this.Data {
+		if run.FinishedAt.Valid && !run.Succeeded {
+			ret[run.Name]++
+		}
+	}
+	return ret
+}
+
+func (this *JobRuns) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "job_runs"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "job_runs"}
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "job_runs", criteria.Filters, criteria.Pagination, Order{By: "started_at", Desc: true}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		run := JobRun{}
+		props := run.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, run)
+	}
+	return err
+}
+
+func PruneJobRuns(ctx context.Context, before time.Time) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, "DELETE FROM job_runs WHERE started_at < $1", before)
+	return err
+}
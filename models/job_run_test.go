@@ -0,0 +1,116 @@
+package models
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, jobRunFix())
+	modelCollectionsUnderTest = append(modelCollectionsUnderTest, jobRunsFix())
+}
+
+func jobRunFixture() (run JobRun) {
+	run.New(randString(), time.Now().Truncate(time.Minute))
+	return
+}
+
+func (JobRun) blank() model {
+	return &JobRun{}
+}
+
+func (run JobRun) id() string {
+	return run.ID
+}
+
+func (run *JobRun) nullDynamicValues() {
+	run.ScheduledFor = time.Time{}
+	run.StartedAt = time.Time{}
+	run.CreatedAt = time.Time{}
+	run.UpdatedAt = time.Time{}
+	run.Revision = ""
+}
+
+func (JobRun) tablename() string {
+	return "job_runs"
+}
+
+func (JobRuns) tablename() string {
+	return "job_runs"
+}
+
+func (JobRuns) blank() models {
+	return &JobRuns{}
+}
+
+func jobRunsFix() modelCollectionFixture {
+	return modelCollectionFixture{
+		collection: &JobRuns{
+			Data: []JobRun{
+				jobRunFixture(),
+				jobRunFixture(),
+			},
+		},
+	}
+}
+
+func jobRunFix() []model {
+	fix := jobRunFixture()
+	return []model{
+		&fix,
+	}
+}
+
+func (this JobRuns) data() []model {
+	ret := []model{}
+	for _, m := range this.Data {
+		ret = append(ret, &m)
+	}
+	return ret
+}
+
+func TestJobRunClaim(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	first := jobRunFixture()
+	claimed, err := first.Claim(ctx)
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+
+	second := JobRun{}
+	second.New(first.Name, first.ScheduledFor)
+	claimed, err = second.Claim(ctx)
+	assert.Nil(t, err)
+	assert.False(t, claimed)
+
+	closeTx(t, ctx)
+}
+
+func TestJobRunFinish(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	fix := jobRunFixture()
+	claimed, err := fix.Claim(ctx)
+	assert.Nil(t, err)
+	assert.True(t, claimed)
+
+	assert.Nil(t, fix.Finish(ctx, errors.New("it broke")))
+
+	found := JobRun{}
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.True(t, found.FinishedAt.Valid)
+	assert.False(t, found.Succeeded)
+	assert.Equal(t, "it broke", found.Error)
+
+	runs := JobRuns{}
+	assert.Nil(t, runs.FindAll(ctx, Criteria{Query: &All{}, Filters: Filters{&Custom{Col: "name", Values: []string{fix.Name}}}}))
+	assert.Equal(t, 1, runs.Failures()[fix.Name])
+	assert.Equal(t, fix.ID, runs.Latest()[fix.Name].ID)
+
+	closeTx(t, ctx)
+}
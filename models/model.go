@@ -2,6 +2,7 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	scummodel "github.com/davidbanham/scum/model"
@@ -9,6 +10,7 @@ import (
 	scumsearch "github.com/davidbanham/scum/search"
 	scumtoggle "github.com/davidbanham/scum/toggle"
 	scumutil "github.com/davidbanham/scum/util"
+	"github.com/lib/pq"
 )
 
 type Criteria struct {
@@ -16,6 +18,8 @@ type Criteria struct {
 	customQuery interface{}
 	Filters     Filters
 	Pagination  Pagination
+	Sort        Sort
+	Cursor      Cursor
 }
 
 // Sometimes it's simpler to define one-off behaviour directly in the model rather than satisfying the Query interface
@@ -25,7 +29,7 @@ func AddCustomQuery[CQ CustomQuery](cus CQ, criteria *Criteria) {
 }
 
 type CustomQuery interface {
-	ByEntityID | OrganisationsContainingUser
+	ByEntityID | OrganisationsContainingUser | SavedViewsFor
 }
 
 type custom struct{}
@@ -64,6 +68,12 @@ type Toggles = scumtoggle.Toggles
 
 var ErrWrongRev = scummodel.ErrWrongRev
 
+// isUniqueViolation is whether err is Postgres refusing a row that would break the named constraint.
+func isUniqueViolation(err error, constraint string) bool {
+	pqErr := &pq.Error{}
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == constraint
+}
+
 func currentUser(ctx context.Context) string {
 	if ctx == nil {
 		return ""
@@ -81,6 +91,23 @@ func currentUser(ctx context.Context) string {
 	return ""
 }
 
+// currentImpersonator is the superadmin really making changes when they're impersonating someone.
+func currentImpersonator(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if impersonator, ok := ctx.Value("impersonator").(User); ok {
+		return impersonator.ID
+	}
+	return ""
+}
+
 func auditQuery(ctx context.Context, action, tableName, entityID, organisationID string) string {
-	return fmt.Sprintf("WITH audit_entry AS (INSERT INTO audit_log (entity_id, organisation_id, table_name, action, user_id, old_row_data) VALUES ('%s', '%s', '%s', '%s', '%s', (SELECT to_jsonb(%s) - 'ts' FROM %s WHERE id = '%s')))", entityID, organisationID, tableName, action, currentUser(ctx), tableName, tableName, entityID)
+	return fmt.Sprintf("WITH audit_entry AS (INSERT INTO audit_log (entity_id, organisation_id, table_name, action, user_id, impersonator_id, old_row_data) VALUES ('%s', '%s', '%s', '%s', '%s', NULLIF('%s', ''), (SELECT to_jsonb(%s) - 'ts' FROM %s WHERE id = '%s')))", entityID, organisationID, tableName, action, currentUser(ctx), currentImpersonator(ctx), tableName, tableName, entityID)
+}
+
+// reasonedAuditQuery is auditQuery with a reason attached. The reason is typed by a person, so it's
+// passed as the numbered prop rather than written into the query.
+func reasonedAuditQuery(ctx context.Context, action, tableName, entityID, organisationID string, reasonIndex int) string {
+	return fmt.Sprintf("WITH audit_entry AS (INSERT INTO audit_log (entity_id, organisation_id, table_name, action, user_id, impersonator_id, old_row_data, reason) VALUES ('%s', '%s', '%s', '%s', '%s', NULLIF('%s', ''), (SELECT to_jsonb(%s) - 'ts' FROM %s WHERE id = '%s'), $%d))", entityID, organisationID, tableName, action, currentUser(ctx), currentImpersonator(ctx), tableName, tableName, entityID, reasonIndex)
 }
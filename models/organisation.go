@@ -3,9 +3,16 @@ package models
 import (
 	"context"
 	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/copy"
+	"doubleboiler/filestore"
+	"doubleboiler/util"
+	"fmt"
 	"strings"
 	"time"
 
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/davidbanham/notifications"
 	"github.com/davidbanham/scum/search"
 	uuid "github.com/satori/go.uuid"
 )
@@ -18,6 +25,19 @@ type Organisation struct {
 	CreatedAt time.Time
 	UpdatedAt time.Time
 	Toggles   Toggles
+	Settings  Settings
+	// The quotas override the organisation's plan when they're above zero. AttachmentQuota is in bytes
+	// and EmailQuota is per day.
+	AttachmentQuota     int64
+	MemberQuota         int
+	SomeThingQuota      int
+	EmailQuota          int
+	OwnerID             sql.NullString
+	DeletionRequestedAt sql.NullTime
+	DeletionRequestedBy sql.NullString
+	ParentID            sql.NullString
+	LogoKey             sql.NullString
+	LogoContentType     string
 }
 
 var RequireAdmin2FA = Toggle{
@@ -31,13 +51,24 @@ var ValidToggles = []Toggle{RequireAdmin2FA}
 
 func (this *Organisation) colmap() *Colmap {
 	return &Colmap{
-		"id":         &this.ID,
-		"name":       &this.Name,
-		"country":    &this.Country,
-		"revision":   &this.Revision,
-		"created_at": &this.CreatedAt,
-		"updated_at": &this.UpdatedAt,
-		"toggles":    &this.Toggles,
+		"id":                    &this.ID,
+		"name":                  &this.Name,
+		"country":               &this.Country,
+		"revision":              &this.Revision,
+		"created_at":            &this.CreatedAt,
+		"updated_at":            &this.UpdatedAt,
+		"toggles":               &this.Toggles,
+		"settings":              &this.Settings,
+		"attachment_quota":      &this.AttachmentQuota,
+		"member_quota":          &this.MemberQuota,
+		"some_thing_quota":      &this.SomeThingQuota,
+		"email_quota":           &this.EmailQuota,
+		"owner_id":              &this.OwnerID,
+		"deletion_requested_at": &this.DeletionRequestedAt,
+		"deletion_requested_by": &this.DeletionRequestedBy,
+		"parent_id":             &this.ParentID,
+		"logo_key":              &this.LogoKey,
+		"logo_content_type":     &this.LogoContentType,
 	}
 }
 
@@ -53,8 +84,25 @@ func (org *Organisation) auditQuery(ctx context.Context, action string) string {
 	return auditQuery(ctx, action, "organisations", org.ID, org.ID)
 }
 
+var ErrOrganisationCycle = ClientSafeError{Message: "An organisation can't sit underneath itself or one of its own sub-accounts"}
+
 func (this *Organisation) Save(ctx context.Context) error {
 	this.Toggles.Populate(ValidToggles)
+	this.Settings.Populate(ValidSettings)
+
+	if err := this.Settings.Validate(); err != nil {
+		return err
+	}
+
+	if this.ParentID.Valid {
+		subtree, err := this.SubtreeIDs(ctx)
+		if err != nil {
+			return err
+		}
+		if this.ParentID.String == this.ID || util.Contains(subtree, this.ParentID.String) {
+			return ErrOrganisationCycle
+		}
+	}
 
 	q, props, newRev := StandardSave("organisations", this.colmap(), this.auditQuery(ctx, "U"))
 
@@ -73,6 +121,7 @@ func (this *Organisation) FindByColumn(ctx context.Context, col, val string) err
 		return err
 	}
 	this.Toggles.Populate(ValidToggles)
+	this.Settings.Populate(ValidSettings)
 	return nil
 }
 
@@ -84,6 +133,211 @@ func (this Organisation) Label() string {
 	return this.Name
 }
 
+// SubtreeIDs is the organisation's ID along with those of all its sub-accounts, however deep.
+func (this Organisation) SubtreeIDs(ctx context.Context) ([]string, error) {
+	db := ctx.Value("tx").(Querier)
+
+	rows, err := db.QueryContext(ctx, organisationSubtree, this.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (this Organisation) IsOwner(userID string) bool {
+	return this.OwnerID.Valid && this.OwnerID.String == userID
+}
+
+var ErrTransferToNonMember = ClientSafeError{Message: "Ownership can only be transferred to a member of the organisation"}
+
+// TransferOwnership hands the organisation over to one of its members, making sure they're an
+// admin so they can actually run it.
+func (this *Organisation) TransferOwnership(ctx context.Context, to OrganisationUser) error {
+	if to.OrganisationID != this.ID {
+		return ErrTransferToNonMember
+	}
+
+	if !to.Roles.Can("admin") {
+		to.Roles = append(to.Roles, adminRole)
+		if err := to.Save(ctx); err != nil {
+			return err
+		}
+	}
+
+	db := ctx.Value("tx").(Querier)
+	if _, err := db.ExecContext(ctx, this.auditQuery(ctx, "U")+" UPDATE organisations SET owner_id = $2 WHERE id = $1", this.ID, to.UserID); err != nil {
+		return err
+	}
+	this.OwnerID = sql.NullString{Valid: true, String: to.UserID}
+
+	return nil
+}
+
+// OrganisationDeletionGracePeriod is how long an organisation lingers after deletion is requested,
+// in case it was a mistake.
+const OrganisationDeletionGracePeriod = 30 * 24 * time.Hour
+
+var ErrDeletionNotConfirmed = ClientSafeError{Message: "The name you entered doesn't match the organisation's name"}
+
+func (this Organisation) DeletionDue() time.Time {
+	return this.DeletionRequestedAt.Time.Add(OrganisationDeletionGracePeriod)
+}
+
+// RequestDeletion schedules the organisation to be archived and purged once the grace period is
+// over. The person asking has to type the name out, and gets an email saying how to cancel.
+func (this *Organisation) RequestDeletion(ctx context.Context, requester User, confirmation string) error {
+	if strings.TrimSpace(confirmation) != this.Name {
+		return ErrDeletionNotConfirmed
+	}
+
+	db := ctx.Value("tx").(Querier)
+	if err := db.QueryRowContext(ctx, this.auditQuery(ctx, "U")+" UPDATE organisations SET deletion_requested_at = now(), deletion_requested_by = $2 WHERE id = $1 RETURNING deletion_requested_at", this.ID, requester.ID).Scan(&this.DeletionRequestedAt); err != nil {
+		return err
+	}
+	this.DeletionRequestedBy = sql.NullString{Valid: true, String: requester.ID}
+
+	emailHTML, emailText := copy.OrganisationDeletionEmail(this.Name, this.DeletionDue().Format("2 January 2006"), fmt.Sprintf("%s/organisations/%s", config.URI, this.ID))
+
+	for _, to := range append([]string{requester.Email}, this.Settings.ByKey(DeletionNoticeRecipients.Key).Emails()...) {
+		mail := notifications.Email{
+			To:      to,
+			From:    config.SYSTEM_EMAIL,
+			ReplyTo: config.SUPPORT_EMAIL,
+			Text:    emailText,
+			HTML:    emailHTML,
+			Subject: fmt.Sprintf("%s is scheduled for deletion", this.Name),
+		}
+
+		task := kewpie.Task{}
+		if err := task.Marshal(mail); err != nil {
+			return err
+		}
+
+		if err := Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (this *Organisation) CancelDeletion(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+	if _, err := db.ExecContext(ctx, this.auditQuery(ctx, "U")+" UPDATE organisations SET deletion_requested_at = NULL, deletion_requested_by = NULL WHERE id = $1", this.ID); err != nil {
+		return err
+	}
+	this.DeletionRequestedAt = sql.NullTime{}
+	this.DeletionRequestedBy = sql.NullString{}
+	return nil
+}
+
+// Purge archives everything the organisation holds, sends the archive to whoever asked for the
+// deletion, then removes it all. Communications don't cascade, and the organisation's audit history
+// goes with it, leaving only the entry recording the deletion itself.
+func (this *Organisation) Purge(ctx context.Context, store filestore.Store) error {
+	if !this.DeletionRequestedBy.Valid {
+		return fmt.Errorf("organisation %s has no deletion request", this.ID)
+	}
+
+	export := DataExport{}
+	export.NewForOrganisation(this.DeletionRequestedBy.String, *this)
+	if err := export.Save(ctx); err != nil {
+		return err
+	}
+	if err := export.Build(ctx, store); err != nil {
+		return err
+	}
+
+	db := ctx.Value("tx").(Querier)
+
+	// The rows go with the organisation but the files they point at don't.
+	keys := []string{}
+	rows, err := db.QueryContext(ctx, "SELECT storage_key FROM attachments WHERE organisation_id = $1", this.ID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return err
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if this.LogoKey.Valid {
+		keys = append(keys, this.LogoKey.String)
+	}
+	if err := DeleteFilesAfterCommit(ctx, keys...); err != nil {
+		return err
+	}
+
+	for _, statement := range []string{
+		"DELETE FROM communications WHERE organisation_id = $1",
+		"DELETE FROM audit_log WHERE organisation_id = $1",
+	} {
+		if _, err := db.ExecContext(ctx, statement, this.ID); err != nil {
+			return err
+		}
+	}
+
+	_, err = db.ExecContext(ctx, this.auditQuery(ctx, "D")+" DELETE FROM organisations WHERE id = $1", this.ID)
+	return err
+}
+
+// OrganisationsDueForDeletion lists the organisations whose deletion was requested before cutoff.
+func OrganisationsDueForDeletion(ctx context.Context, cutoff time.Time) ([]string, error) {
+	db := ctx.Value("tx").(Querier)
+
+	rows, err := db.QueryContext(ctx, "SELECT id FROM organisations WHERE deletion_requested_at < $1", cutoff)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// QueueDueOrganisationDeletions hands each organisation that's due to the worker that purges it.
+func QueueDueOrganisationDeletions(ctx context.Context, cutoff time.Time) error {
+	ids, err := OrganisationsDueForDeletion(ctx, cutoff)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		task := kewpie.Task{}
+		if err := task.Marshal(map[string]string{"id": id}); err != nil {
+			return err
+		}
+		if err := Enqueue(ctx, config.ORGANISATION_DELETION_QUEUE_NAME, &task); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type Organisations struct {
 	Data     []Organisation
 	Criteria Criteria
@@ -116,6 +370,57 @@ func (this Organisations) ByID() map[string]Organisation {
 	return ret
 }
 
+type OrganisationNode struct {
+	Organisation
+	Depth int
+}
+
+// Indent is something to put in front of the name to show how deep in the tree it is.
+func (this OrganisationNode) Indent() string {
+	return strings.Repeat("— ", this.Depth)
+}
+
+// Tree puts each organisation straight after its parent. Organisations whose parent isn't in the
+// list are treated as top level.
+func (this Organisations) Tree() []OrganisationNode {
+	byID := this.ByID()
+	children := map[string][]Organisation{}
+	roots := []Organisation{}
+	for _, org := range this.Data {
+		if _, ok := byID[org.ParentID.String]; org.ParentID.Valid && ok {
+			children[org.ParentID.String] = append(children[org.ParentID.String], org)
+		} else {
+			roots = append(roots, org)
+		}
+	}
+
+	ret := []OrganisationNode{}
+	seen := map[string]bool{}
+	var walk func(orgs []Organisation, depth int)
+	walk = func(orgs []Organisation, depth int) {
+		for _, org := range orgs {
+			if seen[org.ID] {
+				continue
+			}
+			seen[org.ID] = true
+			ret = append(ret, OrganisationNode{Organisation: org, Depth: depth})
+			walk(children[org.ID], depth+1)
+		}
+	}
+	walk(roots, 0)
+
+	return ret
+}
+
+func (this Organisations) HasChildren(id string) bool {
+	for _, org := range this.Data {
+		if org.ParentID.Valid && org.ParentID.String == id {
+			return true
+		}
+	}
+	return false
+}
+
 func (this *Organisations) FindAll(ctx context.Context, criteria Criteria) error {
 	this.Criteria = criteria
 
@@ -142,7 +447,13 @@ func (this *Organisations) FindAll(ctx context.Context, criteria Criteria) error
 		`+strings.Join(cols, ",")+`
 		FROM organisations
 		`+filterQuery+`
-		AND id IN (SELECT DISTINCT organisation_id FROM organisations_users WHERE user_id = $1)
+		AND id IN (
+			WITH RECURSIVE tree AS (
+				SELECT organisation_id AS id FROM organisations_users WHERE user_id = $1
+				UNION
+				SELECT organisations.id FROM organisations INNER JOIN tree ON organisations.parent_id = tree.id
+			) SELECT id FROM tree
+		)
 		ORDER BY name`+criteria.Pagination.PaginationQuery(), props...)
 			if err != nil {
 				return err
@@ -165,6 +476,7 @@ func (this *Organisations) FindAll(ctx context.Context, criteria Criteria) error
 		}
 
 		org.Toggles.Populate(ValidToggles)
+		org.Settings.Populate(ValidSettings)
 
 		(*this).Data = append((*this).Data, org)
 	}
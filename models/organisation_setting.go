@@ -0,0 +1,315 @@
+package models
+
+import (
+	"database/sql/driver"
+	"doubleboiler/util"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type SettingType string
+
+const (
+	SettingString    SettingType = "string"
+	SettingInt       SettingType = "int"
+	SettingEnum      SettingType = "enum"
+	SettingDuration  SettingType = "duration"
+	SettingEmailList SettingType = "email_list"
+	SettingEmail     SettingType = "email"
+	SettingColour    SettingType = "colour"
+)
+
+// Setting is something an organisation can configure that's more than on or off. Only the key and
+// value are stored, the rest comes from ValidSettings. A blank value means the default.
+type Setting struct {
+	Key      string      `json:"key"`
+	Value    string      `json:"value"`
+	Category string      `json:"-"`
+	Label    string      `json:"-"`
+	HelpText string      `json:"-"`
+	Type     SettingType `json:"-"`
+	Default  string      `json:"-"`
+	// Options are the choices for an enum.
+	Options []string `json:"-"`
+	// Min and Max bound an int, or a duration in hours. Max is also the longest a string can be and
+	// the most addresses an email list can have. Zero means no bound.
+	Min int `json:"-"`
+	Max int `json:"-"`
+	// Role is what the user needs in the organisation to change the setting.
+	Role string `json:"-"`
+}
+
+var EmailSenderName = Setting{
+	Category: "branding",
+	Label:    "Email sender name",
+	Key:      "email_sender_name",
+	HelpText: `The name emails sent on the organisation's behalf come from. Leave it blank to use the organisation's name.`,
+	Type:     SettingString,
+	Max:      60,
+	Role:     "admin",
+}
+
+var AccentColour = Setting{
+	Category: "branding",
+	Label:    "Accent colour",
+	Key:      "accent_colour",
+	HelpText: `The colour of buttons and links while the organisation is active, like #4f46e5.`,
+	Type:     SettingColour,
+	Role:     "admin",
+}
+
+var EmailFooter = Setting{
+	Category: "branding",
+	Label:    "Email footer",
+	Key:      "email_footer",
+	HelpText: `Added to the bottom of every email sent on the organisation's behalf.`,
+	Type:     SettingString,
+	Max:      500,
+	Role:     "admin",
+}
+
+var EmailReplyTo = Setting{
+	Category: "branding",
+	Label:    "Reply-to address",
+	Key:      "email_reply_to",
+	HelpText: `Where replies to emails sent on the organisation's behalf go, instead of to us.`,
+	Type:     SettingEmail,
+	Role:     "admin",
+}
+
+var BroadcastReplyTo = Setting{
+	Category: "notifications",
+	Label:    "Broadcast replies go to",
+	Key:      "broadcast_reply_to",
+	HelpText: `Whether replies to a broadcast go to whoever sent it or to support.`,
+	Type:     SettingEnum,
+	Default:  "sender",
+	Options:  []string{"sender", "support"},
+	Role:     "admin",
+}
+
+var DeletionNoticeRecipients = Setting{
+	Category: "notifications",
+	Label:    "Also tell about deletion",
+	Key:      "deletion_notice_recipients",
+	HelpText: `Addresses that get a copy of the notice when someone asks for the organisation to be deleted.`,
+	Type:     SettingEmailList,
+	Max:      10,
+	Role:     "admin",
+}
+
+var DataExportLifetime = Setting{
+	Category: "data retention",
+	Label:    "Data exports expire after",
+	Key:      "data_export_lifetime",
+	HelpText: `How long a download link for the organisation's data keeps working, like 2d or 12h.`,
+	Type:     SettingDuration,
+	Default:  "7d",
+	Min:      1,
+	Max:      30 * 24,
+	Role:     "admin",
+}
+
+var CommunicationRetentionDays = Setting{
+	Category: "data retention",
+	Label:    "Keep sent email records for (days)",
+	Key:      "communication_retention_days",
+	HelpText: `Records of emails sent to members are removed after this many days. 0 keeps them forever. Contact support to change it.`,
+	Type:     SettingInt,
+	Default:  "0",
+	Min:      0,
+	Max:      3650,
+	Role:     "superadmin",
+}
+
+var ValidSettings = []Setting{EmailSenderName, AccentColour, EmailFooter, EmailReplyTo, BroadcastReplyTo, DeletionNoticeRecipients, DataExportLifetime, CommunicationRetentionDays}
+
+// Current is the setting's value, or its default if it hasn't been set.
+func (this Setting) Current() string {
+	if this.Value == "" {
+		return this.Default
+	}
+	return this.Value
+}
+
+func (this Setting) Int() int {
+	i, _ := strconv.Atoi(this.Current())
+	return i
+}
+
+func (this Setting) Duration() time.Duration {
+	d, _ := parseSettingDuration(this.Current())
+	return d
+}
+
+func (this Setting) Emails() []string {
+	return splitList(this.Current())
+}
+
+// normalise checks a value is valid for the setting, returning it in the form it's stored in.
+func (this Setting) normalise(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", nil
+	}
+
+	invalid := func(reason string, args ...any) error {
+		return ClientSafeError{Message: this.Label + " " + fmt.Sprintf(reason, args...)}
+	}
+
+	switch this.Type {
+	default:
+		return "", fmt.Errorf("unknown setting type %s", this.Type)
+	case SettingString:
+		if this.Max > 0 && len([]rune(value)) > this.Max {
+			return "", invalid("can be at most %d characters", this.Max)
+		}
+		return value, nil
+	case SettingInt:
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return "", invalid("must be a whole number")
+		}
+		if i < this.Min || (this.Max > 0 && i > this.Max) {
+			return "", invalid("must be between %d and %d", this.Min, this.Max)
+		}
+		return strconv.Itoa(i), nil
+	case SettingEnum:
+		if !util.Contains(this.Options, value) {
+			return "", invalid("must be one of %s", strings.Join(this.Options, ", "))
+		}
+		return value, nil
+	case SettingDuration:
+		d, err := parseSettingDuration(value)
+		if err != nil {
+			return "", invalid("must be a length of time like 7d or 12h")
+		}
+		if d < time.Duration(this.Min)*time.Hour || (this.Max > 0 && d > time.Duration(this.Max)*time.Hour) {
+			return "", invalid("must be between %d and %d hours", this.Min, this.Max)
+		}
+		return value, nil
+	case SettingEmail:
+		addr, err := mail.ParseAddress(value)
+		if err != nil || addr.Address != value {
+			return "", invalid("must be an email address")
+		}
+		return strings.ToLower(value), nil
+	case SettingColour:
+		if !colourPattern.MatchString(value) {
+			return "", invalid("must be a colour like #4f46e5")
+		}
+		return strings.ToLower(value), nil
+	case SettingEmailList:
+		emails := splitList(value)
+		if this.Max > 0 && len(emails) > this.Max {
+			return "", invalid("can have at most %d addresses", this.Max)
+		}
+		for i, email := range emails {
+			addr, err := mail.ParseAddress(email)
+			if err != nil || addr.Address != email {
+				return "", invalid("has an invalid address: %s", email)
+			}
+			emails[i] = strings.ToLower(email)
+		}
+		return strings.Join(emails, ", "), nil
+	}
+}
+
+var colourPattern = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// parseSettingDuration understands days as well as everything time.ParseDuration does.
+func parseSettingDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func splitList(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
+}
+
+type Settings []Setting
+
+func (this *Settings) Populate(validSettings []Setting) {
+	m := map[string]Setting{}
+	for _, setting := range *this {
+		m[setting.Key] = setting
+	}
+	(*this) = []Setting{}
+	for _, setting := range validSettings {
+		setting.Value = m[setting.Key].Value
+		(*this) = append((*this), setting)
+	}
+}
+
+func (this Settings) ByKey(key string) Setting {
+	for _, s := range this {
+		if s.Key == key {
+			return s
+		}
+	}
+	return Setting{}
+}
+
+// Set changes the value, checking it first. The key has to be one that's been populated.
+func (this Settings) Set(key, value string) error {
+	for i, s := range this {
+		if s.Key == key {
+			normalised, err := s.normalise(value)
+			if err != nil {
+				return err
+			}
+			this[i].Value = normalised
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown setting %s", key)
+}
+
+func (this Settings) Validate() error {
+	for _, s := range this {
+		if _, err := s.normalise(s.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (this Settings) ByCategory(category string) []Setting {
+	ret := []Setting{}
+	for _, s := range this {
+		if s.Category == category {
+			ret = append(ret, s)
+		}
+	}
+	return ret
+}
+
+func (this Settings) Value() (driver.Value, error) {
+	return json.Marshal(this)
+}
+
+func (this *Settings) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(b, &this)
+}
+
+// SettingCategories is the order categories appear in, covering toggles as well as settings.
+var SettingCategories = []string{"branding", "notifications", "security", "data retention"}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSettingNormalise(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		setting  Setting
+		value    string
+		expected string
+		valid    bool
+	}{
+		{EmailSenderName, "  Acme Support ", "Acme Support", true},
+		{EmailSenderName, "", "", true},
+		{EmailSenderName, strings.Repeat("a", 61), "", false},
+		{AccentColour, "#4F46E5", "#4f46e5", true},
+		{AccentColour, "red", "", false},
+		{AccentColour, "#fff", "", false},
+		{EmailReplyTo, "Help@Example.com", "help@example.com", true},
+		{EmailReplyTo, "a@example.com, b@example.com", "", false},
+		{BroadcastReplyTo, "support", "support", true},
+		{BroadcastReplyTo, "nobody", "", false},
+		{DeletionNoticeRecipients, "A@example.com,\nb@example.com", "a@example.com, b@example.com", true},
+		{DeletionNoticeRecipients, "Someone <a@example.com>", "", false},
+		{DeletionNoticeRecipients, "not an email", "", false},
+		{DataExportLifetime, "2d", "2d", true},
+		{DataExportLifetime, "36h", "36h", true},
+		{DataExportLifetime, "10m", "", false},
+		{DataExportLifetime, "31d", "", false},
+		{DataExportLifetime, "soon", "", false},
+		{CommunicationRetentionDays, "90", "90", true},
+		{CommunicationRetentionDays, "-1", "", false},
+		{CommunicationRetentionDays, "ninety", "", false},
+	} {
+		normalised, err := tc.setting.normalise(tc.value)
+		if tc.valid {
+			assert.Nil(t, err, tc.setting.Key+": "+tc.value)
+			assert.Equal(t, tc.expected, normalised)
+		} else {
+			assert.IsType(t, ClientSafeError{}, err, tc.setting.Key+": "+tc.value)
+		}
+	}
+}
+
+func TestSettingsDefaults(t *testing.T) {
+	t.Parallel()
+
+	settings := Settings{{Key: DataExportLifetime.Key, Value: "2d"}, {Key: "gone", Value: "whatever"}}
+	settings.Populate(ValidSettings)
+
+	assert.Len(t, settings, len(ValidSettings))
+	assert.Equal(t, 48*time.Hour, settings.ByKey(DataExportLifetime.Key).Duration())
+	assert.Equal(t, "sender", settings.ByKey(BroadcastReplyTo.Key).Current())
+	assert.Equal(t, 0, settings.ByKey(CommunicationRetentionDays.Key).Int())
+	assert.Empty(t, settings.ByKey(DeletionNoticeRecipients.Key).Emails())
+	assert.Equal(t, "data retention", settings.ByKey(DataExportLifetime.Key).Category)
+
+	assert.Nil(t, settings.Set(DeletionNoticeRecipients.Key, "a@example.com b@example.com"))
+	assert.Equal(t, []string{"a@example.com", "b@example.com"}, settings.ByKey(DeletionNoticeRecipients.Key).Emails())
+	assert.NotNil(t, settings.Set(BroadcastReplyTo.Key, "nobody"))
+	assert.NotNil(t, settings.Set("gone", "whatever"))
+
+	settings.Set(DataExportLifetime.Key, "")
+	assert.Equal(t, 7*24*time.Hour, settings.ByKey(DataExportLifetime.Key).Duration())
+}
+
+func TestOrganisationSettingsSave(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	org.Settings.Populate(ValidSettings)
+	assert.Nil(t, org.Settings.Set(CommunicationRetentionDays.Key, "30"))
+	assert.Nil(t, org.Save(ctx))
+
+	found := Organisation{}
+	assert.Nil(t, found.FindByID(ctx, org.ID))
+	assert.Equal(t, 30, found.Settings.ByKey(CommunicationRetentionDays.Key).Int())
+
+	found.Settings[0].Value = strings.Repeat("a", 100)
+	assert.IsType(t, ClientSafeError{}, found.Save(ctx))
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"context"
+	"doubleboiler/util"
+	"time"
+)
+
+// OrganisationStats are the figures on an organisation's dashboard. Membership and 2FA adoption are
+// as they stand now, everything else is for Period.
+type OrganisationStats struct {
+	OrganisationID        string
+	Period                util.Period
+	Members               int
+	MembersByRole         []RoleCount
+	SomeThingsCreated     []DayCount
+	Communications        int
+	CommunicationFailures int
+	Admins                int
+	AdminsWith2FA         int
+	RecentActivity        Audits
+}
+
+type RoleCount struct {
+	Role  string
+	Count int
+}
+
+type DayCount struct {
+	Day   time.Time
+	Count int
+}
+
+// Find gathers the figures. Failed sends never make it into communications, so failures are
+// counted from the dead letters of the email queue.
+func (this *OrganisationStats) Find(ctx context.Context, organisationID string, period util.Period, emailQueueName string) error {
+	this.OrganisationID = organisationID
+	this.Period = period
+
+	db := ctx.Value("tx").(Querier)
+
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM organisations_users WHERE organisation_id = $1", organisationID).Scan(&this.Members); err != nil {
+		return err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT role->>'name', count(*)
+	FROM organisations_users, jsonb_array_elements(CASE jsonb_typeof(roles) WHEN 'array' THEN roles ELSE '[]'::jsonb END) role
+	WHERE organisation_id = $1
+	GROUP BY 1 ORDER BY 1`, organisationID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	this.MembersByRole = []RoleCount{}
+	for rows.Next() {
+		count := RoleCount{}
+		if err := rows.Scan(&count.Role, &count.Count); err != nil {
+			return err
+		}
+		this.MembersByRole = append(this.MembersByRole, count)
+	}
+
+	rows, err = db.QueryContext(ctx, `SELECT day, count(some_things.id)
+	FROM generate_series($2::date, $3::date, interval '1 day') day
+	LEFT JOIN some_things ON some_things.organisation_id = $1 AND some_things.created_at >= day AND some_things.created_at < day + interval '1 day'
+	GROUP BY day ORDER BY day`, organisationID, period.Start, period.End)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	this.SomeThingsCreated = []DayCount{}
+	for rows.Next() {
+		count := DayCount{}
+		if err := rows.Scan(&count.Day, &count.Count); err != nil {
+			return err
+		}
+		this.SomeThingsCreated = append(this.SomeThingsCreated, count)
+	}
+
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM communications WHERE organisation_id = $1 AND created_at >= $2::date AND created_at < $3::date + 1", organisationID, period.Start, period.End).Scan(&this.Communications); err != nil {
+		return err
+	}
+
+	if err := db.QueryRowContext(ctx, "SELECT count(*) FROM dead_letters WHERE queue_name = $4 AND tags->>'organisation_id' = $1 AND created_at >= $2::date AND created_at < $3::date + 1", organisationID, period.Start, period.End, emailQueueName).Scan(&this.CommunicationFailures); err != nil {
+		return err
+	}
+
+	if err := db.QueryRowContext(ctx, `SELECT count(*), count(*) FILTER (WHERE users.totp_active)
+	FROM organisations_users JOIN users ON organisations_users.user_id = users.id
+	WHERE organisations_users.organisation_id = $1 AND organisations_users.roles @> '[{"name": "admin"}]'`, organisationID).Scan(&this.Admins, &this.AdminsWith2FA); err != nil {
+		return err
+	}
+
+	return this.RecentActivity.FindAll(ctx, Criteria{
+		Query:      &ByOrg{ID: organisationID},
+		Sort:       Sort{{Key: "stamp", Desc: true}},
+		Pagination: Pagination{Limit: 10},
+	})
+}
+
+// FailureRate is the percentage of attempted sends in the period that failed.
+func (this OrganisationStats) FailureRate() float64 {
+	attempts := this.Communications + this.CommunicationFailures
+	if attempts == 0 {
+		return 0
+	}
+	return float64(this.CommunicationFailures) / float64(attempts) * 100
+}
+
+// TwoFactorAdoption is the percentage of admins with 2FA turned on.
+func (this OrganisationStats) TwoFactorAdoption() float64 {
+	if this.Admins == 0 {
+		return 0
+	}
+	return float64(this.AdminsWith2FA) / float64(this.Admins) * 100
+}
+
+func (this OrganisationStats) SomeThingsCreatedTotal() int {
+	total := 0
+	for _, day := range this.SomeThingsCreated {
+		total += day.Count
+	}
+	return total
+}
+
+// Height is how tall a day's bar is, as a percentage of the busiest day in the period.
+func (this OrganisationStats) Height(day DayCount) int {
+	busiest := 0
+	for _, d := range this.SomeThingsCreated {
+		if d.Count > busiest {
+			busiest = d.Count
+		}
+	}
+	if busiest == 0 {
+		return 0
+	}
+	return day.Count * 100 / busiest
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"doubleboiler/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrganisationStatsRates(t *testing.T) {
+	t.Parallel()
+
+	stats := OrganisationStats{
+		Communications:        9,
+		CommunicationFailures: 1,
+		Admins:                4,
+		AdminsWith2FA:         1,
+		SomeThingsCreated:     []DayCount{{Count: 2}, {Count: 0}, {Count: 4}},
+	}
+
+	assert.Equal(t, float64(10), stats.FailureRate())
+	assert.Equal(t, float64(25), stats.TwoFactorAdoption())
+	assert.Equal(t, 6, stats.SomeThingsCreatedTotal())
+	assert.Equal(t, 50, stats.Height(stats.SomeThingsCreated[0]))
+	assert.Equal(t, 100, stats.Height(stats.SomeThingsCreated[2]))
+
+	assert.Equal(t, float64(0), OrganisationStats{}.FailureRate())
+	assert.Equal(t, float64(0), OrganisationStats{}.TwoFactorAdoption())
+}
+
+func TestOrganisationStatsFind(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	user := userFixture()
+	assert.Nil(t, user.Save(ctx))
+
+	orgUser := organisationUserFixture(user.ID, org.ID)
+	assert.Nil(t, orgUser.Save(ctx))
+
+	someThing := someThingFixture(org.ID)
+	assert.Nil(t, someThing.Save(ctx))
+
+	comm := communicationFixture(user, org)
+	assert.Nil(t, comm.Save(ctx))
+
+	today := time.Now().Truncate(24 * time.Hour)
+	stats := OrganisationStats{}
+	assert.Nil(t, stats.Find(ctx, org.ID, util.Period{Start: today.Add(-6 * 24 * time.Hour), End: today}, "send_email"))
+
+	assert.Equal(t, 1, stats.Members)
+	assert.Equal(t, []RoleCount{{Role: "admin", Count: 1}}, stats.MembersByRole)
+	assert.Len(t, stats.SomeThingsCreated, 7)
+	assert.Equal(t, 1, stats.SomeThingsCreatedTotal())
+	assert.Equal(t, 1, stats.Communications)
+	assert.Equal(t, 1, stats.Admins)
+	assert.Equal(t, 0, stats.AdminsWith2FA)
+	assert.NotEmpty(t, stats.RecentActivity.Data)
+
+	closeTx(t, ctx)
+}
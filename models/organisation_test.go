@@ -1,6 +1,9 @@
 package models
 
 import (
+	"bytes"
+	"database/sql"
+	"doubleboiler/filestore"
 	"testing"
 	"time"
 
@@ -92,3 +95,173 @@ func TestOrganisationRevisionChange(t *testing.T) {
 
 	closeTx(t, ctx)
 }
+
+func TestOrganisationTransferOwnership(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	owner := userFixture()
+	assert.Nil(t, owner.Save(ctx))
+	member := userFixture()
+	assert.Nil(t, member.Save(ctx))
+
+	fix := organisationFixture()
+	fix.OwnerID = sql.NullString{Valid: true, String: owner.ID}
+	assert.Nil(t, fix.Save(ctx))
+
+	ownerOU := organisationUserFixture(owner.ID, fix.ID)
+	assert.Nil(t, ownerOU.Save(ctx))
+	memberOU := organisationUserFixture(member.ID, fix.ID)
+	memberOU.Roles = Roles{}
+	assert.Nil(t, memberOU.Save(ctx))
+
+	// The owner can't be removed or demoted
+	assert.Equal(t, ErrOwnerMustStayAdmin, ownerOU.Delete(ctx))
+	ownerOU.Roles = Roles{}
+	assert.Equal(t, ErrOwnerMustStayAdmin, ownerOU.Save(ctx))
+
+	assert.Nil(t, fix.TransferOwnership(ctx, memberOU))
+
+	found := Organisation{}
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.True(t, found.IsOwner(member.ID))
+
+	promoted := OrganisationUser{}
+	assert.Nil(t, promoted.FindByID(ctx, memberOU.ID))
+	assert.True(t, promoted.Roles.Can("admin"))
+
+	// Now the old owner can be let go
+	assert.Nil(t, ownerOU.Save(ctx))
+	assert.Nil(t, ownerOU.Delete(ctx))
+}
+
+func TestOrganisationDeletion(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	store := filestore.Local{Dir: t.TempDir()}
+
+	owner := userFixture()
+	assert.Nil(t, owner.Save(ctx))
+
+	fix := organisationFixture()
+	fix.OwnerID = sql.NullString{Valid: true, String: owner.ID}
+	assert.Nil(t, fix.Save(ctx))
+	ou := organisationUserFixture(owner.ID, fix.ID)
+	assert.Nil(t, ou.Save(ctx))
+	comm := communicationFixture(owner, fix)
+	assert.Nil(t, comm.Save(ctx))
+	someThing := someThingFixture(fix.ID)
+	assert.Nil(t, someThing.Save(ctx))
+	attachment := Attachment{}
+	attachment.New(fix.ID, someThing.ID, owner.ID, "hello.txt", "text/plain", 5)
+	assert.Nil(t, attachment.Upload(ctx, store, bytes.NewBufferString("hello")))
+	assert.Nil(t, fix.UploadLogo(ctx, store, bytes.NewReader(pngHeader)))
+
+	assert.Equal(t, ErrDeletionNotConfirmed, fix.RequestDeletion(ctx, owner, "not the name"))
+	assert.Nil(t, fix.RequestDeletion(ctx, owner, fix.Name))
+	assert.WithinDuration(t, time.Now().Add(OrganisationDeletionGracePeriod), fix.DeletionDue(), time.Minute)
+
+	due, err := OrganisationsDueForDeletion(ctx, time.Now().Add(-OrganisationDeletionGracePeriod))
+	assert.Nil(t, err)
+	assert.NotContains(t, due, fix.ID)
+
+	assert.Nil(t, fix.CancelDeletion(ctx))
+	assert.Nil(t, fix.RequestDeletion(ctx, owner, fix.Name))
+
+	db := ctx.Value("tx").(Querier)
+	_, err = db.ExecContext(ctx, "UPDATE organisations SET deletion_requested_at = $2 WHERE id = $1", fix.ID, time.Now().Add(-OrganisationDeletionGracePeriod-time.Hour))
+	assert.Nil(t, err)
+
+	due, err = OrganisationsDueForDeletion(ctx, time.Now().Add(-OrganisationDeletionGracePeriod))
+	assert.Nil(t, err)
+	assert.Contains(t, due, fix.ID)
+
+	assert.Nil(t, fix.Purge(ctx, store))
+
+	assert.Equal(t, sql.ErrNoRows, fix.FindByID(ctx, fix.ID))
+	assert.Equal(t, sql.ErrNoRows, comm.FindByID(ctx, comm.ID))
+
+	assert.Nil(t, pendingFileDeletion(ctx, t).Run(ctx, store))
+	for _, key := range []string{attachment.StorageKey, fix.LogoKey.String} {
+		_, err = store.Open(ctx, key)
+		assert.Equal(t, filestore.ErrNotFound, err)
+	}
+
+	var key string
+	assert.Nil(t, db.QueryRowContext(ctx, "SELECT storage_key FROM data_exports WHERE user_id = $1 AND status = 'ready'", owner.ID).Scan(&key))
+	file, err := store.Open(ctx, key)
+	assert.Nil(t, err)
+	file.Close()
+
+	remaining := 0
+	assert.Nil(t, db.QueryRowContext(ctx, "SELECT count(*) FROM audit_log WHERE organisation_id = $1", fix.ID).Scan(&remaining))
+	assert.Equal(t, 1, remaining)
+}
+
+func TestOrganisationHierarchy(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	parent := organisationFixture()
+	assert.Nil(t, parent.Save(ctx))
+	child := organisationFixture()
+	child.ParentID = sql.NullString{Valid: true, String: parent.ID}
+	assert.Nil(t, child.Save(ctx))
+	grandchild := organisationFixture()
+	grandchild.ParentID = sql.NullString{Valid: true, String: child.ID}
+	assert.Nil(t, grandchild.Save(ctx))
+
+	parent.ParentID = sql.NullString{Valid: true, String: grandchild.ID}
+	assert.Equal(t, ErrOrganisationCycle, parent.Save(ctx))
+	parent.ParentID = sql.NullString{}
+
+	subtree, err := parent.SubtreeIDs(ctx)
+	assert.Nil(t, err)
+	assert.ElementsMatch(t, []string{parent.ID, child.ID, grandchild.ID}, subtree)
+
+	childThing := someThingFixture(child.ID)
+	assert.Nil(t, childThing.Save(ctx))
+	grandchildThing := someThingFixture(grandchild.ID)
+	assert.Nil(t, grandchildThing.Save(ctx))
+
+	things := SomeThings{}
+	assert.Nil(t, things.FindAll(ctx, Criteria{Query: &ByOrgTree{ID: parent.ID}}))
+	assert.Len(t, things.Data, 2)
+
+	things = SomeThings{}
+	assert.Nil(t, things.FindAll(ctx, Criteria{Query: &ByOrgTree{ID: grandchild.ID}}))
+	assert.Len(t, things.Data, 1)
+	assert.Equal(t, grandchildThing.ID, things.Data[0].ID)
+
+	// An admin of the parent can see, and is an admin of, everything underneath it
+	user := userFixture()
+	assert.Nil(t, user.Save(ctx))
+	ou := organisationUserFixture(user.ID, parent.ID)
+	assert.Nil(t, ou.Save(ctx))
+
+	orgs := Organisations{}
+	criteria := Criteria{}
+	AddCustomQuery(OrganisationsContainingUser{ID: user.ID}, &criteria)
+	assert.Nil(t, orgs.FindAll(ctx, criteria))
+	assert.Len(t, orgs.Data, 3)
+
+	ous := OrganisationUsers{}
+	assert.Nil(t, ous.FindAll(ctx, Criteria{Query: &ByUser{ID: user.ID}}))
+	inherited := ous.WithInherited(orgs)
+	assert.True(t, inherited.ForOrgID(grandchild.ID).Roles.Can("admin"))
+	assert.True(t, inherited.ForOrgID(grandchild.ID).Roles.Can("teamlead"))
+
+	tree := orgs.Tree()
+	assert.Equal(t, parent.ID, tree[0].ID)
+	assert.Equal(t, 0, tree[0].Depth)
+	assert.Equal(t, child.ID, tree[1].ID)
+	assert.Equal(t, 1, tree[1].Depth)
+	assert.Equal(t, grandchild.ID, tree[2].ID)
+	assert.Equal(t, 2, tree[2].Depth)
+	assert.True(t, orgs.HasChildren(child.ID))
+	assert.False(t, orgs.HasChildren(grandchild.ID))
+}
@@ -6,9 +6,14 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/davidbanham/scum/search"
 	uuid "github.com/satori/go.uuid"
 )
 
+func init() {
+	SearchTargets = append(SearchTargets, (OrganisationUsers{}).Searchable())
+}
+
 type OrganisationUser struct {
 	ID             string
 	UserID         string
@@ -60,11 +65,48 @@ func (orguser OrganisationUser) checkRolesAreValid() error {
 	return nil
 }
 
+var ErrOwnerMustStayAdmin = ClientSafeError{Message: "The owner of an organisation must stay an admin. Transfer ownership to someone else first."}
+
+func (orguser OrganisationUser) isOwner(ctx context.Context) (bool, error) {
+	db := ctx.Value("tx").(Querier)
+
+	owner := false
+	err := db.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM organisations WHERE id = $1 AND owner_id = $2)", orguser.OrganisationID, orguser.UserID).Scan(&owner)
+	return owner, err
+}
+
+func (orguser OrganisationUser) checkQuota(ctx context.Context) error {
+	org, quotas, usage, err := quotasForUpdate(ctx, orguser.OrganisationID)
+	if err != nil {
+		return err
+	}
+	if quotas.Members > 0 && usage.Members >= quotas.Members {
+		return errOverQuota(org, quotas.Members, "members")
+	}
+	return nil
+}
+
 func (this *OrganisationUser) Save(ctx context.Context) error {
 	if err := this.checkRolesAreValid(); err != nil {
 		return err
 	}
 
+	if !this.Roles.Can("admin") {
+		owner, err := this.isOwner(ctx)
+		if err != nil {
+			return err
+		}
+		if owner {
+			return ErrOwnerMustStayAdmin
+		}
+	}
+
+	if this.Revision == "" {
+		if err := this.checkQuota(ctx); err != nil {
+			return err
+		}
+	}
+
 	q, props, newRev := StandardSave("organisations_users", this.colmap(), this.auditQuery(ctx, "U"))
 
 	if err := ExecSave(ctx, q, props); err != nil {
@@ -94,7 +136,15 @@ func (orguser *OrganisationUser) FindByID(ctx context.Context, id string) error
 func (orguser OrganisationUser) Delete(ctx context.Context) error {
 	db := ctx.Value("tx").(Querier)
 
-	_, err := db.ExecContext(ctx, orguser.auditQuery(ctx, "D")+"DELETE FROM organisations_users WHERE id = $1 AND revision = $2", orguser.ID, orguser.Revision)
+	owner, err := orguser.isOwner(ctx)
+	if err != nil {
+		return err
+	}
+	if owner {
+		return ErrOwnerMustStayAdmin
+	}
+
+	_, err = db.ExecContext(ctx, orguser.auditQuery(ctx, "D")+"DELETE FROM organisations_users WHERE id = $1 AND revision = $2", orguser.ID, orguser.Revision)
 	return err
 }
 
@@ -120,6 +170,22 @@ func (this OrganisationUsers) colmap() *Colmap {
 	return r.colmap()
 }
 
+func (OrganisationUsers) AvailableFilters() Filters {
+	return (Users{}).AvailableFilters()
+}
+
+// Searchable matches members on name, family name and email. Team leads can search it, but only
+// see the members they lead; see ByPrefix.Restrictions.
+func (OrganisationUsers) Searchable() Searchable {
+	return Searchable{
+		EntityType: "Member",
+		Label:      "coalesce(nullif(trim(organisations_users.name || ' ' || organisations_users.family_name), ''), (SELECT email FROM users WHERE users.id = organisations_users.user_id))",
+		Path:       "organisation-users",
+		Tablename:  "organisations_users",
+		Permitted:  search.BasicRoleCheck("teamlead"),
+	}
+}
+
 func (this OrganisationUsers) ByID() map[string]OrganisationUser {
 	ret := map[string]OrganisationUser{}
 	for _, t := range this.Data {
@@ -137,6 +203,53 @@ func (this OrganisationUsers) ForOrgID(target string) OrganisationUser {
 	return OrganisationUser{}
 }
 
+// WithInherited carries each membership's roles down to the sub-accounts of its organisation, so an
+// admin of a parent acts as an admin of everything underneath it.
+func (this OrganisationUsers) WithInherited(orgs Organisations) OrganisationUsers {
+	byID := orgs.ByID()
+	ret := OrganisationUsers{Criteria: this.Criteria}
+
+	for _, org := range orgs.Data {
+		ou := this.ForOrgID(org.ID)
+		ou.Roles = append(Roles{}, ou.Roles...)
+
+		seen := map[string]bool{org.ID: true}
+		for current := org; current.ParentID.Valid && !seen[current.ParentID.String]; {
+			parent, ok := byID[current.ParentID.String]
+			if !ok {
+				break
+			}
+			seen[parent.ID] = true
+
+			inherited := this.ForOrgID(parent.ID)
+			for _, role := range inherited.Roles {
+				if !ou.Roles.Can(role.Name) {
+					ou.Roles = append(ou.Roles, Role{Name: role.Name})
+				}
+			}
+			if ou.UserID == "" {
+				ou.UserID = inherited.UserID
+			}
+			current = parent
+		}
+
+		if ou.ID == "" && len(ou.Roles) == 0 {
+			continue
+		}
+		ou.OrganisationID = org.ID
+		ou.Roles.Implications(ValidRoles)
+		ret.Data = append(ret.Data, ou)
+	}
+
+	for _, ou := range this.Data {
+		if _, ok := byID[ou.OrganisationID]; !ok {
+			ret.Data = append(ret.Data, ou)
+		}
+	}
+
+	return ret
+}
+
 func (this *OrganisationUsers) FindAll(ctx context.Context, criteria Criteria) error {
 	this.Criteria = criteria
 
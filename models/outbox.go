@@ -0,0 +1,178 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"encoding/json"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Enqueue records a task in the outbox as part of the current transaction. The outbox relay
+// publishes it once the transaction has committed, so a rolled back change never sends anything.
+// Emails sent on behalf of an organisation count against its daily quota.
+func Enqueue(ctx context.Context, queueName string, task *kewpie.Task) error {
+	db := ctx.Value("tx").(Querier)
+
+	if queueName == config.SEND_EMAIL_QUEUE_NAME && task.Tags.Get("organisation_id") != "" {
+		if err := consumeEmailQuota(ctx, task.Tags.Get("organisation_id"), 1); err != nil {
+			return err
+		}
+	}
+
+	id := uuid.NewV4().String()
+	task.Tags.Set("outbox_id", id)
+
+	payload, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, "INSERT INTO outbox (id, queue_name, task) VALUES ($1, $2, $3)", id, queueName, payload)
+	return err
+}
+
+type OutboxEntry struct {
+	ID        string
+	QueueName string
+	Task      kewpie.Task
+	Attempts  int
+}
+
+// PendingOutbox locks a batch of unpublished entries that are due to be tried. Rows locked by
+// another relay are skipped, so it must be called inside a transaction.
+func PendingOutbox(ctx context.Context, limit int) ([]OutboxEntry, error) {
+	db := ctx.Value("tx").(Querier)
+
+	rows, err := db.QueryContext(ctx, "SELECT id, queue_name, task, attempts FROM outbox WHERE published_at IS NULL AND (next_attempt_at IS NULL OR next_attempt_at <= now()) ORDER BY created_at LIMIT $1 FOR UPDATE SKIP LOCKED", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ret := []OutboxEntry{}
+	for rows.Next() {
+		entry := OutboxEntry{}
+		payload := []byte{}
+		if err := rows.Scan(&entry.ID, &entry.QueueName, &payload, &entry.Attempts); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &entry.Task); err != nil {
+			return nil, err
+		}
+		ret = append(ret, entry)
+	}
+
+	return ret, rows.Err()
+}
+
+func (this OutboxEntry) MarkPublished(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, "UPDATE outbox SET published_at = now(), attempts = attempts + 1 WHERE id = $1", this.ID)
+	return err
+}
+
+// MarkFailed records a failed publish and puts the entry aside until OutboxRetryDelay has passed.
+func (this OutboxEntry) MarkFailed(ctx context.Context, publishErr error) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, "UPDATE outbox SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3 WHERE id = $1", this.ID, publishErr.Error(), time.Now().Add(OutboxRetryDelay(this.Attempts+1)))
+	return err
+}
+
+// OutboxRetryDelay doubles with each failed attempt, up to an hour.
+func OutboxRetryDelay(attempts int) time.Duration {
+	delay := 10 * time.Second
+	for i := 1; i < attempts && delay < time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > time.Hour {
+		return time.Hour
+	}
+	return delay
+}
+
+// OutboxLease is how long a handler has to finish with an entry before it's assumed to have died and
+// someone else may take over.
+const OutboxLease = 30 * time.Minute
+
+// OutboxRetention is how long handled entries are kept. It's longer than any queue we use holds on
+// to a message, so a late redelivery still finds its entry and is skipped.
+const OutboxRetention = 90 * 24 * time.Hour
+
+type OutboxClaim int
+
+const (
+	OutboxClaimed OutboxClaim = iota
+	// OutboxDelivered means the entry has already been handled.
+	OutboxDelivered
+	// OutboxBusy means someone else is handling it right now.
+	OutboxBusy
+)
+
+// ClaimOutboxDelivery takes a lease on an outbox entry so only one handler acts on it. The relay may
+// publish an entry more than once, so handlers use this to act on it only once. The entry only
+// counts as delivered once MarkOutboxDelivered is called, so a handler that dies part way through
+// leaves it for the next delivery once the lease runs out.
+func ClaimOutboxDelivery(ctx context.Context, id string) (OutboxClaim, error) {
+	db := ctx.Value("tx").(Querier)
+
+	result, err := db.ExecContext(ctx, "UPDATE outbox SET claimed_until = now() + $2 * interval '1 second' WHERE id = $1 AND delivered_at IS NULL AND (claimed_until IS NULL OR claimed_until < now())", id, OutboxLease.Seconds())
+	if err != nil {
+		return OutboxBusy, err
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return OutboxBusy, err
+	}
+	if num == 1 {
+		return OutboxClaimed, nil
+	}
+
+	delivered := false
+	if err := db.QueryRowContext(ctx, "SELECT delivered_at IS NOT NULL FROM outbox WHERE id = $1", id).Scan(&delivered); err != nil {
+		if err == sql.ErrNoRows {
+			// Pruned, which only happens long after it was handled.
+			return OutboxDelivered, nil
+		}
+		return OutboxBusy, err
+	}
+	if delivered {
+		return OutboxDelivered, nil
+	}
+	return OutboxBusy, nil
+}
+
+func MarkOutboxDelivered(ctx context.Context, id string) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, "UPDATE outbox SET delivered_at = now(), claimed_until = NULL WHERE id = $1", id)
+	return err
+}
+
+func ReleaseOutboxDelivery(ctx context.Context, id string) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, "UPDATE outbox SET claimed_until = NULL WHERE id = $1", id)
+	return err
+}
+
+func CountUnpublishedOutbox(ctx context.Context) (int, error) {
+	db := ctx.Value("tx").(Querier)
+
+	count := 0
+	err := db.QueryRowContext(ctx, "SELECT COUNT(*) FROM outbox WHERE published_at IS NULL").Scan(&count)
+	return count, err
+}
+
+func PruneOutbox(ctx context.Context, before time.Time) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, "DELETE FROM outbox WHERE COALESCE(delivered_at, published_at) < $1", before)
+	return err
+}
@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxEnqueue(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	queueName := randString()
+
+	task := kewpie.Task{}
+	assert.Nil(t, task.Marshal(map[string]string{"to": randString()}))
+	assert.Nil(t, Enqueue(ctx, queueName, &task))
+
+	id := task.Tags.Get("outbox_id")
+	assert.NotEqual(t, "", id)
+
+	pending, err := PendingOutbox(ctx, 1000)
+	assert.Nil(t, err)
+
+	found := OutboxEntry{}
+	for _, entry := range pending {
+		if entry.ID == id {
+			found = entry
+		}
+	}
+	assert.Equal(t, queueName, found.QueueName)
+	assert.Equal(t, task.Body, found.Task.Body)
+
+	assert.Nil(t, found.MarkPublished(ctx))
+
+	pendingAfter, err := PendingOutbox(ctx, 1000)
+	assert.Nil(t, err)
+	for _, entry := range pendingAfter {
+		assert.NotEqual(t, id, entry.ID)
+	}
+
+	closeTx(t, ctx)
+}
+
+func TestOutboxClaimDelivery(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	task := kewpie.Task{}
+	assert.Nil(t, Enqueue(ctx, randString(), &task))
+	id := task.Tags.Get("outbox_id")
+
+	claim, err := ClaimOutboxDelivery(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, OutboxClaimed, claim)
+
+	// Someone else has it until they're done or their lease runs out
+	claim, err = ClaimOutboxDelivery(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, OutboxBusy, claim)
+
+	assert.Nil(t, ReleaseOutboxDelivery(ctx, id))
+
+	claim, err = ClaimOutboxDelivery(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, OutboxClaimed, claim)
+
+	db := ctx.Value("tx").(Querier)
+	_, err = db.ExecContext(ctx, "UPDATE outbox SET claimed_until = now() - interval '1 minute' WHERE id = $1", id)
+	assert.Nil(t, err)
+
+	claim, err = ClaimOutboxDelivery(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, OutboxClaimed, claim, "an expired lease can be taken over")
+
+	assert.Nil(t, MarkOutboxDelivered(ctx, id))
+
+	claim, err = ClaimOutboxDelivery(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, OutboxDelivered, claim)
+
+	assert.Nil(t, PruneOutbox(ctx, time.Now().Add(-OutboxRetention)))
+	claim, err = ClaimOutboxDelivery(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, OutboxDelivered, claim, "recently delivered entries are kept")
+
+	closeTx(t, ctx)
+}
+
+func TestOutboxFailedEntryWaitsToRetry(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	task := kewpie.Task{}
+	assert.Nil(t, Enqueue(ctx, randString(), &task))
+	id := task.Tags.Get("outbox_id")
+
+	pendingIDs := func() []string {
+		pending, err := PendingOutbox(ctx, 1000)
+		assert.Nil(t, err)
+		ids := []string{}
+		for _, entry := range pending {
+			ids = append(ids, entry.ID)
+		}
+		return ids
+	}
+
+	assert.Contains(t, pendingIDs(), id)
+
+	assert.Nil(t, OutboxEntry{ID: id}.MarkFailed(ctx, fmt.Errorf("queue is down")))
+	assert.NotContains(t, pendingIDs(), id)
+
+	db := ctx.Value("tx").(Querier)
+	_, err := db.ExecContext(ctx, "UPDATE outbox SET next_attempt_at = now() - interval '1 second' WHERE id = $1", id)
+	assert.Nil(t, err)
+	assert.Contains(t, pendingIDs(), id)
+}
+
+func TestOutboxRetryDelay(t *testing.T) {
+	assert.Equal(t, 10*time.Second, OutboxRetryDelay(1))
+	assert.Equal(t, 20*time.Second, OutboxRetryDelay(2))
+	assert.Equal(t, 80*time.Second, OutboxRetryDelay(4))
+	assert.Equal(t, time.Hour, OutboxRetryDelay(50))
+}
@@ -0,0 +1,82 @@
+package models
+
+// Plan is what an organisation is subscribed to. Any limit left at zero falls back to the
+// configured default quota.
+type Plan struct {
+	ID            string
+	Name          string
+	Price         string
+	MaxMembers    int
+	MaxSomeThings int
+	EmailsPerDay  int
+	StorageBytes  int64
+	TrialDays     int
+}
+
+var FreePlan = Plan{
+	ID:            "free",
+	Name:          "Free",
+	Price:         "Free",
+	MaxMembers:    3,
+	MaxSomeThings: 50,
+	EmailsPerDay:  100,
+	StorageBytes:  100 * 1024 * 1024,
+}
+
+var TeamPlan = Plan{
+	ID:            "team",
+	Name:          "Team",
+	Price:         "$29 a month",
+	MaxMembers:    25,
+	MaxSomeThings: 5000,
+	EmailsPerDay:  1000,
+	StorageBytes:  10 * 1024 * 1024 * 1024,
+	TrialDays:     14,
+}
+
+var BusinessPlan = Plan{
+	ID:           "business",
+	Name:         "Business",
+	Price:        "$99 a month",
+	StorageBytes: 100 * 1024 * 1024 * 1024,
+}
+
+// Organisations from before there were plans have no subscription, and get the default quotas.
+var legacyPlan = Plan{
+	ID:   "legacy",
+	Name: "Legacy",
+}
+
+var ValidPlans = []Plan{FreePlan, TeamPlan, BusinessPlan}
+
+// TrialPlan is the plan new organisations start out trialling.
+var TrialPlan = TeamPlan
+
+var ErrUnknownPlan = ClientSafeError{Message: "That isn't one of our plans"}
+
+func PlanByID(id string) (Plan, error) {
+	for _, plan := range ValidPlans {
+		if plan.ID == id {
+			return plan, nil
+		}
+	}
+	return Plan{}, ErrUnknownPlan
+}
+
+// Quotas are the plan's limits, with the configured defaults for any it leaves unset.
+func (this Plan) Quotas() Quotas {
+	quotas := defaultQuotas()
+	if this.MaxMembers > 0 {
+		quotas.Members = this.MaxMembers
+	}
+	if this.MaxSomeThings > 0 {
+		quotas.SomeThings = this.MaxSomeThings
+	}
+	if this.EmailsPerDay > 0 {
+		quotas.EmailsPerDay = this.EmailsPerDay
+	}
+	if this.StorageBytes > 0 {
+		quotas.StorageBytes = this.StorageBytes
+	}
+	return quotas
+}
@@ -1,6 +1,9 @@
 package models
 
 import (
+	"fmt"
+	"strings"
+
 	scumquery "github.com/davidbanham/scum/query"
 )
 
@@ -9,6 +12,30 @@ type ByOrg = scumquery.ByOrg
 type ByUser = scumquery.ByUser
 type ByIDs = scumquery.ByIDs
 
+// ByOrgTree is ByOrg for the organisation and every sub-account underneath it.
+type ByOrgTree struct {
+	ID    string
+	props []any
+}
+
+func (this *ByOrgTree) Construct(columns []string, table string, filters Filters, pagination Pagination, order Order) string {
+	filterQuery, filterProps := filters.Query(2)
+	this.props = append(this.props, filterProps...)
+	tableOnly := strings.Split(table, " ")[0]
+	return fmt.Sprintf(`SELECT %s FROM %s %s AND %s.organisation_id IN (%s) %s %s`, strings.Join(columns, ", "), table, filterQuery, tableOnly, organisationSubtree, order.String(columns), pagination.PaginationQuery())
+}
+
+func (this *ByOrgTree) Args() []any {
+	return append([]any{this.ID}, this.props...)
+}
+
+// organisationSubtree selects the IDs of the organisation in $1 and all its descendants.
+const organisationSubtree = `WITH RECURSIVE subtree AS (
+	SELECT id FROM organisations WHERE id = $1
+	UNION
+	SELECT organisations.id FROM organisations INNER JOIN subtree ON organisations.parent_id = subtree.id
+) SELECT id FROM subtree`
+
 type OrganisationsContainingUser struct {
 	ID string
 }
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"context"
+	"doubleboiler/config"
+	"fmt"
+	"time"
+)
+
+// Quotas are how much an organisation may have. Zero means there's no limit.
+type Quotas struct {
+	Members      int
+	SomeThings   int
+	EmailsPerDay int
+	StorageBytes int64
+}
+
+func defaultQuotas() Quotas {
+	return Quotas{
+		Members:      config.MEMBER_QUOTA,
+		SomeThings:   config.SOME_THING_QUOTA,
+		EmailsPerDay: config.EMAIL_QUOTA_PER_DAY,
+		StorageBytes: config.ATTACHMENT_QUOTA_BYTES,
+	}
+}
+
+// Quotas are the organisation's plan's quotas, with any overrides a superadmin has set on top.
+func (this Organisation) Quotas(ctx context.Context) (Quotas, error) {
+	plan, err := this.Plan(ctx)
+	if err != nil {
+		return Quotas{}, err
+	}
+
+	quotas := plan.Quotas()
+	if this.MemberQuota > 0 {
+		quotas.Members = this.MemberQuota
+	}
+	if this.SomeThingQuota > 0 {
+		quotas.SomeThings = this.SomeThingQuota
+	}
+	if this.EmailQuota > 0 {
+		quotas.EmailsPerDay = this.EmailQuota
+	}
+	if this.AttachmentQuota > 0 {
+		quotas.StorageBytes = this.AttachmentQuota
+	}
+	return quotas, nil
+}
+
+// Usage is read from counters the database keeps up to date as rows come and go.
+type Usage struct {
+	Members      int
+	SomeThings   int
+	EmailsToday  int
+	StorageBytes int64
+}
+
+func (this Organisation) Usage(ctx context.Context) (Usage, error) {
+	db := ctx.Value("tx").(Querier)
+
+	usage := Usage{}
+	err := db.QueryRowContext(ctx, `SELECT
+	COALESCE((SELECT members FROM organisation_usage WHERE organisation_id = $1), 0),
+	COALESCE((SELECT some_things FROM organisation_usage WHERE organisation_id = $1), 0),
+	COALESCE((SELECT storage_bytes FROM organisation_usage WHERE organisation_id = $1), 0),
+	COALESCE((SELECT sent FROM organisation_email_usage WHERE organisation_id = $1 AND day = $2), 0)`, this.ID, emailDay(time.Now())).Scan(&usage.Members, &usage.SomeThings, &usage.StorageBytes, &usage.EmailsToday)
+	return usage, err
+}
+
+// QuotaWarningPercent is how close to a quota an organisation gets before it's warned.
+const QuotaWarningPercent = 80
+
+type QuotaReport struct {
+	Label string
+	Used  int64
+	Limit int64
+	Bytes bool
+}
+
+func (this QuotaReport) Percent() int64 {
+	if this.Limit <= 0 {
+		return 0
+	}
+	percent := this.Used * 100 / this.Limit
+	if percent > 100 {
+		return 100
+	}
+	return percent
+}
+
+func (this QuotaReport) Warning() bool {
+	return this.Limit > 0 && this.Used*100 >= this.Limit*QuotaWarningPercent
+}
+
+func (this QuotaReport) Reached() bool {
+	return this.Limit > 0 && this.Used >= this.Limit
+}
+
+func (this Quotas) Report(usage Usage) []QuotaReport {
+	return []QuotaReport{
+		{Label: "Members", Used: int64(usage.Members), Limit: int64(this.Members)},
+		{Label: "SomeThings", Used: int64(usage.SomeThings), Limit: int64(this.SomeThings)},
+		{Label: "Emails today", Used: int64(usage.EmailsToday), Limit: int64(this.EmailsPerDay)},
+		{Label: "Storage", Used: usage.StorageBytes, Limit: this.StorageBytes, Bytes: true},
+	}
+}
+
+// quotasForUpdate locks the organisation row for the rest of the transaction, so concurrent saves
+// can't both squeeze under a quota, and returns its quotas along with what it's using.
+func quotasForUpdate(ctx context.Context, organisationID string) (Organisation, Quotas, Usage, error) {
+	db := ctx.Value("tx").(Querier)
+
+	org := Organisation{}
+
+	if _, err := db.ExecContext(ctx, "SELECT id FROM organisations WHERE id = $1 FOR UPDATE", organisationID); err != nil {
+		return org, Quotas{}, Usage{}, err
+	}
+
+	if err := org.FindByID(ctx, organisationID); err != nil {
+		return org, Quotas{}, Usage{}, err
+	}
+
+	quotas, err := org.Quotas(ctx)
+	if err != nil {
+		return org, quotas, Usage{}, err
+	}
+
+	usage, err := org.Usage(ctx)
+	return org, quotas, usage, err
+}
+
+func errOverQuota(org Organisation, limit int, things string) error {
+	return ClientSafeError{Message: fmt.Sprintf("%s has reached its limit of %d %s. Upgrade its plan or contact support to raise it.", org.Name, limit, things)}
+}
+
+// Emails are counted against the day in UTC.
+func emailDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// consumeEmailQuota counts emails against the organisation's daily quota, refusing them all if
+// there isn't room for every one.
+func consumeEmailQuota(ctx context.Context, organisationID string, count int) error {
+	db := ctx.Value("tx").(Querier)
+
+	org := Organisation{}
+	if err := org.FindByID(ctx, organisationID); err != nil {
+		return err
+	}
+
+	quotas, err := org.Quotas(ctx)
+	if err != nil {
+		return err
+	}
+
+	if quotas.EmailsPerDay <= 0 {
+		return nil
+	}
+
+	if count > quotas.EmailsPerDay {
+		return errOverQuota(org, quotas.EmailsPerDay, "emails a day")
+	}
+
+	rows, err := db.QueryContext(ctx, `INSERT INTO organisation_email_usage (organisation_id, day, sent) VALUES ($1, $2, $3)
+ON CONFLICT (organisation_id, day) DO UPDATE SET sent = organisation_email_usage.sent + EXCLUDED.sent
+WHERE organisation_email_usage.sent + EXCLUDED.sent <= $4
+RETURNING sent`, organisationID, emailDay(time.Now()), count, quotas.EmailsPerDay)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		return errOverQuota(org, quotas.EmailsPerDay, "emails a day")
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"doubleboiler/config"
+	"testing"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuotas(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	quotas, err := org.Quotas(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, defaultQuotas(), quotas)
+
+	sub := Subscription{}
+	sub.New(org.ID, FreePlan)
+	assert.Nil(t, sub.Save(ctx))
+
+	quotas, err = org.Quotas(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, FreePlan.MaxMembers, quotas.Members)
+	assert.Equal(t, FreePlan.StorageBytes, quotas.StorageBytes)
+
+	for i := 0; i < FreePlan.MaxMembers; i++ {
+		user := userFixture()
+		assert.Nil(t, user.Save(ctx))
+		ou := organisationUserFixture(user.ID, org.ID)
+		assert.Nil(t, ou.Save(ctx))
+
+		// Existing members can still be updated at the limit.
+		ou.Name = "Updated"
+		assert.Nil(t, ou.Save(ctx))
+	}
+
+	user := userFixture()
+	assert.Nil(t, user.Save(ctx))
+	ou := organisationUserFixture(user.ID, org.ID)
+	assert.IsType(t, ClientSafeError{}, ou.Save(ctx))
+
+	for i := 0; i < FreePlan.MaxSomeThings; i++ {
+		someThing := someThingFixture(org.ID)
+		assert.Nil(t, someThing.Save(ctx))
+	}
+
+	someThing := someThingFixture(org.ID)
+	assert.IsType(t, ClientSafeError{}, someThing.Save(ctx))
+
+	usage, err := org.Usage(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, FreePlan.MaxMembers, usage.Members)
+	assert.Equal(t, FreePlan.MaxSomeThings, usage.SomeThings)
+
+	// Soft deleting frees up room.
+	db := ctx.Value("tx").(Querier)
+	_, err = db.ExecContext(ctx, "UPDATE some_things SET soft_deleted = true WHERE id IN (SELECT id FROM some_things WHERE organisation_id = $1 LIMIT 1)", org.ID)
+	assert.Nil(t, err)
+	assert.Nil(t, someThing.Save(ctx))
+
+	org.MemberQuota = FreePlan.MaxMembers + 1
+	org.EmailQuota = 2
+	assert.Nil(t, org.Save(ctx))
+	assert.Nil(t, ou.Save(ctx))
+
+	email := func() error {
+		task := kewpie.Task{}
+		task.Tags.Set("organisation_id", org.ID)
+		return Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task)
+	}
+
+	assert.Nil(t, email())
+	assert.Nil(t, email())
+	assert.IsType(t, ClientSafeError{}, email())
+
+	usage, err = org.Usage(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, usage.EmailsToday)
+}
+
+func TestQuotaReport(t *testing.T) {
+	t.Parallel()
+
+	quotas := Quotas{Members: 10, SomeThings: 0, EmailsPerDay: 100, StorageBytes: 1000}
+	report := quotas.Report(Usage{Members: 8, SomeThings: 500, EmailsToday: 100, StorageBytes: 10})
+
+	assert.True(t, report[0].Warning())
+	assert.False(t, report[0].Reached())
+	assert.Equal(t, int64(80), report[0].Percent())
+
+	assert.False(t, report[1].Warning())
+	assert.Equal(t, int64(0), report[1].Percent())
+
+	assert.True(t, report[2].Reached())
+
+	assert.False(t, report[3].Warning())
+	assert.True(t, report[3].Bytes)
+}
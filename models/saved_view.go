@@ -0,0 +1,265 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// SavedViewLists are the list pages views can be saved against, keyed by path.
+var SavedViewLists = map[string]string{
+	"some-things":    "SomeThings",
+	"communications": "Communications",
+	"audits":         "Audits",
+	"users":          "Users",
+}
+
+// savedViewIgnoredParams are query params that describe where you are rather than what you're looking at.
+var savedViewIgnoredParams = []string{"view", "skip", "cursor", "csrf"}
+
+// SavedView is a named combination of filters, sort order and page size for a list page.
+// The combination is kept as the query string the list page understands.
+type SavedView struct {
+	ID             string
+	Revision       string
+	UserID         string
+	OrganisationID sql.NullString
+	List           string
+	Name           string
+	Query          string
+	Shared         bool
+	IsDefault      bool
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (this *SavedView) colmap() *Colmap {
+	return &Colmap{
+		"id":              &this.ID,
+		"revision":        &this.Revision,
+		"user_id":         &this.UserID,
+		"organisation_id": &this.OrganisationID,
+		"list":            &this.List,
+		"name":            &this.Name,
+		"query":           &this.Query,
+		"shared":          &this.Shared,
+		"is_default":      &this.IsDefault,
+		"created_at":      &this.CreatedAt,
+		"updated_at":      &this.UpdatedAt,
+	}
+}
+
+func (this *SavedView) New(userID, organisationID, list, name string, values url.Values) {
+	this.ID = uuid.NewV4().String()
+	this.UserID = userID
+	this.OrganisationID = sql.NullString{
+		Valid:  organisationID != "",
+		String: organisationID,
+	}
+	this.List = list
+	this.Name = strings.TrimSpace(name)
+	this.SetValues(values)
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+func (this *SavedView) SetValues(values url.Values) {
+	kept := url.Values{}
+	for k, v := range values {
+		ignored := false
+		for _, param := range savedViewIgnoredParams {
+			if k == param {
+				ignored = true
+			}
+		}
+		if !ignored {
+			kept[k] = v
+		}
+	}
+	this.Query = kept.Encode()
+}
+
+func (this SavedView) Values() url.Values {
+	values, err := url.ParseQuery(this.Query)
+	if err != nil {
+		return url.Values{}
+	}
+	return values
+}
+
+// Path is the list page URL with the view applied.
+func (this SavedView) Path() string {
+	values := this.Values()
+	values.Set("view", this.ID)
+	return "/" + this.List + "?" + values.Encode()
+}
+
+func (this *SavedView) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "saved_views", this.ID, this.OrganisationID.String)
+}
+
+func (this *SavedView) Save(ctx context.Context) error {
+	if _, ok := SavedViewLists[this.List]; !ok {
+		return ClientSafeError{Message: fmt.Sprintf("Views can't be saved for %s", this.List)}
+	}
+
+	if this.Name == "" {
+		return ClientSafeError{Message: "A view needs a name"}
+	}
+
+	if this.Shared && !this.OrganisationID.Valid {
+		return ClientSafeError{Message: "Only views of an organisation's lists can be shared"}
+	}
+
+	if this.IsDefault {
+		if err := this.clearOtherDefaults(ctx); err != nil {
+			return err
+		}
+	}
+
+	q, props, newRev := StandardSave("saved_views", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+// clearOtherDefaults makes sure there's only one default per person per list, and one shared
+// default per organisation per list.
+func (this SavedView) clearOtherDefaults(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	if this.Shared {
+		_, err := db.ExecContext(ctx, "UPDATE saved_views SET is_default = false WHERE id != $1 AND list = $2 AND shared AND organisation_id = $3", this.ID, this.List, this.OrganisationID)
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, "UPDATE saved_views SET is_default = false WHERE id != $1 AND list = $2 AND NOT shared AND user_id = $3", this.ID, this.List, this.UserID)
+	return err
+}
+
+func (this *SavedView) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("saved_views", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *SavedView) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this SavedView) Label() string {
+	return this.Name
+}
+
+func (this SavedView) Delete(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	_, err := db.ExecContext(ctx, this.auditQuery(ctx, "D")+"DELETE FROM saved_views WHERE id = $1 AND revision = $2", this.ID, this.Revision)
+	return err
+}
+
+type SavedViews struct {
+	Data     []SavedView
+	Criteria Criteria
+}
+
+// SavedViewsFor finds the views a user can apply to a list: their own and any shared with the organisation.
+type SavedViewsFor struct {
+	UserID         string
+	OrganisationID string
+	List           string
+}
+
+func (this SavedViews) colmap() *Colmap {
+	r := SavedView{}
+	return r.colmap()
+}
+
+func (SavedViews) AvailableFilters() Filters {
+	return standardFilters("saved_views")
+}
+
+func (this SavedViews) ByID() map[string]SavedView {
+	ret := map[string]SavedView{}
+	for _, view := range this.Data {
+		ret[view.ID] = view
+	}
+	return ret
+}
+
+// Default is the view to apply when the list is loaded bare. A person's own default wins over
+// one shared with the organisation.
+func (this SavedViews) Default(userID string) (SavedView, bool) {
+	shared := SavedView{}
+	for _, view := range this.Data {
+		if !view.IsDefault {
+			continue
+		}
+		if !view.Shared && view.UserID == userID {
+			return view, true
+		}
+		if view.Shared {
+			shared = view
+		}
+	}
+	return shared, shared.ID != ""
+}
+
+func (this *SavedViews) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "saved_views"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "saved_views"}
+		case SavedViewsFor:
+			filterQuery, filterProps := criteria.Filters.Query(4)
+			props := append([]any{v.List, v.UserID, v.OrganisationID}, filterProps...)
+
+			rows, err = db.QueryContext(ctx, `
+		SELECT
+		`+strings.Join(cols, ",")+`
+		FROM saved_views
+		`+filterQuery+`
+		AND list = $1
+		AND organisation_id IS NOT DISTINCT FROM NULLIF($3, '')::uuid
+		AND (user_id = $2 OR shared)
+		ORDER BY name`+criteria.Pagination.PaginationQuery(), props...)
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "saved_views", criteria.Filters, criteria.Pagination, Order{By: "name"}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		view := SavedView{}
+		props := view.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, view)
+	}
+	return err
+}
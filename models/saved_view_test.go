@@ -0,0 +1,138 @@
+package models
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, savedViewFix())
+	modelCollectionsUnderTest = append(modelCollectionsUnderTest, savedViewsFix())
+}
+
+func savedViewFixture(userID, organisationID string) (v SavedView) {
+	v.New(userID, organisationID, "some-things", randString(), url.Values{"filter": {"is-deleted"}, "limit": {"10"}})
+	return
+}
+
+func (SavedView) blank() model {
+	return &SavedView{}
+}
+
+func (v SavedView) id() string {
+	return v.ID
+}
+
+func (v *SavedView) nullDynamicValues() {
+	v.CreatedAt = time.Time{}
+	v.UpdatedAt = time.Time{}
+	v.Revision = ""
+}
+
+func (SavedView) tablename() string {
+	return "saved_views"
+}
+
+func (SavedViews) tablename() string {
+	return "saved_views"
+}
+
+func (SavedViews) blank() models {
+	return &SavedViews{}
+}
+
+func savedViewsFix() modelCollectionFixture {
+	org := organisationFixture()
+	user := userFixture()
+	return modelCollectionFixture{
+		deps: []model{&org, &user},
+		collection: &SavedViews{
+			Data: []SavedView{
+				savedViewFixture(user.ID, org.ID),
+				savedViewFixture(user.ID, org.ID),
+			},
+		},
+	}
+}
+
+func savedViewFix() []model {
+	org := organisationFixture()
+	user := userFixture()
+	fix := savedViewFixture(user.ID, org.ID)
+	return []model{
+		&org,
+		&user,
+		&fix,
+	}
+}
+
+func (this SavedViews) data() []model {
+	ret := []model{}
+	for _, m := range this.Data {
+		ret = append(ret, &m)
+	}
+	return ret
+}
+
+func TestSavedViewValues(t *testing.T) {
+	t.Parallel()
+
+	view := SavedView{}
+	view.New("", "", "some-things", "Mine", url.Values{
+		"filter": {"is-deleted"},
+		"skip":   {"50"},
+		"view":   {"abc"},
+		"csrf":   {"nope"},
+	})
+
+	assert.Equal(t, "filter=is-deleted", view.Query)
+	assert.Equal(t, "/some-things?filter=is-deleted&view="+view.ID, view.Path())
+}
+
+func TestSavedViewsFor(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	me := userFixture()
+	assert.Nil(t, me.Save(ctx))
+	them := userFixture()
+	assert.Nil(t, them.Save(ctx))
+
+	mine := savedViewFixture(me.ID, org.ID)
+	assert.Nil(t, mine.Save(ctx))
+
+	theirs := savedViewFixture(them.ID, org.ID)
+	assert.Nil(t, theirs.Save(ctx))
+
+	shared := savedViewFixture(them.ID, org.ID)
+	shared.Shared = true
+	shared.IsDefault = true
+	assert.Nil(t, shared.Save(ctx))
+
+	views := SavedViews{}
+	criteria := Criteria{}
+	AddCustomQuery(SavedViewsFor{UserID: me.ID, OrganisationID: org.ID, List: "some-things"}, &criteria)
+	assert.Nil(t, views.FindAll(ctx, criteria))
+	assert.Equal(t, 2, len(views.Data))
+	assert.NotContains(t, views.ByID(), theirs.ID)
+
+	def, ok := views.Default(me.ID)
+	assert.True(t, ok)
+	assert.Equal(t, shared.ID, def.ID)
+
+	mine.IsDefault = true
+	assert.Nil(t, mine.Save(ctx))
+
+	views = SavedViews{}
+	assert.Nil(t, views.FindAll(ctx, criteria))
+	def, ok = views.Default(me.ID)
+	assert.True(t, ok)
+	assert.Equal(t, mine.ID, def.ID)
+
+	closeTx(t, ctx)
+}
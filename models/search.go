@@ -1,13 +1,239 @@
 package models
 
 import (
+	"context"
+	"fmt"
+	"html"
+	"html/template"
+	"strings"
+	"unicode"
+
 	scumsearch "github.com/davidbanham/scum/search"
 )
 
-type SearchResult = scumsearch.SearchResult
-type SearchResults = scumsearch.SearchResults
 type SearchCriteria = scumsearch.SearchCriteria
 
 var BasicRoleCheck = scumsearch.BasicRoleCheck
 
-type ByPhrase = scumsearch.ByPhrase
+// ts_headline wraps matches in these. They're swapped for <mark> once the rest has been escaped.
+const (
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// ByPrefix matches rows containing every word in the phrase, treating each word as a prefix so
+// partial words still find results.
+type ByPrefix struct {
+	OrganisationID string
+	Phrase         string
+	// Restrictions narrow the rows of one searchable, keyed by table name, for people who can
+	// search it but can't see all of it.
+	Restrictions map[string]Filter
+	props        []any
+}
+
+// TSQuery is the phrase as a to_tsquery expression. Anything that isn't a letter or digit is
+// dropped so the phrase can't be read as tsquery syntax.
+func (this ByPrefix) TSQuery() string {
+	words := strings.FieldsFunc(this.Phrase, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := []string{}
+	for _, word := range words {
+		terms = append(terms, word+":*")
+	}
+	return strings.Join(terms, " & ")
+}
+
+func (this *ByPrefix) matches(entities Searchables, filters Filters, cols func(Searchable) string) string {
+	filterQuery, filterProps := filters.Query(3)
+	this.props = filterProps
+
+	parts := []string{}
+	for _, entity := range entities {
+		restriction := ""
+		if filter, ok := this.Restrictions[entity.Tablename]; ok {
+			q, props := filter.Query(3 + len(this.props))
+			this.props = append(this.props, props...)
+			restriction = " AND " + q
+		}
+		parts = append(parts, `
+SELECT `+cols(entity)+`
+FROM `+entity.Tablename+`, to_tsquery('english', $2) query `+filterQuery+` AND `+entity.Tablename+`.organisation_id = $1 AND query @@ `+entity.Tablename+`.ts`+restriction)
+	}
+	return strings.Join(parts, " UNION ALL ")
+}
+
+func (this *ByPrefix) Construct(entities Searchables, filters Filters, pagination Pagination) string {
+	matches := this.matches(entities, filters, func(entity Searchable) string {
+		return fmt.Sprintf("text '%s' AS entity_type, text '%s' AS uri_path, %s.id AS id, %s AS label, ts_rank(%s.ts, query) AS rank", entity.EntityType, entity.Path, entity.Tablename, entity.Label, entity.Tablename)
+	})
+
+	// Headlines are expensive, so they're only made for the page being shown.
+	return `SELECT entity_type, uri_path, id, coalesce(label, ''), rank, coalesce(ts_headline('english', label, to_tsquery('english', $2), 'StartSel="` + highlightStart + `", StopSel="` + highlightStop + `", MaxWords=20, MinWords=8'), '')
+FROM (` + matches + ` ORDER BY rank DESC` + pagination.PaginationQuery() + `) results ORDER BY rank DESC`
+}
+
+// CountQuery counts every match by entity type.
+func (this *ByPrefix) CountQuery(entities Searchables, filters Filters) string {
+	matches := this.matches(entities, filters, func(entity Searchable) string {
+		return fmt.Sprintf("text '%s' AS entity_type", entity.EntityType)
+	})
+	return `SELECT entity_type, count(*) FROM (` + matches + `) results GROUP BY entity_type ORDER BY count(*) DESC`
+}
+
+func (this *ByPrefix) Args() []any {
+	return append([]any{this.OrganisationID, this.TSQuery()}, this.props...)
+}
+
+func (this ByPrefix) UserInput() string {
+	return this.Phrase
+}
+
+type SearchResult struct {
+	Path       string
+	EntityType string
+	ID         string
+	Label      string
+	Rank       float64
+	Headline   string
+}
+
+// Highlighted is the headline with matched words marked up.
+func (this SearchResult) Highlighted() template.HTML {
+	if this.Headline == "" {
+		return template.HTML(html.EscapeString(this.Label))
+	}
+	escaped := html.EscapeString(this.Headline)
+	escaped = strings.ReplaceAll(escaped, highlightStart, "<mark>")
+	escaped = strings.ReplaceAll(escaped, highlightStop, "</mark>")
+	return template.HTML(escaped)
+}
+
+// SearchCount is how many results there are of one entity type, across all pages.
+type SearchCount struct {
+	EntityType string
+	Count      int
+}
+
+// SearchGroup is the results of one entity type on the current page.
+type SearchGroup struct {
+	EntityType string
+	Count      int
+	Data       []SearchResult
+}
+
+type SearchResults struct {
+	Data     []SearchResult
+	Counts   []SearchCount
+	Criteria SearchCriteria
+}
+
+// permitted is the searchables the criteria asks for that the roles are allowed to see.
+func permitted(roles scumsearch.Roles, criteria SearchCriteria, searchables Searchables) Searchables {
+	allowed := searchables.FilterByRole(roles, criteria.Query).ByTableName()
+	ret := Searchables{}
+	for _, searchable := range searchables.FilterByTableNames(criteria.Entities) {
+		if _, ok := allowed[searchable.Tablename]; ok {
+			ret = append(ret, searchable)
+		}
+	}
+	return ret
+}
+
+func (this *SearchResults) FindAll(ctx context.Context, roles scumsearch.Roles, criteria SearchCriteria, searchables Searchables) error {
+	this.Criteria = criteria
+
+	filtered := permitted(roles, criteria, searchables)
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	db := ctx.Value("tx").(Querier)
+
+	rows, err := db.QueryContext(ctx, criteria.Query.Construct(filtered, criteria.Filters, criteria.Pagination), criteria.Query.Args()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result := SearchResult{}
+		if err := rows.Scan(&result.EntityType, &result.Path, &result.ID, &result.Label, &result.Rank, &result.Headline); err != nil {
+			return err
+		}
+		this.Data = append(this.Data, result)
+	}
+
+	return err
+}
+
+// Count fills in how many matches there are of each entity type. Only queries that can count
+// themselves are counted.
+func (this *SearchResults) Count(ctx context.Context, roles scumsearch.Roles, criteria SearchCriteria, searchables Searchables) error {
+	counter, ok := criteria.Query.(interface {
+		CountQuery(Searchables, Filters) string
+	})
+	if !ok {
+		return nil
+	}
+
+	filtered := permitted(roles, criteria, searchables)
+	if len(filtered) == 0 {
+		return nil
+	}
+
+	db := ctx.Value("tx").(Querier)
+
+	rows, err := db.QueryContext(ctx, counter.CountQuery(filtered, criteria.Filters), criteria.Query.Args()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	this.Counts = []SearchCount{}
+	for rows.Next() {
+		count := SearchCount{}
+		if err := rows.Scan(&count.EntityType, &count.Count); err != nil {
+			return err
+		}
+		this.Counts = append(this.Counts, count)
+	}
+
+	return err
+}
+
+func (this SearchResults) Total() int {
+	total := 0
+	for _, count := range this.Counts {
+		total += count.Count
+	}
+	return total
+}
+
+// Groups is the page's results by entity type, most relevant type first. Types with matches only
+// on other pages are included so their counts still show.
+func (this SearchResults) Groups() []SearchGroup {
+	counts := map[string]int{}
+	for _, count := range this.Counts {
+		counts[count.EntityType] = count.Count
+	}
+
+	ret := []SearchGroup{}
+	index := map[string]int{}
+	for _, result := range this.Data {
+		i, ok := index[result.EntityType]
+		if !ok {
+			i = len(ret)
+			index[result.EntityType] = i
+			ret = append(ret, SearchGroup{EntityType: result.EntityType, Count: counts[result.EntityType]})
+		}
+		ret[i].Data = append(ret[i].Data, result)
+	}
+	for _, count := range this.Counts {
+		if _, ok := index[count.EntityType]; !ok {
+			ret = append(ret, SearchGroup{EntityType: count.EntityType, Count: count.Count})
+		}
+	}
+	return ret
+}
@@ -1,5 +1,84 @@
 package models
 
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestByPrefixTSQuery(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "quick:* & brow:*", ByPrefix{Phrase: "quick brow"}.TSQuery())
+	assert.Equal(t, "foo:* & bar:*", ByPrefix{Phrase: "foo' | !bar:*"}.TSQuery())
+	assert.Equal(t, "", ByPrefix{Phrase: "  "}.TSQuery())
+}
+
+func TestSearchResultHighlighted(t *testing.T) {
+	t.Parallel()
+
+	result := SearchResult{Label: "<b>Widget</b>", Headline: "<b>" + highlightStart + "Widget" + highlightStop + "</b>"}
+	assert.Equal(t, "&lt;b&gt;<mark>Widget</mark>&lt;/b&gt;", string(result.Highlighted()))
+
+	result.Headline = ""
+	assert.Equal(t, "&lt;b&gt;Widget&lt;/b&gt;", string(result.Highlighted()))
+}
+
+func TestSearchResultsGroups(t *testing.T) {
+	t.Parallel()
+
+	results := SearchResults{
+		Data: []SearchResult{
+			{EntityType: "SomeThing", ID: "1"},
+			{EntityType: "Broadcast", ID: "2"},
+			{EntityType: "SomeThing", ID: "3"},
+		},
+		Counts: []SearchCount{
+			{EntityType: "SomeThing", Count: 12},
+			{EntityType: "Broadcast", Count: 1},
+			{EntityType: "Organisation", Count: 1},
+		},
+	}
+
+	groups := results.Groups()
+	assert.Len(t, groups, 3)
+	assert.Equal(t, "SomeThing", groups[0].EntityType)
+	assert.Equal(t, 12, groups[0].Count)
+	assert.Len(t, groups[0].Data, 2)
+	assert.Equal(t, "Broadcast", groups[1].EntityType)
+	assert.Equal(t, "Organisation", groups[2].EntityType)
+	assert.Len(t, groups[2].Data, 0)
+	assert.Equal(t, 14, results.Total())
+}
+
+func TestPrefixSearch(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	fix := someThingFixture(org.ID)
+	fix.Name = "Thermostatic " + randString()
+	assert.Nil(t, fix.Save(ctx))
+
+	criteria := SearchCriteria{
+		Entities: []string{"some_things"},
+		Query:    &ByPrefix{OrganisationID: org.ID, Phrase: "thermo"},
+	}
+
+	results := SearchResults{}
+	assert.Nil(t, results.FindAll(ctx, Roles{ValidRoles.ByName("admin")}, criteria, SearchTargets))
+	assert.Nil(t, results.Count(ctx, Roles{ValidRoles.ByName("admin")}, criteria, SearchTargets))
+
+	assert.Len(t, results.Data, 1)
+	assert.Equal(t, fix.ID, results.Data[0].ID)
+	assert.Contains(t, string(results.Data[0].Highlighted()), "<mark>Thermostatic</mark>")
+	assert.Equal(t, []SearchCount{{EntityType: "SomeThing", Count: 1}}, results.Counts)
+
+	closeTx(t, ctx)
+}
+
 //func TestSearchResults(t *testing.T) {
 //	t.Parallel()
 //	ctx := getCtx(t)
@@ -153,3 +232,29 @@ package models
 //
 //	closeTx(t, ctx)
 //}
+
+func TestOrganisationUserSearch(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	user := userFixture()
+	assert.Nil(t, user.Save(ctx))
+
+	fix := organisationUserFixture(user.ID, org.ID)
+	assert.Nil(t, fix.Save(ctx))
+
+	results := SearchResults{}
+	assert.Nil(t, results.FindAll(ctx, Roles{ValidRoles.ByName("teamlead")}, SearchCriteria{
+		Entities: []string{"organisations_users"},
+		Query:    &ByPrefix{OrganisationID: org.ID, Phrase: user.Email},
+	}, SearchTargets))
+
+	assert.Len(t, results.Data, 1)
+	assert.Equal(t, fix.ID, results.Data[0].ID)
+	assert.Equal(t, user.Email, results.Data[0].Label)
+
+	closeTx(t, ctx)
+}
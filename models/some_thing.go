@@ -3,6 +3,8 @@ package models
 import (
 	"context"
 	"database/sql"
+	"doubleboiler/util"
+	"fmt"
 	"log"
 	"time"
 
@@ -20,6 +22,8 @@ type SomeThing struct {
 	Description    string
 	SoftDeleted    bool
 	OrganisationID string
+	Tags           TagList
+	CustomFields   CustomFieldValues
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 	Revision       string
@@ -31,6 +35,8 @@ func (this *SomeThing) colmap() *Colmap {
 		"name":            &this.Name,
 		"description":     &this.Description,
 		"organisation_id": &this.OrganisationID,
+		"tags":            &this.Tags,
+		"custom_fields":   &this.CustomFields,
 		"created_at":      &this.CreatedAt,
 		"updated_at":      &this.UpdatedAt,
 		"revision":        &this.Revision,
@@ -43,6 +49,8 @@ func (this *SomeThing) New(name, description, organisationID string) {
 	this.Name = name
 	this.Description = description
 	this.OrganisationID = organisationID
+	this.Tags = TagList{}
+	this.CustomFields = CustomFieldValues{}
 	this.CreatedAt = time.Now()
 	this.UpdatedAt = time.Now()
 }
@@ -68,6 +76,16 @@ func (this *SomeThing) auditQuery(ctx context.Context, action string) string {
 }
 
 func (this *SomeThing) Save(ctx context.Context) error {
+	if err := this.validateCustomData(ctx); err != nil {
+		return err
+	}
+
+	if this.Revision == "" {
+		if err := this.checkQuota(ctx); err != nil {
+			return err
+		}
+	}
+
 	q, props, newRev := StandardSave("some_things", this.colmap(), this.auditQuery(ctx, "U"))
 
 	if err := ExecSave(ctx, q, props); err != nil {
@@ -79,13 +97,76 @@ func (this *SomeThing) Save(ctx context.Context) error {
 	return nil
 }
 
+func (this SomeThing) checkQuota(ctx context.Context) error {
+	org, quotas, usage, err := quotasForUpdate(ctx, this.OrganisationID)
+	if err != nil {
+		return err
+	}
+	if quotas.SomeThings > 0 && usage.SomeThings >= quotas.SomeThings {
+		return errOverQuota(org, quotas.SomeThings, "SomeThings")
+	}
+	return nil
+}
+
+// validateCustomData checks the tags are in the organisation's vocabulary and the custom field
+// values match the organisation's field definitions.
+func (this *SomeThing) validateCustomData(ctx context.Context) error {
+	vocabulary := Tags{}
+	if err := vocabulary.FindAll(ctx, Criteria{Query: &ByOrg{ID: this.OrganisationID}}); err != nil {
+		return err
+	}
+
+	known := vocabulary.Names()
+	seen := map[string]bool{}
+	tags := TagList{}
+	for _, tag := range this.Tags {
+		if !util.Contains(known, tag) {
+			return ClientSafeError{Message: fmt.Sprintf("%s is not one of this organisation's tags", tag)}
+		}
+		if !seen[tag] {
+			tags = append(tags, tag)
+		}
+		seen[tag] = true
+	}
+	this.Tags = tags
+
+	fields := CustomFields{}
+	if err := fields.FindAll(ctx, Criteria{Query: &ByOrg{ID: this.OrganisationID}}); err != nil {
+		return err
+	}
+
+	byKey := fields.ByKey()
+	for key, value := range this.CustomFields {
+		field, ok := byKey[key]
+		if !ok {
+			return ClientSafeError{Message: fmt.Sprintf("%s is not one of this organisation's fields", key)}
+		}
+		if err := field.Validate(value); err != nil {
+			return err
+		}
+	}
+
+	for _, field := range fields.Data {
+		if !field.Required {
+			continue
+		}
+		if value, ok := this.CustomFields[field.Key]; !ok || value == "" {
+			return ClientSafeError{Message: fmt.Sprintf("%s is required", field.FieldLabel)}
+		}
+	}
+
+	return nil
+}
+
 func (this SomeThing) Label() string {
 	return this.Name
 }
 
 type SomeThings struct {
-	Data     []SomeThing
-	Criteria Criteria
+	Data         []SomeThing
+	Criteria     Criteria
+	Vocabulary   Tags
+	CustomFields CustomFields
 }
 
 func (this SomeThings) colmap() *Colmap {
@@ -93,7 +174,19 @@ func (this SomeThings) colmap() *Colmap {
 	return r.colmap()
 }
 
-func (SomeThings) AvailableFilters() Filters {
+// LoadDefinitions fetches the organisation's tags and custom fields so AvailableFilters can offer
+// filters on them.
+func (this *SomeThings) LoadDefinitions(ctx context.Context, organisationID string) error {
+	this.Vocabulary = Tags{}
+	if err := this.Vocabulary.FindAll(ctx, Criteria{Query: &ByOrg{ID: organisationID}}); err != nil {
+		return err
+	}
+
+	this.CustomFields = CustomFields{}
+	return this.CustomFields.FindAll(ctx, Criteria{Query: &ByOrg{ID: organisationID}})
+}
+
+func (this SomeThings) AvailableFilters() Filters {
 	isDeleted := HasProp{}
 	if err := isDeleted.Hydrate(HasPropOpts{
 		Label: "Is Deleted",
@@ -105,7 +198,31 @@ func (SomeThings) AvailableFilters() Filters {
 		log.Fatal(err)
 	}
 
-	return append(standardFilters("some_things"), &isDeleted)
+	ret := append(standardFilters("some_things"), &isDeleted)
+
+	for _, tag := range this.Vocabulary.Data {
+		ret = append(ret, &JSONBContains{
+			FilterID:    "tag-" + tag.ID,
+			FilterLabel: "Tagged " + tag.Name,
+			Table:       "some_things",
+			Col:         "tags",
+			Document:    []string{tag.Name},
+		})
+	}
+
+	for _, field := range this.CustomFields.Data {
+		ret = append(ret, field.Filters("some_things")...)
+	}
+
+	return ret
+}
+
+func (SomeThings) Sortables() Sortables {
+	return Sortables{
+		{Key: "name", Label: "Name", Col: "some_things.name"},
+		{Key: "created", Label: "Created", Col: "some_things.created_at"},
+		{Key: "updated", Label: "Updated", Col: "some_things.updated_at"},
+	}
 }
 
 func (SomeThings) Searchable() Searchable {
@@ -159,7 +276,7 @@ func (this *SomeThings) FindAll(ctx context.Context, criteria Criteria) error {
 			return ErrInvalidQuery{Query: v, Model: "some_things"}
 		}
 	case Query:
-		rows, err = db.QueryContext(ctx, v.Construct(cols, "some_things", criteria.Filters, criteria.Pagination, Order{By: "name"}), v.Args()...)
+		rows, err = db.QueryContext(ctx, construct(v, cols, "some_things", criteria, this.Sortables(), Order{By: "name"}), v.Args()...)
 	}
 	if err != nil {
 		return err
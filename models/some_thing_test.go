@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"net/url"
 	"testing"
 	"time"
 
@@ -165,3 +166,62 @@ func TestSomeThingHardDelete(t *testing.T) {
 
 	closeTx(t, ctx)
 }
+
+func TestSomeThingCustomData(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	tag := tagFixture(org.ID)
+	assert.Nil(t, tag.Save(ctx))
+
+	field := CustomField{}
+	field.New(org.ID, "Due Date", FieldTypeDate, nil, true)
+	assert.Nil(t, field.Save(ctx))
+
+	fix := someThingFixture(org.ID)
+	assert.Equal(t, ClientSafeError{Message: "Due Date is required"}, fix.Save(ctx))
+
+	fix.CustomFields = CustomFieldValues{"due_date": "soon"}
+	assert.NotNil(t, fix.Save(ctx))
+
+	fix.CustomFields = CustomFieldValues{"due_date": "2026-10-19", "nope": "nope"}
+	assert.NotNil(t, fix.Save(ctx))
+
+	fix.CustomFields = CustomFieldValues{"due_date": "2026-10-19"}
+	fix.Tags = TagList{"not-a-tag"}
+	assert.NotNil(t, fix.Save(ctx))
+
+	fix.Tags = TagList{tag.Name, tag.Name}
+	assert.Nil(t, fix.Save(ctx))
+	assert.Equal(t, TagList{tag.Name}, fix.Tags)
+
+	other := someThingFixture(org.ID)
+	other.CustomFields = CustomFieldValues{"due_date": "2027-01-01"}
+	assert.Nil(t, other.Save(ctx))
+
+	someThings := SomeThings{}
+	assert.Nil(t, someThings.LoadDefinitions(ctx, org.ID))
+	filters := someThings.AvailableFilters()
+
+	tagged := SomeThings{}
+	assert.Nil(t, tagged.FindAll(ctx, Criteria{Query: &ByOrg{ID: org.ID}, Filters: Filters{filters.ByID("tag-" + tag.ID)}}))
+	assert.Equal(t, 1, len(tagged.Data))
+	assert.Equal(t, fix.ID, tagged.Data[0].ID)
+
+	due := filters.ByID("field-due_date")
+	assert.Nil(t, due.Populate(url.Values{"field-due_date-start": {"2026-10-01"}, "field-due_date-end": {"2026-10-31"}}))
+	dueSoon := SomeThings{}
+	assert.Nil(t, dueSoon.FindAll(ctx, Criteria{Query: &ByOrg{ID: org.ID}, Filters: Filters{due}}))
+	assert.Equal(t, 1, len(dueSoon.Data))
+	assert.Equal(t, fix.ID, dueSoon.Data[0].ID)
+
+	db := ctx.Value("tx").(Querier)
+	matches := 0
+	assert.Nil(t, db.QueryRowContext(ctx, "SELECT COUNT(*) FROM some_things WHERE id = $1 AND ts @@ plainto_tsquery('english', $2)", fix.ID, tag.Name).Scan(&matches))
+	assert.Equal(t, 1, matches)
+
+	closeTx(t, ctx)
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	scumutil "github.com/davidbanham/scum/util"
+)
+
+// Sortable is a column a list can be ordered by. Key is what appears in the query string, Col is
+// the SQL it maps to. Only columns a collection lists in its Sortables can be sorted on.
+type Sortable struct {
+	Key   string
+	Label string
+	Col   string
+}
+
+type Sortables []Sortable
+
+func (this Sortables) ByKey() map[string]Sortable {
+	ret := map[string]Sortable{}
+	for _, s := range this {
+		ret[s.Key] = s
+	}
+	return ret
+}
+
+// SortKey is one term of a list's ordering. In the query string it's the Sortable key, prefixed
+// with - when descending.
+type SortKey struct {
+	Key  string
+	Desc bool
+}
+
+func (this SortKey) Param() string {
+	if this.Desc {
+		return "-" + this.Key
+	}
+	return this.Key
+}
+
+// Sort is an ordering by one or more keys, most significant first.
+type Sort []SortKey
+
+// FromForm reads sort params, ignoring any key that isn't whitelisted.
+func (this *Sort) FromForm(form url.Values, sortables Sortables) {
+	byKey := sortables.ByKey()
+	seen := map[string]bool{}
+	for _, param := range form["sort"] {
+		key := SortKey{Key: strings.TrimPrefix(param, "-"), Desc: strings.HasPrefix(param, "-")}
+		if _, ok := byKey[key.Key]; !ok || seen[key.Key] {
+			continue
+		}
+		seen[key.Key] = true
+		*this = append(*this, key)
+	}
+}
+
+func (this Sort) Params() []string {
+	ret := []string{}
+	for _, key := range this {
+		ret = append(ret, key.Param())
+	}
+	return ret
+}
+
+// Get is the sort term for key, or an empty SortKey if the list isn't sorted by it.
+func (this Sort) Get(key string) SortKey {
+	for _, k := range this {
+		if k.Key == key {
+			return k
+		}
+	}
+	return SortKey{}
+}
+
+// By makes key the only sort, flipping its direction if it was already the primary sort.
+func (this Sort) By(key string) Sort {
+	primary := len(this) > 0 && this[0].Key == key
+	return Sort{{Key: key, Desc: primary && !this[0].Desc}}
+}
+
+// Then adds key as the least significant sort, or flips its direction if it's already present.
+func (this Sort) Then(key string) Sort {
+	ret := Sort{}
+	found := false
+	for _, k := range this {
+		if k.Key == key {
+			k.Desc = !k.Desc
+			found = true
+		}
+		ret = append(ret, k)
+	}
+	if !found {
+		ret = append(ret, SortKey{Key: key})
+	}
+	return ret
+}
+
+type orderTerm struct {
+	Col  string
+	Desc bool
+}
+
+func (this orderTerm) String() string {
+	if this.Desc {
+		return this.Col + " DESC"
+	}
+	return this.Col + " ASC"
+}
+
+func idTerm(table string) orderTerm {
+	return orderTerm{Col: strings.Split(table, " ")[0] + ".id"}
+}
+
+func orderBy(terms []orderTerm) string {
+	clauses := []string{}
+	for _, term := range terms {
+		clauses = append(clauses, term.String())
+	}
+	return " ORDER BY " + strings.Join(clauses, ", ")
+}
+
+func (this Sort) terms(sortables Sortables) []orderTerm {
+	byKey := sortables.ByKey()
+	ret := []orderTerm{}
+	for _, key := range this {
+		sortable, ok := byKey[key.Key]
+		if !ok {
+			continue
+		}
+		ret = append(ret, orderTerm{Col: sortable.Col, Desc: key.Desc})
+	}
+	return ret
+}
+
+// OrderBy is the ORDER BY clause for the sort. The table's id is always the final term so rows with
+// equal sort values come back in a stable order from page to page.
+func (this Sort) OrderBy(table string, sortables Sortables) string {
+	terms := this.terms(sortables)
+	if len(terms) == 0 {
+		return ""
+	}
+	return orderBy(append(terms, idTerm(table)))
+}
+
+// construct builds a list query, ordered by the criteria's sort if it has one and by the
+// collection's usual order otherwise.
+func construct(q Query, cols []string, table string, criteria Criteria, sortables Sortables, fallback Order) string {
+	if criteria.Cursor.Enabled {
+		return criteria.Cursor.construct(q, cols, table, criteria, sortables, fallback)
+	}
+	orderBy := criteria.Sort.OrderBy(table, sortables)
+	if orderBy == "" {
+		return q.Construct(cols, table, criteria.Filters, criteria.Pagination, fallback)
+	}
+	return q.Construct(cols, table, criteria.Filters, Pagination{}, Order{}) + orderBy + criteria.Pagination.PaginationQuery()
+}
+
+// Values is the query string that reproduces the criteria's filters, sort and page size, so links
+// on a list page can change one thing without losing the rest.
+func (this Criteria) Values() url.Values {
+	ret := url.Values{}
+	for _, filter := range this.Filters {
+		id := filter.ID()
+		if id == "" {
+			continue
+		}
+		ret.Add("filter", id)
+		if dated, ok := filter.(interface{ Period() scumutil.Period }); ok {
+			ret.Set(id+"-start", dated.Period().Start.Format("2006-01-02"))
+			ret.Set(id+"-end", dated.Period().End.Format("2006-01-02"))
+		}
+		if valued, ok := filter.(interface{ InputValues() []string }); ok && len(valued.InputValues()) > 0 {
+			ret[id] = valued.InputValues()
+		}
+	}
+	if len(this.Sort) > 0 {
+		ret["sort"] = this.Sort.Params()
+	}
+	if this.Pagination.Limit > 0 {
+		ret.Set("limit", strconv.Itoa(this.Pagination.Limit))
+	}
+	if this.Cursor.Enabled {
+		ret.Set("limit", strconv.Itoa(this.Cursor.Limit))
+		if this.Cursor.Count {
+			ret.Set("count", "true")
+		}
+	}
+	return ret
+}
+
+// With is the criteria's Values with some params replaced.
+func (this Criteria) With(replacements url.Values) url.Values {
+	ret := this.Values()
+	for k, v := range replacements {
+		ret[k] = v
+	}
+	return ret
+}
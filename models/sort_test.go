@@ -0,0 +1,54 @@
+package models
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortFromForm(t *testing.T) {
+	t.Parallel()
+
+	sort := Sort{}
+	sort.FromForm(url.Values{"sort": {"-created", "name; DROP TABLE users", "name", "created"}}, (SomeThings{}).Sortables())
+
+	assert.Equal(t, Sort{{Key: "created", Desc: true}, {Key: "name"}}, sort)
+	assert.Equal(t, " ORDER BY some_things.created_at DESC, some_things.name ASC, some_things.id ASC", sort.OrderBy("some_things", (SomeThings{}).Sortables()))
+	assert.Equal(t, "", Sort{}.OrderBy("some_things", (SomeThings{}).Sortables()))
+}
+
+func TestSortByAndThen(t *testing.T) {
+	t.Parallel()
+
+	sort := Sort{}.By("name")
+	assert.Equal(t, []string{"name"}, sort.Params())
+
+	sort = sort.By("name")
+	assert.Equal(t, []string{"-name"}, sort.Params())
+
+	sort = sort.Then("created")
+	assert.Equal(t, []string{"-name", "created"}, sort.Params())
+
+	sort = sort.Then("created")
+	assert.Equal(t, []string{"-name", "-created"}, sort.Params())
+
+	sort = sort.By("created")
+	assert.Equal(t, []string{"created"}, sort.Params())
+}
+
+func TestCriteriaValues(t *testing.T) {
+	t.Parallel()
+
+	criteria := Criteria{
+		Filters:    Filters{(SomeThings{}).AvailableFilters().ByID("is-deleted")},
+		Sort:       Sort{{Key: "name", Desc: true}},
+		Pagination: Pagination{Limit: 10, Skip: 20},
+	}
+
+	values := criteria.With(url.Values{"skip": {"30"}})
+	assert.Equal(t, []string{"is-deleted"}, values["filter"])
+	assert.Equal(t, []string{"-name"}, values["sort"])
+	assert.Equal(t, "10", values.Get("limit"))
+	assert.Equal(t, "30", values.Get("skip"))
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/billing"
+	"doubleboiler/util"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+type Subscription struct {
+	ID                     string
+	Revision               string
+	OrganisationID         string
+	PlanID                 string
+	Status                 string
+	TrialEndsAt            sql.NullTime
+	RenewsAt               sql.NullTime
+	ProviderCustomerID     string
+	ProviderSubscriptionID sql.NullString
+	LastEventAt            sql.NullTime
+	CreatedAt              time.Time
+	UpdatedAt              time.Time
+}
+
+func (this *Subscription) colmap() *Colmap {
+	return &Colmap{
+		"id":                       &this.ID,
+		"revision":                 &this.Revision,
+		"organisation_id":          &this.OrganisationID,
+		"plan_id":                  &this.PlanID,
+		"status":                   &this.Status,
+		"trial_ends_at":            &this.TrialEndsAt,
+		"renews_at":                &this.RenewsAt,
+		"provider_customer_id":     &this.ProviderCustomerID,
+		"provider_subscription_id": &this.ProviderSubscriptionID,
+		"last_event_at":            &this.LastEventAt,
+		"created_at":               &this.CreatedAt,
+		"updated_at":               &this.UpdatedAt,
+	}
+}
+
+// New subscribes the organisation to the plan, on a trial if the plan has one.
+func (this *Subscription) New(organisationID string, plan Plan) {
+	this.ID = uuid.NewV4().String()
+	this.OrganisationID = organisationID
+	this.PlanID = plan.ID
+	this.Status = billing.StatusActive
+	if plan.TrialDays > 0 {
+		this.Status = billing.StatusTrialing
+		this.TrialEndsAt = sql.NullTime{Valid: true, Time: time.Now().AddDate(0, 0, plan.TrialDays)}
+	}
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+func (this *Subscription) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "subscriptions", this.ID, this.OrganisationID)
+}
+
+func (this *Subscription) Save(ctx context.Context) error {
+	if !util.Contains(billing.ValidStatuses, this.Status) {
+		return ClientSafeError{Message: "Invalid subscription status: " + this.Status}
+	}
+
+	q, props, newRev := StandardSave("subscriptions", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *Subscription) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("subscriptions", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *Subscription) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this Subscription) Label() string {
+	return this.Plan().Name + " subscription"
+}
+
+// Plan is the plan the organisation chose, which isn't necessarily the one it's getting.
+func (this Subscription) Plan() Plan {
+	plan, err := PlanByID(this.PlanID)
+	if err != nil {
+		return FreePlan
+	}
+	return plan
+}
+
+func (this Subscription) TrialEnded(now time.Time) bool {
+	return this.Status == billing.StatusTrialing && (!this.TrialEndsAt.Valid || !now.Before(this.TrialEndsAt.Time))
+}
+
+// EffectivePlan is the plan whose limits apply. An organisation keeps its plan while a payment is
+// overdue, but drops to the free plan once its trial runs out or the subscription is cancelled.
+func (this Subscription) EffectivePlan(now time.Time) Plan {
+	switch this.Status {
+	case billing.StatusActive, billing.StatusPastDue:
+		return this.Plan()
+	case billing.StatusTrialing:
+		if !this.TrialEnded(now) {
+			return this.Plan()
+		}
+	}
+	return FreePlan
+}
+
+// ChangePlan moves the organisation onto the plan, starting a subscription with the provider for
+// anything other than the free plan and cancelling the one it had.
+func (this *Subscription) ChangePlan(ctx context.Context, provider billing.Provider, plan Plan, email string) error {
+	if this.ProviderSubscriptionID.Valid {
+		if err := provider.Cancel(ctx, this.ProviderSubscriptionID.String); err != nil {
+			return err
+		}
+		this.ProviderSubscriptionID = sql.NullString{}
+	}
+
+	this.PlanID = plan.ID
+	this.TrialEndsAt = sql.NullTime{}
+	this.RenewsAt = sql.NullTime{}
+	this.Status = billing.StatusActive
+	this.UpdatedAt = time.Now()
+
+	if plan.ID != FreePlan.ID {
+		if this.ProviderCustomerID == "" {
+			customerID, err := provider.CreateCustomer(ctx, this.OrganisationID, email)
+			if err != nil {
+				return err
+			}
+			this.ProviderCustomerID = customerID
+		}
+
+		sub, err := provider.Subscribe(ctx, this.ProviderCustomerID, plan.ID)
+		if err != nil {
+			return err
+		}
+		this.ProviderSubscriptionID = sql.NullString{Valid: true, String: sub.ID}
+		this.Status = sub.Status
+		this.RenewsAt = sql.NullTime{Valid: true, Time: sub.PeriodEnd}
+	}
+
+	return this.Save(ctx)
+}
+
+// ApplyEvent records a change the provider has told us about. An event that's already been
+// applied, or that happened before the last one that was, is ignored.
+func (this *Subscription) ApplyEvent(ctx context.Context, event billing.Event) error {
+	if !util.Contains(billing.ValidStatuses, event.Status) {
+		return ClientSafeError{Message: "Invalid subscription status: " + event.Status}
+	}
+	if event.ID == "" || event.OccurredAt.IsZero() {
+		return ClientSafeError{Message: "A billing event needs an ID and a time"}
+	}
+
+	db := ctx.Value("tx").(Querier)
+
+	// Events for the same subscription queue up behind each other here, and each one sees what
+	// the one before it did.
+	if _, err := db.ExecContext(ctx, "SELECT id FROM subscriptions WHERE id = $1 FOR UPDATE", this.ID); err != nil {
+		return err
+	}
+	if err := this.FindByID(ctx, this.ID); err != nil {
+		return err
+	}
+
+	result, err := db.ExecContext(ctx, "INSERT INTO billing_events (id, subscription_id, occurred_at) VALUES ($1, $2, $3) ON CONFLICT (id) DO NOTHING", event.ID, this.ID, event.OccurredAt)
+	if err != nil {
+		return err
+	}
+
+	num, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if num == 0 || (this.LastEventAt.Valid && event.OccurredAt.Before(this.LastEventAt.Time)) {
+		return nil
+	}
+
+	this.Status = event.Status
+	if !event.PeriodEnd.IsZero() {
+		this.RenewsAt = sql.NullTime{Valid: true, Time: event.PeriodEnd}
+	}
+	this.LastEventAt = sql.NullTime{Valid: true, Time: event.OccurredAt}
+	this.UpdatedAt = time.Now()
+	return this.Save(ctx)
+}
+
+func (this Organisation) Subscription(ctx context.Context) (Subscription, error) {
+	sub := Subscription{}
+	err := sub.FindByColumn(ctx, "organisation_id", this.ID)
+	return sub, err
+}
+
+// Plan is the plan whose limits currently apply to the organisation.
+func (this Organisation) Plan(ctx context.Context) (Plan, error) {
+	sub, err := this.Subscription(ctx)
+	if err == sql.ErrNoRows {
+		return legacyPlan, nil
+	}
+	if err != nil {
+		return Plan{}, err
+	}
+	return sub.EffectivePlan(time.Now()), nil
+}
@@ -0,0 +1,167 @@
+package models
+
+import (
+	"database/sql"
+	"doubleboiler/billing"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, subscriptionFix())
+}
+
+func subscriptionFixture(organisationID string) (sub Subscription) {
+	sub.New(organisationID, TeamPlan)
+	return
+}
+
+func (Subscription) blank() model {
+	return &Subscription{}
+}
+
+func (sub Subscription) id() string {
+	return sub.ID
+}
+
+func (sub *Subscription) nullDynamicValues() {
+	sub.CreatedAt = time.Time{}
+	sub.UpdatedAt = time.Time{}
+	sub.TrialEndsAt = sql.NullTime{}
+	sub.RenewsAt = sql.NullTime{}
+	sub.Revision = ""
+}
+
+func (Subscription) tablename() string {
+	return "subscriptions"
+}
+
+func subscriptionFix() []model {
+	org := organisationFixture()
+	fix := subscriptionFixture(org.ID)
+	return []model{
+		&org,
+		&fix,
+	}
+}
+
+func TestSubscriptionEffectivePlan(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	sub := subscriptionFixture("")
+	assert.Equal(t, billing.StatusTrialing, sub.Status)
+	assert.Equal(t, TeamPlan, sub.EffectivePlan(now))
+	assert.Equal(t, FreePlan, sub.EffectivePlan(now.AddDate(0, 0, TeamPlan.TrialDays+1)))
+
+	sub.Status = billing.StatusPastDue
+	assert.Equal(t, TeamPlan, sub.EffectivePlan(now.AddDate(1, 0, 0)))
+
+	sub.Status = billing.StatusCancelled
+	assert.Equal(t, FreePlan, sub.EffectivePlan(now))
+
+	free := Subscription{}
+	free.New("", FreePlan)
+	assert.Equal(t, billing.StatusActive, free.Status)
+	assert.False(t, free.TrialEndsAt.Valid)
+}
+
+func TestSubscriptionChangePlan(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	plan, err := org.Plan(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, legacyPlan, plan)
+
+	sub := subscriptionFixture(org.ID)
+	assert.Nil(t, sub.Save(ctx))
+
+	assert.Nil(t, sub.ChangePlan(ctx, billing.Fake{}, BusinessPlan, "billing@example.com"))
+	assert.Equal(t, billing.StatusActive, sub.Status)
+	assert.True(t, sub.ProviderSubscriptionID.Valid)
+	assert.NotEqual(t, "", sub.ProviderCustomerID)
+	assert.True(t, sub.RenewsAt.Valid)
+
+	plan, err = org.Plan(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, BusinessPlan, plan)
+
+	found := Subscription{}
+	assert.Nil(t, found.FindByColumn(ctx, "provider_subscription_id", sub.ProviderSubscriptionID.String))
+	assert.Nil(t, found.ApplyEvent(ctx, billing.Event{ID: randString(), SubscriptionID: sub.ProviderSubscriptionID.String, Status: billing.StatusCancelled, OccurredAt: time.Now()}))
+
+	plan, err = org.Plan(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, FreePlan, plan)
+
+	assert.IsType(t, ClientSafeError{}, found.ApplyEvent(ctx, billing.Event{ID: randString(), Status: "bogus", OccurredAt: time.Now()}))
+
+	assert.Nil(t, found.FindByID(ctx, sub.ID))
+	assert.Nil(t, found.ChangePlan(ctx, billing.Fake{}, FreePlan, "billing@example.com"))
+	assert.False(t, found.ProviderSubscriptionID.Valid)
+	assert.False(t, found.RenewsAt.Valid)
+}
+
+func TestSubscriptionApplyEventOnceAndInOrder(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	sub := subscriptionFixture(org.ID)
+	assert.Nil(t, sub.Save(ctx))
+	assert.Nil(t, sub.ChangePlan(ctx, billing.Fake{}, BusinessPlan, "billing@example.com"))
+
+	// Events can arrive well after they happened
+	happened := time.Now().Add(-time.Hour)
+	event := func(status string, at time.Time) billing.Event {
+		return billing.Event{ID: randString(), SubscriptionID: sub.ProviderSubscriptionID.String, Status: status, OccurredAt: at}
+	}
+
+	assert.Nil(t, sub.ApplyEvent(ctx, event(billing.StatusActive, happened)))
+	pastDue := event(billing.StatusPastDue, happened.Add(time.Second))
+	assert.Nil(t, sub.ApplyEvent(ctx, pastDue))
+	assert.Equal(t, billing.StatusPastDue, sub.Status)
+
+	// A replay of an event that's already been applied
+	found := Subscription{}
+	assert.Nil(t, found.FindByID(ctx, sub.ID))
+	assert.Nil(t, found.ChangePlan(ctx, billing.Fake{}, BusinessPlan, "billing@example.com"))
+	assert.Nil(t, found.ApplyEvent(ctx, pastDue))
+	assert.Equal(t, billing.StatusActive, found.Status)
+
+	// An event that happened before the last one applied
+	assert.Nil(t, found.ApplyEvent(ctx, event(billing.StatusCancelled, happened)))
+	assert.Equal(t, billing.StatusActive, found.Status)
+
+	assert.Nil(t, found.ApplyEvent(ctx, event(billing.StatusCancelled, happened.Add(2*time.Second))))
+	assert.Equal(t, billing.StatusCancelled, found.Status)
+
+	assert.IsType(t, ClientSafeError{}, found.ApplyEvent(ctx, billing.Event{SubscriptionID: found.ProviderSubscriptionID.String, Status: billing.StatusCancelled}))
+}
+
+func TestSubscriptionChangePlanWithoutBilling(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+	sub := subscriptionFixture(org.ID)
+	assert.Nil(t, sub.Save(ctx))
+
+	assert.Equal(t, billing.ErrBillingDisabled, sub.ChangePlan(ctx, billing.None{}, BusinessPlan, "billing@example.com"))
+
+	assert.Nil(t, sub.FindByID(ctx, sub.ID))
+	assert.Nil(t, sub.ChangePlan(ctx, billing.None{}, FreePlan, "billing@example.com"))
+	assert.Equal(t, FreePlan.ID, sub.PlanID)
+}
@@ -0,0 +1,177 @@
+package models
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// Tag is one entry in an organisation's vocabulary of labels that can be applied to SomeThings.
+type Tag struct {
+	ID             string
+	Revision       string
+	OrganisationID string
+	Name           string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+func (this *Tag) colmap() *Colmap {
+	return &Colmap{
+		"id":              &this.ID,
+		"revision":        &this.Revision,
+		"organisation_id": &this.OrganisationID,
+		"name":            &this.Name,
+		"created_at":      &this.CreatedAt,
+		"updated_at":      &this.UpdatedAt,
+	}
+}
+
+func (this *Tag) New(organisationID, name string) {
+	this.ID = uuid.NewV4().String()
+	this.OrganisationID = organisationID
+	this.Name = strings.TrimSpace(name)
+	this.CreatedAt = time.Now()
+	this.UpdatedAt = time.Now()
+}
+
+func (this *Tag) auditQuery(ctx context.Context, action string) string {
+	return auditQuery(ctx, action, "tags", this.ID, this.OrganisationID)
+}
+
+func (this *Tag) Save(ctx context.Context) error {
+	if this.Name == "" {
+		return ClientSafeError{Message: "A tag needs a name"}
+	}
+
+	q, props, newRev := StandardSave("tags", this.colmap(), this.auditQuery(ctx, "U"))
+
+	if err := ExecSave(ctx, q, props); err != nil {
+		if isUniqueViolation(err, "tags_organisation_id_name_key") {
+			return ClientSafeError{Message: "A tag with that name already exists"}
+		}
+		return err
+	}
+
+	this.Revision = newRev
+
+	return nil
+}
+
+func (this *Tag) FindByColumn(ctx context.Context, col, val string) error {
+	q, props := StandardFindByColumn("tags", this.colmap(), col)
+	return StandardExecFindByColumn(ctx, q, val, props)
+}
+
+func (this *Tag) FindByID(ctx context.Context, id string) error {
+	return this.FindByColumn(ctx, "id", id)
+}
+
+func (this Tag) Label() string {
+	return this.Name
+}
+
+// Delete removes the tag from the vocabulary and strips it from every SomeThing in the organisation.
+func (this Tag) Delete(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	if _, err := db.ExecContext(ctx, this.auditQuery(ctx, "D")+"DELETE FROM tags WHERE id = $1 AND revision = $2", this.ID, this.Revision); err != nil {
+		return err
+	}
+
+	_, err := db.ExecContext(ctx, "UPDATE some_things SET tags = tags - $2::text WHERE organisation_id = $1 AND tags @> jsonb_build_array($2::text)", this.OrganisationID, this.Name)
+	return err
+}
+
+type Tags struct {
+	Data     []Tag
+	Criteria Criteria
+}
+
+func (this Tags) colmap() *Colmap {
+	r := Tag{}
+	return r.colmap()
+}
+
+func (Tags) AvailableFilters() Filters {
+	return standardFilters("tags")
+}
+
+func (this Tags) Names() []string {
+	ret := []string{}
+	for _, tag := range this.Data {
+		ret = append(ret, tag.Name)
+	}
+	return ret
+}
+
+func (this *Tags) FindAll(ctx context.Context, criteria Criteria) error {
+	this.Criteria = criteria
+
+	db := ctx.Value("tx").(Querier)
+
+	cols, _ := this.colmap().Split()
+
+	var rows *sql.Rows
+	var err error
+
+	switch v := criteria.Query.(type) {
+	default:
+		return ErrInvalidQuery{Query: v, Model: "tags"}
+	case custom:
+		switch v := criteria.customQuery.(type) {
+		default:
+			return ErrInvalidQuery{Query: v, Model: "tags"}
+		}
+	case Query:
+		rows, err = db.QueryContext(ctx, v.Construct(cols, "tags", criteria.Filters, criteria.Pagination, Order{By: "name"}), v.Args()...)
+	}
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		tag := Tag{}
+		props := tag.colmap().ByKeys(cols)
+		if err := rows.Scan(props...); err != nil {
+			return err
+		}
+		(*this).Data = append((*this).Data, tag)
+	}
+	return err
+}
+
+// TagList is the set of tag names applied to a row, stored as a JSONB array.
+type TagList []string
+
+func (this TagList) Value() (driver.Value, error) {
+	if len(this) == 0 {
+		return "[]", nil
+	}
+	return json.Marshal(this)
+}
+
+func (this *TagList) Scan(value interface{}) error {
+	b, ok := value.([]byte)
+	if !ok {
+		return errors.New("type assertion to []byte failed")
+	}
+
+	return json.Unmarshal(b, &this)
+}
+
+func (this TagList) Has(name string) bool {
+	for _, tag := range this {
+		if tag == name {
+			return true
+		}
+	}
+	return false
+}
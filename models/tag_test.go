@@ -0,0 +1,115 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	modelsUnderTest = append(modelsUnderTest, tagFix())
+	modelCollectionsUnderTest = append(modelCollectionsUnderTest, tagsFix())
+}
+
+func tagFixture(organisationID string) (t Tag) {
+	t.New(organisationID, randString())
+	return
+}
+
+func (Tag) blank() model {
+	return &Tag{}
+}
+
+func (t Tag) id() string {
+	return t.ID
+}
+
+func (t *Tag) nullDynamicValues() {
+	t.CreatedAt = time.Time{}
+	t.UpdatedAt = time.Time{}
+	t.Revision = ""
+}
+
+func (Tag) tablename() string {
+	return "tags"
+}
+
+func (Tags) tablename() string {
+	return "tags"
+}
+
+func (Tags) blank() models {
+	return &Tags{}
+}
+
+func tagsFix() modelCollectionFixture {
+	org := organisationFixture()
+	return modelCollectionFixture{
+		deps: []model{&org},
+		collection: &Tags{
+			Data: []Tag{
+				tagFixture(org.ID),
+				tagFixture(org.ID),
+			},
+		},
+	}
+}
+
+func tagFix() []model {
+	org := organisationFixture()
+	fix := tagFixture(org.ID)
+	return []model{
+		&org,
+		&fix,
+	}
+}
+
+func (this Tags) data() []model {
+	ret := []model{}
+	for _, m := range this.Data {
+		ret = append(ret, &m)
+	}
+	return ret
+}
+
+func TestTagDeleteStripsSomeThings(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	keep := tagFixture(org.ID)
+	assert.Nil(t, keep.Save(ctx))
+	drop := tagFixture(org.ID)
+	assert.Nil(t, drop.Save(ctx))
+
+	someThing := someThingFixture(org.ID)
+	someThing.Tags = TagList{keep.Name, drop.Name}
+	assert.Nil(t, someThing.Save(ctx))
+
+	assert.Nil(t, drop.Delete(ctx))
+
+	found := SomeThing{}
+	assert.Nil(t, found.FindByID(ctx, someThing.ID))
+	assert.Equal(t, TagList{keep.Name}, found.Tags)
+
+	closeTx(t, ctx)
+}
+
+func TestTagNameIsUnique(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	first := tagFixture(org.ID)
+	assert.Nil(t, first.Save(ctx))
+
+	second := Tag{}
+	second.New(org.ID, first.Name)
+	assert.Equal(t, ClientSafeError{Message: "A tag with that name already exists"}, second.Save(ctx))
+}
@@ -3,6 +3,7 @@ package models
 import (
 	"context"
 	"database/sql"
+	"doubleboiler/changelog"
 	"doubleboiler/config"
 	"doubleboiler/copy"
 	"doubleboiler/flashes"
@@ -38,6 +39,11 @@ type User struct {
 	totpSecret            sql.NullString
 	TOTPActive            bool
 	recoveryCodes         NullStringList
+	Disabled              bool
+	DeletionRequestedAt   sql.NullTime
+	AnonymisedAt          sql.NullTime
+	ChangelogSeenAt       sql.NullTime
+	ChangelogNotifiedAt   sql.NullTime
 }
 
 func (this *User) colmap() *Colmap {
@@ -56,6 +62,11 @@ func (this *User) colmap() *Colmap {
 		"totp_active":             &this.TOTPActive,
 		"totp_secret":             &this.totpSecret,
 		"recovery_codes":          &this.recoveryCodes,
+		"disabled":                &this.Disabled,
+		"deletion_requested_at":   &this.DeletionRequestedAt,
+		"anonymised_at":           &this.AnonymisedAt,
+		"changelog_seen_at":       &this.ChangelogSeenAt,
+		"changelog_notified_at":   &this.ChangelogNotifiedAt,
 	}
 }
 
@@ -165,8 +176,8 @@ func (user *User) Validate2FA(ctx context.Context, code, recoveryCode string) (b
 					return false, err
 				}
 
-				if err := config.QUEUE.Buffer(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
-					logger.Log(ctx, logger.Error, "buffering recovery code used email", err)
+				if err := Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+					logger.Log(ctx, logger.Error, "enqueueing recovery code used email", err)
 					return false, err
 				}
 			}
@@ -283,6 +294,10 @@ func (user *User) Disable2FA(ctx context.Context) error {
 		return err
 	}
 
+	return user.send2FADisabledEmail(ctx)
+}
+
+func (user User) send2FADisabledEmail(ctx context.Context) error {
 	payload := notifications.Email{
 		To:      user.Email,
 		From:    fmt.Sprintf("%s <%s>", config.NAME, config.SYSTEM_EMAIL_ONLY),
@@ -297,16 +312,303 @@ func (user *User) Disable2FA(ctx context.Context) error {
 		return err
 	}
 
-	if err := config.QUEUE.Buffer(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
-		logger.Log(ctx, logger.Error, "buffering 2fa disabled email", err)
+	if err := Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+		logger.Log(ctx, logger.Error, "enqueueing 2fa disabled email", err)
+		return err
+	}
+
+	return nil
+}
+
+var ErrReasonRequired = ClientSafeError{Message: "A reason is required"}
+
+// adminUpdate runs an update to the user's row on a superadmin's behalf, recording why in the
+// audit log. The update gets the user's ID as $1 and the reason as $2.
+func (user *User) adminUpdate(ctx context.Context, reason, update string, props ...any) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrReasonRequired
+	}
+
+	db := ctx.Value("tx").(Querier)
+	q := reasonedAuditQuery(ctx, "U", "users", user.ID, user.ID, 2) + " " + update
+	_, err := db.ExecContext(ctx, q, append([]any{user.ID, reason}, props...)...)
+	return err
+}
+
+// Disable stops the user logging in or using any session they already have.
+func (user *User) Disable(ctx context.Context, reason string) error {
+	if err := user.adminUpdate(ctx, reason, "UPDATE users SET disabled = true WHERE id = $1"); err != nil {
+		return err
+	}
+	user.Disabled = true
+	return nil
+}
+
+func (user *User) Enable(ctx context.Context, reason string) error {
+	if err := user.adminUpdate(ctx, reason, "UPDATE users SET disabled = false WHERE id = $1"); err != nil {
+		return err
+	}
+	user.Disabled = false
+	return nil
+}
+
+// ForcePasswordReset replaces the user's password with one nobody knows and emails them a link to
+// choose a new one.
+func (user *User) ForcePasswordReset(ctx context.Context, reason string) error {
+	hash, err := util.HashPassword(uuid.NewV4().String())
+	if err != nil {
+		return err
+	}
+	if err := user.adminUpdate(ctx, reason, "UPDATE users SET password = $3 WHERE id = $1", hash); err != nil {
+		return err
+	}
+	user.Password = hash
+	return user.SendPasswordResetEmail(ctx)
+}
+
+// Reset2FA clears a locked out user's 2FA entirely, so they can log in with their password and
+// enrol again.
+func (user *User) Reset2FA(ctx context.Context, reason string) error {
+	if err := user.adminUpdate(ctx, reason, "UPDATE users SET totp_active = false, totp_secret = NULL, recovery_codes = NULL, totp_failure_count = 0 WHERE id = $1"); err != nil {
+		return err
+	}
+	user.TOTPActive = false
+	user.totpSecret = sql.NullString{}
+	user.recoveryCodes = NullStringList{}
+	return user.send2FADisabledEmail(ctx)
+}
+
+// RecordImpersonation notes in the audit log that a superadmin has started acting as this user.
+func (user *User) RecordImpersonation(ctx context.Context, reason string) error {
+	if strings.TrimSpace(reason) == "" {
+		return ErrReasonRequired
+	}
+	return user.adminUpdate(ctx, "Impersonated: "+reason, "SELECT id FROM users WHERE id = $1")
+}
+
+var ErrMergeIntoSelf = ClientSafeError{Message: "A user can't be merged into themselves"}
+
+// MergeInto moves everything belonging to this user over to target, then deletes this user.
+// Memberships of organisations target already belongs to are dropped rather than doubled up.
+// Audit entries keep pointing at this user's ID, so the history of who did what survives.
+func (user *User) MergeInto(ctx context.Context, target User, reason string) error {
+	if user.ID == target.ID {
+		return ErrMergeIntoSelf
+	}
+	if strings.TrimSpace(reason) == "" {
+		return ErrReasonRequired
+	}
+
+	db := ctx.Value("tx").(Querier)
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM organisations_users WHERE user_id = $1
+	AND organisation_id IN (SELECT organisation_id FROM organisations_users WHERE user_id = $2)`, user.ID, target.ID); err != nil {
+		return err
+	}
+
+	for _, table := range []string{"organisations_users", "communications", "broadcasts", "attachments", "comments", "saved_views", "data_exports"} {
+		if _, err := db.ExecContext(ctx, "UPDATE "+table+" SET user_id = $2 WHERE user_id = $1", user.ID, target.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, col := range []string{"owner_id", "deletion_requested_by"} {
+		if _, err := db.ExecContext(ctx, "UPDATE organisations SET "+col+" = $2 WHERE "+col+" = $1", user.ID, target.ID); err != nil {
+			return err
+		}
+	}
+
+	q := reasonedAuditQuery(ctx, "D", "users", user.ID, user.ID, 2) + " DELETE FROM users WHERE id = $1"
+	if _, err := db.ExecContext(ctx, q, user.ID, fmt.Sprintf("Merged into %s: %s", target.Email, reason)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnreadChanges are the changelog entries made since the user last looked at the changelog. Changes
+// from before they signed up don't count.
+func (user User) UnreadChanges() []changelog.Change {
+	since := user.CreatedAt
+	if user.ChangelogSeenAt.Valid {
+		since = user.ChangelogSeenAt.Time
+	}
+	return changelog.Since(since)
+}
+
+func (user *User) MarkChangelogSeen(ctx context.Context, at time.Time) error {
+	db := ctx.Value("tx").(Querier)
+	if _, err := db.ExecContext(ctx, "UPDATE users SET changelog_seen_at = $2 WHERE id = $1", user.ID, at); err != nil {
+		return err
+	}
+	user.ChangelogSeenAt.Valid = true
+	user.ChangelogSeenAt.Time = at
+	return nil
+}
+
+// NotifyOfChanges flashes the user once about changes they haven't seen, unless they've already been
+// told about every one of them. It reports whether a flash was added.
+func (user *User) NotifyOfChanges(ctx context.Context) (bool, error) {
+	unread := user.UnreadChanges()
+	if len(unread) == 0 {
+		return false, nil
+	}
+
+	latest := unread[0]
+	if user.ChangelogNotifiedAt.Valid && !latest.Date.After(user.ChangelogNotifiedAt.Time) {
+		return false, nil
+	}
+
+	text := "What's new: " + latest.Title
+	if len(unread) > 1 {
+		text = "There are new changes, including " + latest.Title
+	}
+
+	if _, err := user.PersistFlash(ctx, flashes.Flash{
+		Persistent:  true,
+		Type:        flashes.Info,
+		Text:        text,
+		OnceOnlyKey: "changelog-" + latest.Slug,
+		Actions: []flashes.FlashAction{
+			{Url: "/changelog", Text: "See what's changed"},
+		},
+	}); err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	db := ctx.Value("tx").(Querier)
+	if _, err := db.ExecContext(ctx, "UPDATE users SET changelog_notified_at = $2 WHERE id = $1", user.ID, now); err != nil {
+		return false, err
+	}
+	user.ChangelogNotifiedAt.Valid = true
+	user.ChangelogNotifiedAt.Time = now
+
+	return true, nil
+}
+
+// AccountDeletionCoolingOff is how long someone has to change their mind after asking for their
+// account to be deleted.
+const AccountDeletionCoolingOff = 14 * 24 * time.Hour
+
+// DeletionDue is when the account will be anonymised, if deletion has been requested.
+func (user User) DeletionDue() time.Time {
+	return user.DeletionRequestedAt.Time.Add(AccountDeletionCoolingOff)
+}
+
+// RequestDeletion schedules the account to be anonymised once the cooling off period is over, and
+// lets the user know how to cancel.
+func (user *User) RequestDeletion(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+	if err := db.QueryRowContext(ctx, user.auditQuery(ctx, "U")+" UPDATE users SET deletion_requested_at = now() WHERE id = $1 RETURNING deletion_requested_at", user.ID).Scan(&user.DeletionRequestedAt); err != nil {
+		return err
+	}
+
+	emailHTML, emailText := copy.AccountDeletionEmail(user.DeletionDue().Format("2 January 2006"), fmt.Sprintf("%s/users/%s", config.URI, user.ID))
+
+	mail := notifications.Email{
+		To:      user.Email,
+		From:    config.SYSTEM_EMAIL,
+		ReplyTo: config.SUPPORT_EMAIL,
+		Text:    emailText,
+		HTML:    emailHTML,
+		Subject: fmt.Sprintf("Your %s account is scheduled for deletion", config.NAME),
+	}
+
+	task := kewpie.Task{}
+	if err := task.Marshal(mail); err != nil {
 		return err
 	}
 
+	return Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task)
+}
+
+func (user *User) CancelDeletion(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+	if _, err := db.ExecContext(ctx, user.auditQuery(ctx, "U")+" UPDATE users SET deletion_requested_at = NULL WHERE id = $1", user.ID); err != nil {
+		return err
+	}
+	user.DeletionRequestedAt = sql.NullTime{}
+	return nil
+}
+
+// Anonymise strips the personal details from the account and everything hanging off it. The rows
+// themselves are kept so audit entries and the records of other people still point somewhere, and
+// the account is disabled so it can't be used again. Old audit snapshots of those rows are scrubbed
+// too, or the details would live on there.
+func (user *User) Anonymise(ctx context.Context) error {
+	db := ctx.Value("tx").(Querier)
+
+	password, err := util.HashPassword(uuid.NewV4().String())
+	if err != nil {
+		return err
+	}
+
+	statements := []string{
+		`UPDATE audit_log SET old_row_data = old_row_data - '{email,password,totp_secret,recovery_codes,flashes}'::text[] WHERE table_name = 'users' AND entity_id = $1`,
+		`UPDATE audit_log SET old_row_data = old_row_data - '{name,family_name}'::text[] WHERE table_name = 'organisations_users' AND entity_id IN (SELECT id FROM organisations_users WHERE user_id = $1)`,
+		`UPDATE audit_log SET old_row_data = old_row_data - 'subject' WHERE table_name = 'communications' AND entity_id IN (SELECT id FROM communications WHERE user_id = $1)`,
+		`UPDATE organisations_users SET name = '', family_name = '' WHERE user_id = $1`,
+		`UPDATE communications SET subject = '' WHERE user_id = $1`,
+	}
+	for _, statement := range statements {
+		if _, err := db.ExecContext(ctx, statement, user.ID); err != nil {
+			return err
+		}
+	}
+
+	if _, err := db.ExecContext(ctx, `UPDATE users SET
+		email = 'deleted-' || id || '@deleted.invalid',
+		password = $2,
+		verified = false,
+		disabled = true,
+		flashes = '[]',
+		totp_active = false,
+		totp_secret = NULL,
+		recovery_codes = NULL,
+		anonymised_at = now()
+	WHERE id = $1`, user.ID, password); err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(ctx, `INSERT INTO audit_log (entity_id, organisation_id, table_name, action, user_id, old_row_data, reason) VALUES ($1, $1, 'users', 'U', $2, (SELECT to_jsonb(users) FROM users WHERE id = $1), $3)`, user.ID, currentUser(ctx), "Anonymised at the user's request")
+	return err
+}
+
+// AnonymiseUsersDueForDeletion anonymises every account whose deletion was requested before the
+// cutoff.
+func AnonymiseUsersDueForDeletion(ctx context.Context, cutoff time.Time) error {
+	db := ctx.Value("tx").(Querier)
+
+	rows, err := db.QueryContext(ctx, "SELECT id FROM users WHERE deletion_requested_at < $1 AND anonymised_at IS NULL", cutoff)
+	if err != nil {
+		return err
+	}
+	ids := []string{}
+	for rows.Next() {
+		id := ""
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		user := User{ID: id}
+		if err := user.Anonymise(ctx); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
 func (this *User) Save(ctx context.Context) error {
-	colmap := this.colmap().Delete("has_flashes", "flashes")
+	colmap := this.colmap().Delete("has_flashes", "flashes", "changelog_seen_at", "changelog_notified_at")
 	q, props, newRev := StandardSave("users", colmap, this.auditQuery(ctx, "U"))
 
 	if err := ExecSave(ctx, q, props); err != nil {
@@ -367,7 +669,7 @@ func (user *User) SendVerificationEmail(ctx context.Context, org Organisation) e
 	task.Tags.Set("organisation_id", org.ID)
 	task.Tags.Set("communication_subject", fmt.Sprintf("Account confirmation request"))
 
-	if err := config.QUEUE.Publish(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+	if err := Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
 		return err
 	}
 
@@ -379,6 +681,30 @@ func (user *User) SendVerificationEmail(ctx context.Context, org Organisation) e
 	return err
 }
 
+func (user User) SendPasswordResetEmail(ctx context.Context) error {
+	token := util.CalcToken(config.SECRET, 1, user.Email)
+	escaped := url.QueryEscape(token.String())
+	resetUrl := fmt.Sprintf("%s/reset-password?expiry=%s&uid=%s&token=%s", config.URI, token.ExpiryString(), user.ID, escaped)
+
+	emailHTML, emailText := copy.PasswordResetEmail(resetUrl)
+
+	mail := notifications.Email{
+		To:      user.Email,
+		From:    config.SYSTEM_EMAIL,
+		ReplyTo: config.SUPPORT_EMAIL,
+		Text:    emailText,
+		HTML:    emailHTML,
+		Subject: fmt.Sprintf("Password reset for your %s account", config.NAME),
+	}
+
+	task := kewpie.Task{}
+	if err := task.Marshal(mail); err != nil {
+		return err
+	}
+
+	return Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task)
+}
+
 func (user User) SendEmailChangedNotification(ctx context.Context, newEmail string) error {
 	emailHTML, emailText := copy.EmailChangedEmail(newEmail, user.Email)
 
@@ -401,7 +727,7 @@ func (user User) SendEmailChangedNotification(ctx context.Context, newEmail stri
 			return err
 		}
 
-		if err := config.QUEUE.Publish(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+		if err := Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
 			return err
 		}
 	}
@@ -458,6 +784,13 @@ func (users Users) AvailableFilters() Filters {
 	return append(standardFilters("users"), &viaEmail, &verified)
 }
 
+func (Users) Sortables() Sortables {
+	return Sortables{
+		{Key: "email", Label: "Email", Col: "users.email"},
+		{Key: "created", Label: "Created", Col: "users.created_at"},
+	}
+}
+
 func (Users) Searchable() Searchable {
 	return Searchable{
 		EntityType: "User",
@@ -496,7 +829,7 @@ func (this *Users) FindAll(ctx context.Context, criteria Criteria) error {
 			return ErrInvalidQuery{Query: v, Model: "users"}
 		}
 	case Query:
-		rows, err = db.QueryContext(ctx, v.Construct(cols, "users", criteria.Filters, criteria.Pagination, Order{By: "email"}), v.Args()...)
+		rows, err = db.QueryContext(ctx, construct(v, cols, "users", criteria, this.Sortables(), Order{By: "email"}), v.Args()...)
 	}
 	if err != nil {
 		return err
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"database/sql"
+	"doubleboiler/changelog"
 	"doubleboiler/flashes"
 	"fmt"
 	"testing"
@@ -294,3 +296,198 @@ func TestUserValidate2FA(t *testing.T) {
 	assert.Equal(t, 8, len(fix.recoveryCodes.Strings))
 	assert.True(t, fix.recoveryCodes.Valid)
 }
+
+func TestUserDisableRecordsReason(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	fix := userFixture()
+	assert.Nil(t, fix.Save(ctx))
+
+	assert.Equal(t, ErrReasonRequired, fix.Disable(ctx, " "))
+	assert.Nil(t, fix.Disable(ctx, "Reported as compromised"))
+
+	found := User{}
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.True(t, found.Disabled)
+
+	audits := Audits{}
+	criteria := Criteria{}
+	AddCustomQuery(ByEntityID{EntityID: fix.ID}, &criteria)
+	assert.Nil(t, audits.FindAll(ctx, criteria))
+	assert.Equal(t, "Reported as compromised", audits.Data[0].Reason)
+
+	assert.Nil(t, found.Enable(ctx, "Owner confirmed by phone"))
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.False(t, found.Disabled)
+}
+
+func TestUserReset2FA(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	fix := userFixture()
+	assert.Nil(t, fix.Save(ctx))
+
+	key, err := fix.Generate2FA(ctx, "", "")
+	assert.Nil(t, err)
+	code, err := totp.GenerateCode(key.Secret(), time.Now())
+	assert.Nil(t, err)
+	okay, err := fix.Validate2FA(ctx, code, "")
+	assert.Nil(t, err)
+	assert.True(t, okay)
+
+	assert.Nil(t, fix.Reset2FA(ctx, "Lost phone, identity checked"))
+
+	found := User{}
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.False(t, found.TOTPActive)
+	assert.False(t, found.totpSecret.Valid)
+}
+
+func TestUserMergeInto(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	shared := organisationFixture()
+	assert.Nil(t, shared.Save(ctx))
+	only := organisationFixture()
+	assert.Nil(t, only.Save(ctx))
+
+	target := userFixture()
+	assert.Nil(t, target.Save(ctx))
+	duplicate := userFixture()
+	assert.Nil(t, duplicate.Save(ctx))
+
+	for _, ou := range []OrganisationUser{
+		organisationUserFixture(target.ID, shared.ID),
+		organisationUserFixture(duplicate.ID, shared.ID),
+		organisationUserFixture(duplicate.ID, only.ID),
+	} {
+		assert.Nil(t, ou.Save(ctx))
+	}
+
+	comm := communicationFixture(duplicate, only)
+	assert.Nil(t, comm.Save(ctx))
+
+	assert.Equal(t, ErrMergeIntoSelf, duplicate.MergeInto(ctx, duplicate, "Oops"))
+	assert.Nil(t, duplicate.MergeInto(ctx, target, "Signed up twice"))
+
+	gone := User{}
+	assert.Equal(t, sql.ErrNoRows, gone.FindByID(ctx, duplicate.ID))
+
+	orgs := Organisations{}
+	criteria := Criteria{}
+	AddCustomQuery(OrganisationsContainingUser{ID: target.ID}, &criteria)
+	assert.Nil(t, orgs.FindAll(ctx, criteria))
+	assert.Len(t, orgs.Data, 2)
+
+	movedComm := Communication{}
+	assert.Nil(t, movedComm.FindByID(ctx, comm.ID))
+	assert.Equal(t, target.ID, movedComm.UserID.String)
+}
+
+func TestUserRequestDeletion(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	fix := userFixture()
+	assert.Nil(t, fix.Save(ctx))
+
+	assert.Nil(t, fix.RequestDeletion(ctx))
+	assert.True(t, fix.DeletionRequestedAt.Valid)
+	assert.WithinDuration(t, time.Now().Add(AccountDeletionCoolingOff), fix.DeletionDue(), time.Minute)
+
+	// Nothing is due yet
+	assert.Nil(t, AnonymiseUsersDueForDeletion(ctx, time.Now().Add(-AccountDeletionCoolingOff)))
+	found := User{}
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.Equal(t, fix.Email, found.Email)
+
+	assert.Nil(t, fix.CancelDeletion(ctx))
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.False(t, found.DeletionRequestedAt.Valid)
+}
+
+func TestAnonymiseUsersDueForDeletion(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture()
+	assert.Nil(t, org.Save(ctx))
+
+	fix := userFixture()
+	assert.Nil(t, fix.Save(ctx))
+	fix.Verified = true
+	assert.Nil(t, fix.Save(ctx))
+
+	ou := organisationUserFixture(fix.ID, org.ID)
+	ou.Name = "Personal"
+	assert.Nil(t, ou.Save(ctx))
+
+	comm := communicationFixture(fix, org)
+	assert.Nil(t, comm.Save(ctx))
+
+	db := ctx.Value("tx").(Querier)
+	_, err := db.ExecContext(ctx, "UPDATE users SET deletion_requested_at = $2 WHERE id = $1", fix.ID, time.Now().Add(-AccountDeletionCoolingOff-time.Hour))
+	assert.Nil(t, err)
+
+	assert.Nil(t, AnonymiseUsersDueForDeletion(ctx, time.Now().Add(-AccountDeletionCoolingOff)))
+
+	found := User{}
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.NotEqual(t, fix.Email, found.Email)
+	assert.True(t, found.Disabled)
+	assert.True(t, found.AnonymisedAt.Valid)
+
+	foundOU := OrganisationUser{}
+	assert.Nil(t, foundOU.FindByID(ctx, ou.ID))
+	assert.Equal(t, "", foundOU.Name)
+
+	leaked := 0
+	assert.Nil(t, db.QueryRowContext(ctx, "SELECT count(*) FROM audit_log WHERE entity_id = $1 AND old_row_data ? 'email'", fix.ID).Scan(&leaked))
+	assert.Equal(t, 0, leaked)
+}
+
+func TestUserChangelog(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	oldest := changelog.Changes[len(changelog.Changes)-1]
+
+	fix := userFixture()
+	fix.CreatedAt = oldest.Date.Add(-time.Second)
+	assert.Nil(t, fix.Save(ctx))
+	assert.Equal(t, len(changelog.Changes), len(fix.UnreadChanges()))
+
+	notified, err := fix.NotifyOfChanges(ctx)
+	assert.Nil(t, err)
+	assert.True(t, notified)
+	assert.True(t, fix.HasFlashes)
+
+	// Only once
+	notified, err = fix.NotifyOfChanges(ctx)
+	assert.Nil(t, err)
+	assert.False(t, notified)
+
+	found := User{}
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.True(t, found.ChangelogNotifiedAt.Valid)
+
+	assert.Nil(t, fix.MarkChangelogSeen(ctx, time.Now()))
+	assert.Empty(t, fix.UnreadChanges())
+
+	assert.Nil(t, found.FindByID(ctx, fix.ID))
+	assert.Empty(t, found.UnreadChanges())
+}
+
+func TestUserUnreadChangesIgnoresChangesBeforeSignup(t *testing.T) {
+	fix := userFixture()
+	assert.Empty(t, fix.UnreadChanges())
+}
@@ -0,0 +1,282 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/models"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/admin").
+		Methods("GET").
+		HandlerFunc(adminHandler)
+
+	r.Path("/admin/audits").
+		Methods("GET").
+		HandlerFunc(adminAuditsHandler)
+
+	r.Path("/admin/users/{id}").
+		Methods("GET").
+		HandlerFunc(adminUserHandler)
+
+	r.Path("/admin/users/{id}/disable").
+		Methods("POST").
+		HandlerFunc(adminUserAction(func(ctx context.Context, user *models.User, r *http.Request) error {
+			return user.Disable(ctx, r.FormValue("reason"))
+		}))
+
+	r.Path("/admin/users/{id}/enable").
+		Methods("POST").
+		HandlerFunc(adminUserAction(func(ctx context.Context, user *models.User, r *http.Request) error {
+			return user.Enable(ctx, r.FormValue("reason"))
+		}))
+
+	r.Path("/admin/users/{id}/force-password-reset").
+		Methods("POST").
+		HandlerFunc(adminUserAction(func(ctx context.Context, user *models.User, r *http.Request) error {
+			return user.ForcePasswordReset(ctx, r.FormValue("reason"))
+		}))
+
+	r.Path("/admin/users/{id}/reset-2fa").
+		Methods("POST").
+		HandlerFunc(adminUserAction(func(ctx context.Context, user *models.User, r *http.Request) error {
+			return user.Reset2FA(ctx, r.FormValue("reason"))
+		}))
+
+	r.Path("/admin/users/{id}/merge").
+		Methods("POST").
+		HandlerFunc(adminUserMergeHandler)
+}
+
+type adminPageData struct {
+	basePageData
+	Query          string
+	Users          models.Users
+	Organisations  models.Organisations
+	RecentActivity models.Audits
+}
+
+func adminHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	q := strings.TrimSpace(r.FormValue("q"))
+
+	users := models.Users{}
+	orgs := models.Organisations{}
+
+	if q != "" {
+		if err := users.FindAll(r.Context(), models.Criteria{
+			Query:      &models.All{},
+			Filters:    models.Filters{models.Matching{Cols: []string{"users.email"}, Phrase: q}},
+			Pagination: models.Pagination{Limit: 20},
+		}); err != nil {
+			errRes(w, r, http.StatusInternalServerError, "error searching users", err)
+			return
+		}
+
+		if err := orgs.FindAll(r.Context(), models.Criteria{
+			Query:      &models.All{},
+			Filters:    models.Filters{models.Matching{Cols: []string{"organisations.name", "organisations.id::text"}, Phrase: q}},
+			Pagination: models.Pagination{Limit: 20},
+		}); err != nil {
+			errRes(w, r, http.StatusInternalServerError, "error searching organisations", err)
+			return
+		}
+	}
+
+	audits := models.Audits{}
+	if err := audits.FindAll(r.Context(), models.Criteria{
+		Query:      &models.All{},
+		Sort:       models.Sort{{Key: "stamp", Desc: true}},
+		Pagination: models.Pagination{Limit: 20},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error fetching audits", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "admin.html", adminPageData{
+		Query:          q,
+		Users:          users,
+		Organisations:  orgs,
+		RecentActivity: audits,
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Back Office",
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+func adminAuditsHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	audits := models.Audits{}
+
+	criteria := models.Criteria{
+		Query: &models.All{},
+	}
+	criteria.Cursor.DefaultPageSize = 50
+	if err := criteria.Cursor.Paginate(r.Form); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting page", err)
+		return
+	}
+
+	if err := criteria.Filters.FromForm(r.Form, audits.AvailableFilters()); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+		return
+	}
+
+	criteria.Sort.FromForm(r.Form, audits.Sortables())
+	if len(criteria.Sort) == 0 {
+		criteria.Sort = models.Sort{{Key: "stamp", Desc: true}}
+	}
+
+	if err := audits.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error fetching audits", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "audits.html", auditsPageData{
+		Audits: audits,
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - All Audits",
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+type adminUserPageData struct {
+	basePageData
+	User          models.User
+	Organisations models.Organisations
+	Audits        models.Audits
+}
+
+func adminUserHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	user := models.User{}
+	if err := user.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		if err == sql.ErrNoRows {
+			errRes(w, r, http.StatusNotFound, "User not found", err)
+			return
+		}
+		errRes(w, r, http.StatusInternalServerError, "error fetching user", err)
+		return
+	}
+
+	orgs := models.Organisations{}
+	criteria := models.Criteria{}
+	models.AddCustomQuery(models.OrganisationsContainingUser{ID: user.ID}, &criteria)
+	if err := orgs.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error fetching organisations", err)
+		return
+	}
+
+	audits := models.Audits{}
+	auditCriteria := models.Criteria{}
+	models.AddCustomQuery(models.ByEntityID{EntityID: user.ID}, &auditCriteria)
+	auditCriteria.Pagination.Limit = 20
+	if err := audits.FindAll(r.Context(), auditCriteria); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error fetching audits", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "admin-user.html", adminUserPageData{
+		User:          user,
+		Organisations: orgs,
+		Audits:        audits,
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Back Office - " + user.Email,
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+// adminUserAction wraps something a superadmin does to someone else's account. Every one of them
+// needs a reason, which ends up in the audit log alongside the change.
+func adminUserAction(action func(context.Context, *models.User, *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !isAppAdmin(r.Context()) {
+			errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+			return
+		}
+
+		if ok := checkFormInput([]string{"reason"}, r.Form, w, r); !ok {
+			return
+		}
+
+		user := models.User{}
+		if err := user.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+			errRes(w, r, http.StatusNotFound, "User not found", err)
+			return
+		}
+
+		if err := action(r.Context(), &user, r); err != nil {
+			errRes(w, r, http.StatusInternalServerError, "error updating user", err)
+			return
+		}
+
+		http.Redirect(w, r, "/admin/users/"+user.ID, http.StatusFound)
+	}
+}
+
+func adminUserMergeHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	if ok := checkFormInput([]string{"into", "reason"}, r.Form, w, r); !ok {
+		return
+	}
+
+	duplicate := models.User{}
+	if err := duplicate.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "User not found", err)
+		return
+	}
+
+	target := models.User{}
+	if err := target.FindByColumn(r.Context(), "email", strings.ToLower(r.FormValue("into"))); err != nil {
+		if err == sql.ErrNoRows {
+			errRes(w, r, http.StatusNotFound, "No user found with that email address", nil)
+			return
+		}
+		errRes(w, r, http.StatusInternalServerError, "error fetching user", err)
+		return
+	}
+
+	if duplicate.ID == target.ID {
+		errRes(w, r, http.StatusBadRequest, models.ErrMergeIntoSelf.Message, nil)
+		return
+	}
+
+	if err := duplicate.MergeInto(r.Context(), target, r.FormValue("reason")); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error merging users", err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/users/"+target.ID, http.StatusFound)
+}
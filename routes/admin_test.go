@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func superadminContext(ctx context.Context, t *testing.T) context.Context {
+	admin := models.User{}
+	admin.New(bandEmail(), bandname())
+	admin.SuperAdmin = true
+	assert.Nil(t, admin.Save(ctx))
+	return context.WithValue(ctx, "user", admin)
+}
+
+func TestAdminHandlerRequiresSuperadmin(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	user, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", user)
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/admin"},
+		Form:   url.Values{},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	adminHandler(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestAdminHandlerSearch(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	ctx = superadminContext(ctx, t)
+
+	user := models.User{}
+	user.New(bandEmail(), bandname())
+	assert.Nil(t, user.Save(ctx))
+	org := organisationFixture(ctx, t)
+
+	for q, expected := range map[string]string{user.Email: user.ID, org.ID: org.ID} {
+		req := &http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "/admin"},
+			Form:   url.Values{"q": {q}},
+		}
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		adminHandler(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), expected)
+	}
+}
+
+func TestAdminUserDisable(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	target, password := userFixture(ctx, t)
+	ctx = superadminContext(ctx, t)
+
+	disable := adminUserAction(func(ctx context.Context, user *models.User, r *http.Request) error {
+		return user.Disable(ctx, r.FormValue("reason"))
+	})
+
+	for form, code := range map[string]int{"": http.StatusBadRequest, "Chargeback fraud": http.StatusFound} {
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/admin/users/" + target.ID + "/disable"},
+			Form:   url.Values{"reason": {form}},
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": target.ID})
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		disable(rr, req)
+
+		assert.Equal(t, code, rr.Code)
+	}
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/login"},
+		Form: url.Values{
+			"email":    {target.Email},
+			"password": {password},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	loginHandler(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestAdminUserMergeHandler(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	duplicate, _ := userFixture(ctx, t)
+	target := models.User{}
+	target.New(bandEmail(), bandname())
+	assert.Nil(t, target.Save(ctx))
+	ctx = superadminContext(ctx, t)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/admin/users/" + duplicate.ID + "/merge"},
+		Form: url.Values{
+			"into":   {target.Email},
+			"reason": {"Signed up twice"},
+		},
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": duplicate.ID})
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	adminUserMergeHandler(rr, req)
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "/admin/users/"+target.ID, rr.Header().Get("Location"))
+}
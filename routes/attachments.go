@@ -0,0 +1,153 @@
+package routes
+
+import (
+	"doubleboiler/filestore"
+	"doubleboiler/models"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/some-things/{id}/attachments").
+		Methods("POST").
+		HandlerFunc(attachmentUploadHandler)
+
+	r.Path("/attachments/{id}").
+		Methods("GET").
+		HandlerFunc(attachmentDownloadHandler)
+
+	r.Path("/attachments/{id}/delete").
+		Methods("POST").
+		HandlerFunc(attachmentDeletionHandler)
+
+	r.Path("/files/{key:.+}").
+		Methods("GET").
+		HandlerFunc(localFileHandler)
+}
+
+func attachmentUploadHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	someThing := models.SomeThing{}
+	if err := someThing.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	org := orgFromContext(r.Context(), someThing.OrganisationID)
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot add attachments for that organisation", nil)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			errRes(w, r, http.StatusRequestEntityTooLarge, "Error uploading attachment", models.ErrAttachmentTooBig)
+			return
+		}
+		errRes(w, r, http.StatusBadRequest, "Please choose a file to upload", err)
+		return
+	}
+	defer file.Close()
+
+	attachment := models.Attachment{}
+	attachment.New(org.ID, someThing.ID, userFromContext(r.Context()).ID, header.Filename, header.Header.Get("Content-Type"), header.Size)
+
+	if err := attachment.Upload(r.Context(), filestore.Default, file); err != nil {
+		if err == models.ErrAttachmentTooBig {
+			errRes(w, r, http.StatusRequestEntityTooLarge, "Error uploading attachment", err)
+			return
+		}
+		errRes(w, r, errCode(err), "Error uploading attachment", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/some-things/"+someThing.ID, r.Form), 302)
+}
+
+func attachmentDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	attachment := models.Attachment{}
+	if err := attachment.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	org := orgFromContext(r.Context(), attachment.OrganisationID)
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot view attachments for that organisation", nil)
+		return
+	}
+
+	url, err := attachment.SignedURL(filestore.Default)
+	if err != nil {
+		errRes(w, r, 500, "Error creating download link", err)
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusFound)
+}
+
+func attachmentDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	attachment := models.Attachment{}
+	if err := attachment.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	org := orgFromContext(r.Context(), attachment.OrganisationID)
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot remove attachments for that organisation", nil)
+		return
+	}
+
+	if err := attachment.Delete(r.Context()); err != nil {
+		errRes(w, r, 500, "Error removing attachment", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/some-things/"+attachment.SomeThingID, r.Form), 302)
+}
+
+// localFileHandler stands in for the storage bucket when files are kept on local disk.
+// The signature on the link is the authorisation, just as it is for a bucket signed URL.
+func localFileHandler(w http.ResponseWriter, r *http.Request) {
+	store, ok := filestore.Default.(filestore.Local)
+	if !ok {
+		errRes(w, r, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	if err := store.Verify(vars["key"], r.FormValue("filename"), r.FormValue("expires"), r.FormValue("signature")); err != nil {
+		errRes(w, r, http.StatusForbidden, "That download link is invalid or has expired", err)
+		return
+	}
+
+	file, err := store.Open(r.Context(), vars["key"])
+	if errors.Is(err, filestore.ErrNotFound) {
+		errRes(w, r, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if err != nil {
+		errRes(w, r, 500, "Error reading file", err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Disposition", filestore.ContentDisposition(r.FormValue("filename")))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	io.Copy(w, file)
+}
@@ -0,0 +1,113 @@
+package routes
+
+import (
+	"bytes"
+	"doubleboiler/models"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentUploadAndDownload(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	fixture := someThingFixture(ctx, t, org)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "notes.txt")
+	assert.Nil(t, err)
+	part.Write([]byte(bandname()))
+	assert.Nil(t, writer.Close())
+
+	req, err := http.NewRequest("POST", "/some-things/"+fixture.ID+"/attachments", body)
+	assert.Nil(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+
+	r := mux.NewRouter()
+	r.HandleFunc("/some-things/{id}/attachments", attachmentUploadHandler).Methods("POST")
+	r.HandleFunc("/attachments/{id}", attachmentDownloadHandler).Methods("GET")
+	r.HandleFunc("/files/{key:.+}", localFileHandler).Methods("GET")
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	attachments := models.Attachments{}
+	assert.Nil(t, attachments.FindAll(ctx, attachmentsForSomeThing(fixture)))
+	assert.Equal(t, 1, len(attachments.Data))
+	assert.Equal(t, "notes.txt", attachments.Data[0].Name)
+
+	req, err = http.NewRequest("GET", "/attachments/"+attachments.Data[0].ID, nil)
+	assert.Nil(t, err)
+	req = req.WithContext(ctx)
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Contains(t, rr.Header().Get("Location"), "/files/"+attachments.Data[0].StorageKey)
+
+	closeTx(t, ctx)
+}
+
+func TestAttachmentUploadOverQuota(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+	org := organisationFixture(ctx, t)
+	org.AttachmentQuota = 1
+	assert.Nil(t, org.Save(ctx))
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	fixture := someThingFixture(ctx, t, org)
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "notes.txt")
+	assert.Nil(t, err)
+	part.Write([]byte(bandname()))
+	assert.Nil(t, writer.Close())
+
+	req, err := http.NewRequest("POST", "/some-things/"+fixture.ID+"/attachments", body)
+	assert.Nil(t, err)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	req = mux.SetURLVars(req.WithContext(ctx), map[string]string{"id": fixture.ID})
+
+	rr := httptest.NewRecorder()
+	attachmentUploadHandler(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestLocalFileHandlerRejectsUnsignedLinks(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+
+	req, err := http.NewRequest("GET", "/files/attachments/nope?filename=nope.txt", nil)
+	assert.Nil(t, err)
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+
+	r := mux.NewRouter()
+	r.HandleFunc("/files/{key:.+}", localFileHandler).Methods("GET")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	closeTx(t, ctx)
+}
@@ -19,7 +19,8 @@ func init() {
 
 type auditsPageData struct {
 	basePageData
-	Audits models.Audits
+	Audits     models.Audits
+	SavedViews savedViewsData
 }
 
 func auditsHandler(w http.ResponseWriter, r *http.Request) {
@@ -39,15 +40,33 @@ func auditsHandler(w http.ResponseWriter, r *http.Request) {
 	audits := models.Audits{}
 
 	var criteria models.Criteria
+	var savedViews savedViewsData
+
+	criteria.Pagination.DefaultPageSize = 50
 
 	if vars["id"] != "" {
 		models.AddCustomQuery(models.ByEntityID{EntityID: vars["id"]}, &criteria)
+		criteria.Pagination.Paginate(r.Form)
 	} else {
+		var written bool
+		if savedViews, written = applySavedView(w, r, "audits", targetOrg); written {
+			return
+		}
+
 		criteria.Query = &models.ByOrg{ID: targetOrg.ID}
-	}
+		criteria.Cursor.DefaultPageSize = 50
+		if err := criteria.Cursor.Paginate(r.Form); err != nil {
+			errRes(w, r, http.StatusBadRequest, "error interpreting page", err)
+			return
+		}
 
-	criteria.Pagination.DefaultPageSize = 50
-	criteria.Pagination.Paginate(r.Form)
+		if err := criteria.Filters.FromForm(r.Form, audits.AvailableFilters()); err != nil {
+			errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+			return
+		}
+
+		criteria.Sort.FromForm(r.Form, audits.Sortables())
+	}
 
 	if err := audits.FindAll(r.Context(), criteria); err != nil {
 		errRes(w, r, http.StatusInternalServerError, "error fetching audits", err)
@@ -62,7 +81,8 @@ func auditsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := Tmpl.ExecuteTemplate(w, "audits.html", auditsPageData{
-		Audits: audits,
+		Audits:     audits,
+		SavedViews: savedViews,
 		basePageData: basePageData{
 			PageTitle: "DoubleBoiler - Audits",
 			Context:   r.Context(),
@@ -26,3 +26,15 @@ func isAppAdmin(ctx context.Context) bool {
 	}
 	return ctx.Value("user").(models.User).SuperAdmin
 }
+
+// canManageOwnership is whether the user can hand the organisation over or delete it. That's the
+// owner's call, or any admin's if nobody owns it.
+func canManageOwnership(ctx context.Context, target models.Organisation) bool {
+	if isAppAdmin(ctx) {
+		return true
+	}
+	if !target.OwnerID.Valid {
+		return can(ctx, target, "admin")
+	}
+	return target.IsOwner(userFromContext(ctx).ID)
+}
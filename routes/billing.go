@@ -0,0 +1,168 @@
+package routes
+
+import (
+	"database/sql"
+	"doubleboiler/billing"
+	"doubleboiler/flashes"
+	"doubleboiler/models"
+	"doubleboiler/util"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/organisations/{id}/billing").
+		Methods("GET").
+		HandlerFunc(billingHandler)
+
+	r.Path("/organisations/{id}/billing/subscribe").
+		Methods("POST").
+		HandlerFunc(billingSubscribeHandler)
+
+	r.Path("/webhooks/billing").
+		Methods("POST").
+		HandlerFunc(billingWebhookHandler)
+}
+
+type billingPageData struct {
+	basePageData
+	Organisation  models.Organisation
+	Subscription  models.Subscription
+	EffectivePlan models.Plan
+	Quotas        models.Quotas
+	Usage         models.Usage
+	Plans         []models.Plan
+	Now           time.Time
+}
+
+func billingHandler(w http.ResponseWriter, r *http.Request) {
+	org := orgFromContext(r.Context(), mux.Vars(r)["id"])
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "Only admins may view billing", nil)
+		return
+	}
+
+	subscription, err := org.Subscription(r.Context())
+	if err != nil && err != sql.ErrNoRows {
+		errRes(w, r, http.StatusInternalServerError, "Error looking up subscription", err)
+		return
+	}
+
+	plan, err := org.Plan(r.Context())
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error looking up plan", err)
+		return
+	}
+
+	usage, err := org.Usage(r.Context())
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error calculating usage", err)
+		return
+	}
+
+	quotas, err := org.Quotas(r.Context())
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error calculating quotas", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "billing.html", billingPageData{
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Billing " + util.FirstFiveChars(org.ID),
+			Context:   r.Context(),
+		},
+		Organisation:  org,
+		Subscription:  subscription,
+		EffectivePlan: plan,
+		Quotas:        quotas,
+		Usage:         usage,
+		Plans:         models.ValidPlans,
+		Now:           time.Now(),
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+func billingSubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	if ok := checkFormInput([]string{"plan"}, r.Form, w, r); !ok {
+		return
+	}
+
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "Only admins may change the plan", nil)
+		return
+	}
+
+	plan, err := models.PlanByID(r.FormValue("plan"))
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Invalid plan", err)
+		return
+	}
+
+	subscription, err := org.Subscription(r.Context())
+	if err == sql.ErrNoRows {
+		subscription.New(org.ID, plan)
+	} else if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error looking up subscription", err)
+		return
+	}
+
+	user := userFromContext(r.Context())
+
+	if err := subscription.ChangePlan(r.Context(), billing.Default, plan, user.Email); err != nil {
+		if err == billing.ErrBillingDisabled {
+			errRes(w, r, http.StatusBadRequest, billing.ErrBillingDisabled.Error(), nil)
+			return
+		}
+		errRes(w, r, http.StatusInternalServerError, "Error changing plan", err)
+		return
+	}
+
+	if ctx, err := user.PersistFlash(r.Context(), flashes.Flash{
+		Type: flashes.Success,
+		Text: org.Name + " is now on the " + plan.Name + " plan.",
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error adding flash message", err)
+		return
+	} else {
+		r = r.WithContext(ctx)
+	}
+
+	http.Redirect(w, r, "/organisations/"+org.ID+"/billing", http.StatusFound)
+}
+
+func billingWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	event, err := billing.Default.ParseWebhook(r)
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Invalid billing event", err)
+		return
+	}
+
+	subscription := models.Subscription{}
+	if err := subscription.FindByColumn(r.Context(), "provider_subscription_id", event.SubscriptionID); err != nil {
+		if err == sql.ErrNoRows {
+			// A subscription we've already moved on from, which there's nothing to do about.
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		errRes(w, r, http.StatusInternalServerError, "Error looking up subscription", err)
+		return
+	}
+
+	if err := subscription.ApplyEvent(r.Context(), event); err != nil {
+		errRes(w, r, errCode(err), "Error applying billing event", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,122 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"doubleboiler/billing"
+	"doubleboiler/models"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBillingHandler(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture(ctx, t)
+	user, _ := userFixture(ctx, t)
+
+	serve := func(ctx context.Context) int {
+		req := &http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "/organisations/" + org.ID + "/billing"},
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": org.ID})
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		billingHandler(rr, req)
+		return rr.Code
+	}
+
+	ctx = context.WithValue(ctx, "user", user)
+
+	assert.Equal(t, http.StatusForbidden, serve(ctx))
+	assert.Equal(t, http.StatusOK, serve(contextifyOrgAdmin(ctx, org)))
+}
+
+func TestBillingSubscribeAndWebhook(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture(ctx, t)
+	user, _ := userFixture(ctx, t)
+	ctx = contextifyOrgAdmin(context.WithValue(ctx, "user", user), org)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/organisations/" + org.ID + "/billing/subscribe"},
+		Form:   url.Values{"plan": {models.TeamPlan.ID}},
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": org.ID})
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	billingSubscribeHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	sub, err := org.Subscription(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, models.TeamPlan.ID, sub.PlanID)
+	assert.Equal(t, billing.StatusActive, sub.Status)
+
+	renews := time.Now().AddDate(0, 2, 0).Truncate(time.Second)
+	body, err := json.Marshal(billing.Event{
+		ID:             "evt_" + sub.ID,
+		SubscriptionID: sub.ProviderSubscriptionID.String,
+		Status:         billing.StatusPastDue,
+		PeriodEnd:      renews,
+		OccurredAt:     time.Now(),
+	})
+	assert.Nil(t, err)
+
+	serveWebhook := func(body []byte, signature string) int {
+		req := httptest.NewRequest("POST", "/webhooks/billing", bytes.NewReader(body))
+		req.Header.Set(billing.SignatureHeader, signature)
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		billingWebhookHandler(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, serveWebhook(body, "forged"))
+	assert.Equal(t, http.StatusOK, serveWebhook(body, billing.Sign(body)))
+
+	assert.Nil(t, sub.FindByID(ctx, sub.ID))
+	assert.Equal(t, billing.StatusPastDue, sub.Status)
+	assert.True(t, sub.RenewsAt.Time.Equal(renews))
+
+	// Replays are acknowledged without being applied again
+	assert.Equal(t, http.StatusOK, serveWebhook(body, billing.Sign(body)))
+
+	bogus, err := json.Marshal(billing.Event{
+		ID:             "evt_bogus_" + sub.ID,
+		SubscriptionID: sub.ProviderSubscriptionID.String,
+		Status:         "bogus",
+		OccurredAt:     time.Now(),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusBadRequest, serveWebhook(bogus, billing.Sign(bogus)))
+
+	req = &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/organisations/" + org.ID + "/billing/subscribe"},
+		Form:   url.Values{"plan": {"platinum"}},
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": org.ID})
+	req = req.WithContext(ctx)
+
+	rr = httptest.NewRecorder()
+	billingSubscribeHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
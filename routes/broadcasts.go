@@ -0,0 +1,322 @@
+package routes
+
+import (
+	"doubleboiler/models"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/broadcasts").
+		Methods("GET").
+		HandlerFunc(broadcastsHandler)
+
+	r.Path("/broadcasts/create").
+		Methods("GET").
+		HandlerFunc(broadcastCreationFormHandler)
+
+	r.Path("/broadcasts/preview").
+		Methods("POST").
+		HandlerFunc(broadcastPreviewHandler)
+
+	r.Path("/broadcasts").
+		Methods("POST").
+		HandlerFunc(broadcastSendHandler)
+
+	r.Path("/broadcasts/{id}").
+		Methods("GET").
+		HandlerFunc(broadcastHandler)
+}
+
+var broadcastFormFields = map[string]bool{
+	"csrf":                 true,
+	"organisationID":       true,
+	"subject":              true,
+	"body":                 true,
+	"roles":                true,
+	"organisation-user-id": true,
+}
+
+func broadcastAudienceFromForm(form url.Values) (models.BroadcastAudience, url.Values, error) {
+	audience := models.BroadcastAudience{
+		Roles:               form["roles"],
+		OrganisationUserIDs: form["organisation-user-id"],
+	}
+
+	if err := audience.Filters.FromForm(form, (models.OrganisationUsers{}).AvailableFilters()); err != nil {
+		return audience, nil, err
+	}
+
+	filterParams := url.Values{}
+	for k, v := range form {
+		if !broadcastFormFields[k] {
+			filterParams[k] = v
+		}
+	}
+
+	return audience, filterParams, nil
+}
+
+type broadcastsPageData struct {
+	basePageData
+	Broadcasts models.Broadcasts
+}
+
+func broadcastsHandler(w http.ResponseWriter, r *http.Request) {
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if targetOrg.ID == "" {
+		redirToDefaultOrg(w, r)
+		return
+	}
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot view broadcasts for that organisation", nil)
+		return
+	}
+
+	broadcasts := models.Broadcasts{}
+
+	criteria := models.Criteria{
+		Query: &models.ByOrg{ID: targetOrg.ID},
+	}
+	criteria.Pagination.DefaultPageSize = 50
+	criteria.Pagination.Paginate(r.Form)
+
+	if err := criteria.Filters.FromForm(r.Form, broadcasts.AvailableFilters()); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+		return
+	}
+
+	if err := broadcasts.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, 500, "error fetching broadcasts", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "broadcasts.html", broadcastsPageData{
+		Broadcasts: broadcasts,
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Broadcasts",
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+type broadcastCreationPageData struct {
+	basePageData
+	Preview           bool
+	Subject           string
+	Body              string
+	Audience          models.BroadcastAudience
+	FilterParams      url.Values
+	OrganisationUsers models.OrganisationUsers
+	Recipients        models.OrganisationUsers
+	ValidRoles        models.Roles
+}
+
+func broadcastCreationFormHandler(w http.ResponseWriter, r *http.Request) {
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if targetOrg.ID == "" {
+		redirToDefaultOrg(w, r)
+		return
+	}
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot send broadcasts for that organisation", nil)
+		return
+	}
+
+	audience, filterParams, err := broadcastAudienceFromForm(r.Form)
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+		return
+	}
+
+	orgUsers := models.OrganisationUsers{}
+	if err := orgUsers.FindAll(r.Context(), models.Criteria{
+		Query:   &models.ByOrg{ID: targetOrg.ID},
+		Filters: audience.Filters,
+	}); err != nil {
+		errRes(w, r, 500, "error fetching org users", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "create-broadcast.html", broadcastCreationPageData{
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - New Broadcast",
+			Context:   r.Context(),
+		},
+		Subject:           r.FormValue("subject"),
+		Body:              r.FormValue("body"),
+		Audience:          audience,
+		FilterParams:      filterParams,
+		OrganisationUsers: orgUsers,
+		ValidRoles:        models.ValidRoles,
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+func broadcastPreviewHandler(w http.ResponseWriter, r *http.Request) {
+	required := []string{
+		"subject",
+		"body",
+	}
+	okay := checkFormInput(required, r.Form, w, r)
+	if !okay {
+		return
+	}
+
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot send broadcasts for that organisation", nil)
+		return
+	}
+
+	audience, filterParams, err := broadcastAudienceFromForm(r.Form)
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+		return
+	}
+
+	recipients, err := audience.Recipients(r.Context(), targetOrg.ID)
+	if err != nil {
+		errRes(w, r, 500, "error resolving recipients", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "create-broadcast.html", broadcastCreationPageData{
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Preview Broadcast",
+			Context:   r.Context(),
+		},
+		Preview:      true,
+		Subject:      r.FormValue("subject"),
+		Body:         r.FormValue("body"),
+		Audience:     audience,
+		FilterParams: filterParams,
+		Recipients:   recipients,
+		ValidRoles:   models.ValidRoles,
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+func broadcastSendHandler(w http.ResponseWriter, r *http.Request) {
+	required := []string{
+		"subject",
+		"body",
+	}
+	okay := checkFormInput(required, r.Form, w, r)
+	if !okay {
+		return
+	}
+
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot send broadcasts for that organisation", nil)
+		return
+	}
+
+	audience, _, err := broadcastAudienceFromForm(r.Form)
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+		return
+	}
+
+	recipients, err := audience.Recipients(r.Context(), targetOrg.ID)
+	if err != nil {
+		errRes(w, r, 500, "error resolving recipients", err)
+		return
+	}
+
+	sender := userFromContext(r.Context())
+
+	broadcast := models.Broadcast{}
+	broadcast.New(targetOrg.ID, sender.ID, r.FormValue("subject"), r.FormValue("body"))
+
+	if err := broadcast.Send(r.Context(), targetOrg, sender, recipients); err != nil {
+		errRes(w, r, errCode(err), "Error sending broadcast", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/broadcasts/"+broadcast.ID, r.Form), 302)
+}
+
+type broadcastPageData struct {
+	basePageData
+	Broadcast      models.Broadcast
+	Delivered      int
+	Communications models.Communications
+	Timeline       models.Timeline
+}
+
+func broadcastHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	broadcast := models.Broadcast{}
+	if err := broadcast.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "error fetching broadcast", err)
+		return
+	}
+
+	org := orgFromContext(r.Context(), broadcast.OrganisationID)
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot view broadcasts for that organisation", nil)
+		return
+	}
+
+	delivered, err := broadcast.Delivered(r.Context())
+	if err != nil {
+		errRes(w, r, 500, "error counting deliveries", err)
+		return
+	}
+
+	communications := models.Communications{}
+
+	criteria := models.Criteria{
+		Query: &models.ByOrg{ID: org.ID},
+		Filters: models.Filters{&models.Custom{
+			Col:    "broadcast_id",
+			Values: []string{broadcast.ID},
+		}},
+	}
+	criteria.Pagination.DefaultPageSize = 50
+	criteria.Pagination.Paginate(r.Form)
+
+	if err := communications.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, 500, "error fetching communications", err)
+		return
+	}
+
+	timeline, err := activityTimeline(r.Context(), org, broadcast.ID)
+	if err != nil {
+		errRes(w, r, 500, "error fetching activity", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "broadcast.html", broadcastPageData{
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Broadcast " + broadcast.Subject,
+			Context:   r.Context(),
+		},
+		Broadcast:      broadcast,
+		Delivered:      delivered,
+		Communications: communications,
+		Timeline:       timeline,
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBroadcastsHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	user, _ := userFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	fixture := broadcastFixture(ctx, t, org, user)
+
+	req, err := http.NewRequest("GET", "/broadcasts", nil)
+	assert.Nil(t, err)
+
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+
+	r := mux.NewRouter()
+
+	r.HandleFunc("/broadcasts", broadcastsHandler).Methods("GET")
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), fixture.Subject, "Subject not found")
+
+	closeTx(t, ctx)
+}
+
+func TestBroadcastHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	user, _ := userFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	fixture := broadcastFixture(ctx, t, org, user)
+
+	req, err := http.NewRequest("GET", "/broadcasts/"+fixture.ID, nil)
+	assert.Nil(t, err)
+
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+
+	r := mux.NewRouter()
+
+	r.HandleFunc("/broadcasts/{id}", broadcastHandler).Methods("GET")
+
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), fixture.Body, "Body not found")
+
+	closeTx(t, ctx)
+}
+
+func TestBroadcastPreviewHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	user, _ := userFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	ou := models.OrganisationUser{}
+	ou.New(user.ID, org.ID, models.Roles{})
+	assert.Nil(t, ou.Save(ctx))
+
+	form := url.Values{
+		"subject":              {bandname()},
+		"body":                 {bandname()},
+		"organisation-user-id": {ou.ID},
+	}
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/broadcasts/preview"},
+		Form:   form,
+	}
+
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	broadcastPreviewHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), user.Email, "Recipient not found")
+
+	closeTx(t, ctx)
+}
+
+func TestBroadcastAudienceFromFormLeavesOutFormFields(t *testing.T) {
+	_, filterParams, err := broadcastAudienceFromForm(url.Values{
+		"organisationID": {"abc"},
+		"subject":        {"Hello"},
+		"body":           {"World"},
+	})
+	assert.Nil(t, err)
+	assert.Empty(t, filterParams)
+}
+
+func TestBroadcastSendHandlerWithoutRecipients(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+	org := organisationFixture(ctx, t)
+	user, _ := userFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+	ctx = context.WithValue(ctx, "user", user)
+
+	// Nobody's in the organisation yet, which is the sender's mistake rather than ours
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/broadcasts"},
+		Form: url.Values{
+			"subject": {bandname()},
+			"body":    {bandname()},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	broadcastSendHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func broadcastFixture(ctx context.Context, t *testing.T, org models.Organisation, user models.User) (broadcast models.Broadcast) {
+	broadcast.New(org.ID, user.ID, bandname(), bandname())
+	assert.Nil(t, broadcast.Save(ctx))
+	return broadcast
+}
@@ -1,33 +1,119 @@
 package routes
 
 import (
+	"context"
 	"doubleboiler/changelog"
+	"doubleboiler/config"
+	"doubleboiler/util"
+	"encoding/xml"
 	"net/http"
+	"time"
 )
 
 func init() {
 	r.Path("/changelog").
 		Methods("GET").
 		HandlerFunc(serveChangelog)
+
+	r.Path("/changelog.atom").
+		Methods("GET").
+		HandlerFunc(serveChangelogFeed)
 }
 
 type changelogPageData struct {
 	basePageData
 	Changes []changelog.Change
+	Unread  map[string]bool
 }
 
 func serveChangelog(w http.ResponseWriter, r *http.Request) {
 	changes := changelog.Changes
 
+	user := userFromContext(r.Context())
+	unread := map[string]bool{}
+	for _, change := range user.UnreadChanges() {
+		unread[change.Slug] = true
+	}
+
+	if impersonatorFromContext(r.Context()).ID == "" {
+		if err := user.MarkChangelogSeen(r.Context(), time.Now()); err != nil {
+			errRes(w, r, http.StatusInternalServerError, "Error marking the changelog as seen", err)
+			return
+		}
+		r = r.WithContext(context.WithValue(r.Context(), "user", user))
+	}
+
 	err := Tmpl.ExecuteTemplate(w, "changelog.html", changelogPageData{
 		basePageData: basePageData{
 			PageTitle: "DoubleBoiler - Changelog",
 			Context:   r.Context(),
 		},
 		Changes: changes,
+		Unread:  unread,
 	})
 	if err != nil {
 		errRes(w, r, 500, "Problem with template", err)
 		return
 	}
 }
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// serveChangelogFeed is public, so people can follow along in a feed reader without logging in.
+func serveChangelogFeed(w http.ResponseWriter, r *http.Request) {
+	feed := atomFeed{
+		ID:    config.URI + "/changelog",
+		Title: config.NAME + " changelog",
+		Link: []atomLink{
+			{Href: config.URI + "/changelog"},
+			{Href: config.URI + "/changelog.atom", Rel: "self"},
+		},
+		Entries: []atomEntry{},
+	}
+
+	for _, change := range changelog.Changes {
+		feed.Entries = append(feed.Entries, atomEntry{
+			ID:      config.URI + "/changelog#" + change.Slug,
+			Title:   change.Title,
+			Updated: change.Date.Format(time.RFC3339),
+			Link:    atomLink{Href: config.URI + "/changelog#" + change.Slug},
+			Content: atomContent{Type: "html", Body: string(util.Markdown(change.Body))},
+		})
+	}
+
+	if len(changelog.Changes) > 0 {
+		feed.Updated = changelog.Changes[0].Date.Format(time.RFC3339)
+	}
+
+	w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+	w.Write([]byte(xml.Header))
+	if err := xml.NewEncoder(w).Encode(feed); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error encoding the changelog feed", err)
+		return
+	}
+}
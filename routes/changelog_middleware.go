@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/util"
+	"net/http"
+	"strings"
+)
+
+// changelogMiddleware tells the user about changes they haven't seen yet, once, the next time they
+// load a page.
+func changelogMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || !isLoggedIn(r.Context()) || util.Contains(assetPaths, util.RootPath(r.URL)) || strings.HasPrefix(util.RootPath(r.URL), "changelog") {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		// Someone looking at the account on the user's behalf shouldn't use up their flash.
+		if impersonatorFromContext(r.Context()).ID != "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		user := userFromContext(r.Context())
+		if notified, err := user.NotifyOfChanges(r.Context()); err != nil {
+			errRes(w, r, http.StatusInternalServerError, "Error adding flash message", err)
+			return
+		} else if notified {
+			r = r.WithContext(context.WithValue(r.Context(), "user", user))
+		}
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/changelog"
+	"doubleboiler/models"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServeChangelogMarksSeen(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	user, _ := userFixture(ctx, t)
+	user.CreatedAt = changelog.Changes[0].Date.Add(-1)
+	assert.NotEmpty(t, user.UnreadChanges())
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/changelog"},
+	}
+	req = req.WithContext(context.WithValue(ctx, "user", user))
+
+	rr := httptest.NewRecorder()
+	serveChangelog(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), changelog.Changes[0].Title)
+
+	found := models.User{}
+	assert.Nil(t, found.FindByID(ctx, user.ID))
+	assert.True(t, found.ChangelogSeenAt.Valid)
+	assert.Empty(t, found.UnreadChanges())
+}
+
+func TestServeChangelogFeed(t *testing.T) {
+	t.Parallel()
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/changelog.atom"},
+	}
+
+	rr := httptest.NewRecorder()
+	serveChangelogFeed(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Header().Get("Content-Type"), "application/atom+xml")
+
+	feed := atomFeed{}
+	assert.Nil(t, xml.Unmarshal(rr.Body.Bytes(), &feed))
+	assert.Equal(t, len(changelog.Changes), len(feed.Entries))
+	assert.Equal(t, changelog.Changes[0].Title, feed.Entries[0].Title)
+}
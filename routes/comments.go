@@ -0,0 +1,190 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/comments").
+		Methods("POST").
+		HandlerFunc(commentCreateHandler)
+
+	r.Path("/comments/{id}").
+		Methods("POST").
+		HandlerFunc(commentUpdateHandler)
+
+	r.Path("/comments/{id}/delete").
+		Methods("POST").
+		HandlerFunc(commentDeletionHandler)
+}
+
+// canSeeEntity checks the user holds whatever role the search target demands to see entities of that type.
+func canSeeEntity(ctx context.Context, org models.Organisation, target models.Searchable) bool {
+	if isAppAdmin(ctx) {
+		return true
+	}
+	return target.Permitted(orgUserFromContext(ctx, org).Roles, nil)
+}
+
+func commentCreateHandler(w http.ResponseWriter, r *http.Request) {
+	required := []string{
+		"entityType",
+		"entityID",
+		"organisationID",
+		"body",
+	}
+	if okay := checkFormInput(required, r.Form, w, r); !okay {
+		return
+	}
+
+	org := orgFromContext(r.Context(), r.FormValue("organisationID"))
+	if org.ID == "" {
+		errRes(w, r, http.StatusForbidden, "You are not a member of that organisation", nil)
+		return
+	}
+
+	user := userFromContext(r.Context())
+
+	comment := models.Comment{}
+	comment.New(org.ID, r.FormValue("entityType"), r.FormValue("entityID"), user.ID, r.FormValue("body"))
+
+	target, err := comment.Target(r.Context())
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Error finding the thing being commented on", err)
+		return
+	}
+
+	if !canSeeEntity(r.Context(), org, target) {
+		errRes(w, r, http.StatusForbidden, "You cannot comment on that", nil)
+		return
+	}
+
+	if err := saveCommentAndNotify(r.Context(), &comment, user, org); err != nil {
+		errRes(w, r, errCode(err), "Error saving comment", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/"+target.Path+"/"+comment.EntityID, r.Form), 302)
+}
+
+func commentUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	comment := models.Comment{}
+	if err := comment.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	user := userFromContext(r.Context())
+
+	if comment.UserID.String != user.ID {
+		errRes(w, r, http.StatusForbidden, "You can only edit your own comments", nil)
+		return
+	}
+
+	org := orgFromContext(r.Context(), comment.OrganisationID)
+	if org.ID == "" {
+		errRes(w, r, http.StatusForbidden, "You are not a member of that organisation", nil)
+		return
+	}
+
+	target, err := comment.Target(r.Context())
+	if err != nil {
+		errRes(w, r, errCode(err), "Error finding the thing being commented on", err)
+		return
+	}
+
+	if !canSeeEntity(r.Context(), org, target) {
+		errRes(w, r, http.StatusForbidden, "You cannot comment on that", nil)
+		return
+	}
+
+	comment.Body = r.FormValue("body")
+	comment.Edited = true
+
+	if err := saveCommentAndNotify(r.Context(), &comment, user, org); err != nil {
+		errRes(w, r, errCode(err), "Error saving comment", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/"+target.Path+"/"+comment.EntityID, r.Form), 302)
+}
+
+func saveCommentAndNotify(ctx context.Context, comment *models.Comment, author models.User, org models.Organisation) error {
+	members := models.OrganisationUsers{}
+	if err := members.FindAll(ctx, models.Criteria{
+		Query: &models.ByOrg{ID: org.ID},
+	}); err != nil {
+		return err
+	}
+
+	mentioned := comment.ResolveMentions(members)
+
+	if err := comment.Save(ctx); err != nil {
+		return err
+	}
+
+	return comment.NotifyMentions(ctx, author, org, mentioned)
+}
+
+func commentDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	comment := models.Comment{}
+	if err := comment.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	org := orgFromContext(r.Context(), comment.OrganisationID)
+
+	if comment.UserID.String != userFromContext(r.Context()).ID && !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You can only remove your own comments", nil)
+		return
+	}
+
+	target, err := comment.Target(r.Context())
+	if err != nil {
+		errRes(w, r, errCode(err), "Error finding the thing being commented on", err)
+		return
+	}
+
+	if err := comment.Delete(r.Context()); err != nil {
+		errRes(w, r, 500, "Error removing comment", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/"+target.Path+"/"+comment.EntityID, r.Form), 302)
+}
+
+// activityTimeline gathers the comments on an entity and, for admins, the changes made to it.
+func activityTimeline(ctx context.Context, org models.Organisation, entityID string) (models.Timeline, error) {
+	comments := models.Comments{}
+	if err := comments.FindAll(ctx, models.Criteria{
+		Query: &models.ByOrg{ID: org.ID},
+		Filters: models.Filters{&models.Custom{
+			Col:    "entity_id",
+			Values: []string{entityID},
+		}},
+	}); err != nil {
+		return models.Timeline{}, err
+	}
+
+	audits := models.Audits{}
+	if can(ctx, org, "admin") {
+		criteria := models.Criteria{
+			Pagination: models.Pagination{Limit: 50},
+		}
+		models.AddCustomQuery(models.ByEntityID{EntityID: entityID}, &criteria)
+		if err := audits.FindAll(ctx, criteria); err != nil {
+			return models.Timeline{}, err
+		}
+	}
+
+	return models.BuildTimeline(comments, audits), nil
+}
@@ -0,0 +1,224 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommentCreateHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	fixture := someThingFixture(ctx, t, org)
+
+	form := url.Values{
+		"entityType":     {"SomeThing"},
+		"entityID":       {fixture.ID},
+		"organisationID": {org.ID},
+		"body":           {"**" + bandname() + "**"},
+	}
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/comments"},
+		Form:   form,
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	commentCreateHandler(rr, req)
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, "/some-things/"+fixture.ID, rr.Header().Get("Location"))
+
+	timeline, err := activityTimeline(ctx, org, fixture.ID)
+	assert.Nil(t, err)
+
+	comments := 0
+	for _, entry := range timeline {
+		if entry.IsComment() {
+			comments++
+		}
+	}
+	assert.Equal(t, 1, comments)
+
+	closeTx(t, ctx)
+}
+
+func TestCommentCreateHandlerRejectsOutsiders(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	fixture := someThingFixture(ctx, t, org)
+
+	other := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, other)
+
+	form := url.Values{
+		"entityType":     {"SomeThing"},
+		"entityID":       {fixture.ID},
+		"organisationID": {org.ID},
+		"body":           {bandname()},
+	}
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/comments"},
+		Form:   form,
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	commentCreateHandler(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	comments := models.Comments{}
+	assert.Nil(t, comments.FindAll(ctx, models.Criteria{Query: &models.ByOrg{ID: org.ID}}))
+	assert.Equal(t, 0, len(comments.Data))
+
+	closeTx(t, ctx)
+}
+
+func TestCommentUpdateHandlerRequiresAuthor(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	fixture := someThingFixture(ctx, t, org)
+
+	author, _ := userFixture(ctx, t)
+	someoneElse, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", someoneElse)
+
+	comment := models.Comment{}
+	comment.New(org.ID, "SomeThing", fixture.ID, author.ID, bandname())
+	assert.Nil(t, comment.Save(ctx))
+
+	req, err := http.NewRequest("POST", "/comments/"+comment.ID, nil)
+	assert.Nil(t, err)
+	req.Form = url.Values{"body": {bandname()}}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+
+	r := mux.NewRouter()
+	r.HandleFunc("/comments/{id}", commentUpdateHandler).Methods("POST")
+	r.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	closeTx(t, ctx)
+}
+
+func TestCommentCreateHandlerOnOrganisationsAndMembers(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+	member := organisationUserFixture(ctx, t)
+	org := models.Organisation{}
+	assert.Nil(t, org.FindByID(ctx, member.OrganisationID))
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	for entityType, entityID := range map[string]string{
+		"Organisation": org.ID,
+		"Member":       member.ID,
+	} {
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/comments"},
+			Form: url.Values{
+				"entityType":     {entityType},
+				"entityID":       {entityID},
+				"organisationID": {org.ID},
+				"body":           {bandname()},
+			},
+		}
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		commentCreateHandler(rr, req)
+		assert.Equal(t, http.StatusFound, rr.Code, entityType)
+
+		timeline, err := activityTimeline(ctx, org, entityID)
+		assert.Nil(t, err)
+
+		comments := 0
+		for _, entry := range timeline {
+			if entry.IsComment() {
+				comments++
+			}
+		}
+		assert.Equal(t, 1, comments, entityType)
+	}
+}
+
+func TestCommentUpdateHandlerRequiresMembership(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+	org := organisationFixture(ctx, t)
+	fixture := someThingFixture(ctx, t, org)
+
+	author, _ := userFixture(ctx, t)
+
+	comment := models.Comment{}
+	comment.New(org.ID, "SomeThing", fixture.ID, author.ID, bandname())
+	assert.Nil(t, comment.Save(ctx))
+
+	// The author has since left, and only belongs to another organisation
+	other := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, other)
+	ctx = context.WithValue(ctx, "user", author)
+
+	req, err := http.NewRequest("POST", "/comments/"+comment.ID, nil)
+	assert.Nil(t, err)
+	req.Form = url.Values{"body": {bandname()}}
+	req = mux.SetURLVars(req.WithContext(ctx), map[string]string{"id": comment.ID})
+
+	rr := httptest.NewRecorder()
+	commentUpdateHandler(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestCommentCreateHandlerRejectsBlankComments(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	fixture := someThingFixture(ctx, t, org)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/comments"},
+		Form: url.Values{
+			"entityType":     {"SomeThing"},
+			"entityID":       {fixture.ID},
+			"organisationID": {org.ID},
+			"body":           {"   "},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	commentCreateHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	closeTx(t, ctx)
+}
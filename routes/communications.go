@@ -24,6 +24,7 @@ type communicationsPageData struct {
 	Communications models.Communications
 	ActiveOrg      models.Organisation
 	Users          models.Users
+	SavedViews     savedViewsData
 }
 
 func communicationsHandler(w http.ResponseWriter, r *http.Request) {
@@ -39,6 +40,11 @@ func communicationsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	savedViews, written := applySavedView(w, r, "communications", targetOrg)
+	if written {
+		return
+	}
+
 	customFilters := models.Filters{}
 
 	if r.FormValue("org-user-id") != "" {
@@ -72,11 +78,16 @@ func communicationsHandler(w http.ResponseWriter, r *http.Request) {
 	criteria := models.Criteria{
 		Query: &models.ByOrg{ID: targetOrg.ID},
 	}
-	criteria.Pagination.DefaultPageSize = 50
-	criteria.Pagination.Paginate(r.Form)
+	criteria.Cursor.DefaultPageSize = 50
+	if err := criteria.Cursor.Paginate(r.Form); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting page", err)
+		return
+	}
 
 	criteria.Filters.FromForm(r.Form, communications.AvailableFilters(), customFilters...)
 
+	criteria.Sort.FromForm(r.Form, communications.Sortables())
+
 	if err := communications.FindAll(r.Context(), criteria); err != nil {
 		errRes(w, r, 500, "error fetching communications", err)
 		return
@@ -94,8 +105,9 @@ func communicationsHandler(w http.ResponseWriter, r *http.Request) {
 			PageTitle: "DoubleBoiler - Communications",
 			Context:   r.Context(),
 		},
-		ActiveOrg: targetOrg,
-		Users:     users,
+		ActiveOrg:  targetOrg,
+		Users:      users,
+		SavedViews: savedViews,
 	}); err != nil {
 		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
 		return
@@ -53,3 +53,38 @@ func communicationFixture(ctx context.Context, t *testing.T, user models.User, o
 	assert.Nil(t, communication.Save(ctx))
 	return communication
 }
+
+func TestCommunicationsHandlerCursor(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	user, _ := userFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	communicationFixture(ctx, t, user, org)
+	communicationFixture(ctx, t, user, org)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/communications", communicationsHandler).Methods("GET")
+
+	req, err := http.NewRequest("GET", fmt.Sprintf("/communications?organisationid=%s&limit=1", org.ID), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, req.ParseForm())
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "cursor=")
+	assert.NotContains(t, rr.Body.String(), "skip=")
+
+	req, err = http.NewRequest("GET", fmt.Sprintf("/communications?organisationid=%s&cursor=nonsense", org.ID), nil)
+	assert.Nil(t, err)
+	assert.Nil(t, req.ParseForm())
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	closeTx(t, ctx)
+}
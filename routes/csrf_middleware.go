@@ -5,6 +5,7 @@ import (
 	"doubleboiler/logger"
 	"doubleboiler/models"
 	"doubleboiler/util"
+	"errors"
 	"net/http"
 )
 
@@ -26,7 +27,13 @@ func csrfMiddleware(h http.Handler) http.Handler {
 
 		u = unconv.(models.User)
 
-		r.ParseMultipartForm(128 << 20)
+		if err := r.ParseMultipartForm(128 << 20); err != nil {
+			var tooBig *http.MaxBytesError
+			if errors.As(err, &tooBig) {
+				errRes(w, r, http.StatusRequestEntityTooLarge, "That upload is too big", err)
+				return
+			}
+		}
 		r.ParseForm()
 
 		if err := util.CheckToken(config.SECRET, "", u.ID, r.FormValue("csrf")); err == nil {
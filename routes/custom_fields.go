@@ -0,0 +1,71 @@
+package routes
+
+import (
+	"doubleboiler/models"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/custom-fields").
+		Methods("POST").
+		HandlerFunc(customFieldCreateHandler)
+
+	r.Path("/custom-fields/{id}/delete").
+		Methods("POST").
+		HandlerFunc(customFieldDeletionHandler)
+}
+
+func customFieldCreateHandler(w http.ResponseWriter, r *http.Request) {
+	required := []string{
+		"label",
+		"fieldType",
+		"organisationID",
+	}
+	if okay := checkFormInput(required, r.Form, w, r); !okay {
+		return
+	}
+
+	org := orgFromContext(r.Context(), r.FormValue("organisationID"))
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot manage fields for that organisation", nil)
+		return
+	}
+
+	field := models.CustomField{}
+	field.New(org.ID, r.FormValue("label"), r.FormValue("fieldType"), strings.Split(r.FormValue("options"), ","), r.FormValue("required") == "true")
+
+	if err := field.Save(r.Context()); err != nil {
+		errRes(w, r, errCode(err), "Error saving field", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/organisations/"+org.ID, r.Form), 302)
+}
+
+func customFieldDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	field := models.CustomField{}
+	if err := field.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	org := orgFromContext(r.Context(), field.OrganisationID)
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot manage fields for that organisation", nil)
+		return
+	}
+
+	if err := field.Delete(r.Context()); err != nil {
+		errRes(w, r, 500, "Error removing field", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/organisations/"+org.ID, r.Form), 302)
+}
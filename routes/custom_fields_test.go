@@ -0,0 +1,185 @@
+package routes
+
+import (
+	"doubleboiler/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCustomFieldCreateAndUse(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/custom-fields"},
+		Form: url.Values{
+			"label":          {"Priority"},
+			"fieldType":      {"select"},
+			"options":        {"low, high"},
+			"organisationID": {org.ID},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	customFieldCreateHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	req = &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/tags"},
+		Form: url.Values{
+			"name":           {"urgent"},
+			"organisationID": {org.ID},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr = httptest.NewRecorder()
+	tagCreateHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	fixture := someThingFixture(ctx, t, org)
+
+	req = &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/some-things/" + fixture.ID},
+		Form: url.Values{
+			"id":             {fixture.ID},
+			"name":           {fixture.Name},
+			"description":    {fixture.Description},
+			"organisationID": {org.ID},
+			"tags":           {"urgent"},
+			"field.priority": {"high"},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr = httptest.NewRecorder()
+	someThingCreateOrUpdateHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	found := models.SomeThing{}
+	assert.Nil(t, found.FindByID(ctx, fixture.ID))
+	assert.Equal(t, models.TagList{"urgent"}, found.Tags)
+	assert.Equal(t, "high", found.CustomFields.Display("priority"))
+
+	closeTx(t, ctx)
+}
+
+func TestCustomFieldCreateRequiresAdmin(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/custom-fields"},
+		Form: url.Values{
+			"label":          {"Priority"},
+			"fieldType":      {"text"},
+			"organisationID": {org.ID},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	customFieldCreateHandler(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	closeTx(t, ctx)
+}
+
+func TestSomeThingUpdateWithUnknownTag(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+	fixture := someThingFixture(ctx, t, org)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/some-things/" + fixture.ID},
+		Form: url.Values{
+			"id":             {fixture.ID},
+			"name":           {fixture.Name},
+			"description":    {fixture.Description},
+			"organisationID": {org.ID},
+			"tags":           {"nonsense"},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	someThingCreateOrUpdateHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	closeTx(t, ctx)
+}
+
+func TestCustomDataFromFormKeepsMissingInputs(t *testing.T) {
+	t.Parallel()
+
+	fields := models.CustomFields{Data: []models.CustomField{
+		{Key: "priority", FieldType: models.FieldTypeText},
+		{Key: "done", FieldType: models.FieldTypeBoolean},
+	}}
+	someThing := models.SomeThing{
+		Tags:         models.TagList{"urgent"},
+		CustomFields: models.CustomFieldValues{"priority": "high", "done": true},
+	}
+
+	assert.Nil(t, customDataFromForm(&someThing, fields, url.Values{}))
+	assert.Equal(t, models.TagList{"urgent"}, someThing.Tags)
+	assert.Equal(t, "high", someThing.CustomFields["priority"])
+	assert.Equal(t, true, someThing.CustomFields["done"])
+
+	form := url.Values{
+		"tags":           {""},
+		"field.priority": {""},
+		"field.done":     {""},
+	}
+	assert.Nil(t, customDataFromForm(&someThing, fields, form))
+	assert.Equal(t, models.TagList{}, someThing.Tags)
+	assert.NotContains(t, someThing.CustomFields, "priority")
+	assert.Equal(t, false, someThing.CustomFields["done"])
+
+	form = url.Values{"field.done": {"", "true"}}
+	assert.Nil(t, customDataFromForm(&someThing, fields, form))
+	assert.Equal(t, true, someThing.CustomFields["done"])
+}
+
+func TestCustomFieldCreateWithInvalidType(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/custom-fields"},
+		Form: url.Values{
+			"label":          {"Priority"},
+			"fieldType":      {"nonsense"},
+			"organisationID": {org.ID},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	customFieldCreateHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	closeTx(t, ctx)
+}
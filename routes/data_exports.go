@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"database/sql"
+	"doubleboiler/filestore"
+	"doubleboiler/flashes"
+	"doubleboiler/models"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/users/{id}/data-export").
+		Methods("POST").
+		HandlerFunc(dataExportRequestHandler)
+
+	r.Path("/data-exports/{id}/download").
+		Methods("GET").
+		HandlerFunc(dataExportDownloadHandler)
+}
+
+func dataExportRequestHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	user := userFromContext(r.Context())
+
+	if user.ID != vars["id"] || impersonatorFromContext(r.Context()).ID != "" {
+		errRes(w, r, http.StatusForbidden, "Only you can export your data", nil)
+		return
+	}
+
+	export := models.DataExport{}
+	export.New(user.ID)
+	if err := export.Request(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error requesting data export", err)
+		return
+	}
+
+	if ctx, err := user.PersistFlash(r.Context(), flashes.Flash{
+		Type: flashes.Success,
+		Text: "We're putting together a copy of your data. We'll email you a link to download it when it's ready.",
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error adding flash message", err)
+		return
+	} else {
+		r = r.WithContext(ctx)
+	}
+
+	http.Redirect(w, r, "/users/"+user.ID, http.StatusFound)
+}
+
+func dataExportDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	export := models.DataExport{}
+	if err := export.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		if err == sql.ErrNoRows {
+			errRes(w, r, http.StatusNotFound, "That export has expired", nil)
+			return
+		}
+		errRes(w, r, http.StatusInternalServerError, "A database error has occurred", err)
+		return
+	}
+
+	if export.UserID != userFromContext(r.Context()).ID || impersonatorFromContext(r.Context()).ID != "" {
+		errRes(w, r, http.StatusForbidden, "Only you can download your data", nil)
+		return
+	}
+
+	if !export.Downloadable(time.Now()) {
+		errRes(w, r, http.StatusNotFound, "That export isn't available", nil)
+		return
+	}
+
+	url, err := export.SignedURL(filestore.Default)
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error creating download link", err)
+		return
+	}
+
+	http.Redirect(w, r, url, http.StatusFound)
+}
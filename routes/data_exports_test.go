@@ -0,0 +1,70 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataExportRequestHandler(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	fixture, _ := userFixture(ctx, t)
+	other, _ := userFixture(ctx, t)
+
+	serve := func(ctx context.Context, target models.User) int {
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/users/" + target.ID + "/data-export"},
+			Form:   url.Values{},
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": target.ID})
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		dataExportRequestHandler(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, serve(context.WithValue(ctx, "user", other), fixture))
+	assert.Equal(t, http.StatusForbidden, serve(context.WithValue(context.WithValue(ctx, "user", fixture), "impersonator", other), fixture))
+	assert.Equal(t, http.StatusFound, serve(context.WithValue(ctx, "user", fixture), fixture))
+}
+
+func TestDataExportDownloadHandler(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	fixture, _ := userFixture(ctx, t)
+	other, _ := userFixture(ctx, t)
+
+	export := models.DataExport{}
+	export.New(fixture.ID)
+	assert.Nil(t, export.Save(ctx))
+
+	serve := func(ctx context.Context) int {
+		req := &http.Request{
+			Method: "GET",
+			URL:    &url.URL{Path: "/data-exports/" + export.ID + "/download"},
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": export.ID})
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		dataExportDownloadHandler(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, serve(context.WithValue(ctx, "user", other)))
+	// Still being built
+	assert.Equal(t, http.StatusNotFound, serve(context.WithValue(ctx, "user", fixture)))
+}
@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"doubleboiler/logger"
+	"doubleboiler/models"
+	"doubleboiler/util"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/dead-letters").
+		Methods("GET").
+		HandlerFunc(deadLettersHandler)
+
+	r.Path("/dead-letters/{id}").
+		Methods("GET").
+		HandlerFunc(deadLetterHandler)
+
+	r.Path("/dead-letters/{id}/retry").
+		Methods("POST").
+		HandlerFunc(deadLetterRetryHandler)
+
+	r.Path("/dead-letters/{id}/discard").
+		Methods("POST").
+		HandlerFunc(deadLetterDiscardHandler)
+}
+
+type deadLettersPageData struct {
+	basePageData
+	DeadLetters models.DeadLetters
+}
+
+func deadLettersHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	customFilters := models.Filters{}
+	if r.FormValue("queue") != "" {
+		customFilters = append(customFilters, &models.Custom{
+			Col:         "queue_name",
+			Values:      []string{r.FormValue("queue")},
+			CustomID:    "queue",
+			CustomLabel: r.FormValue("queue"),
+		})
+		r.Form.Add("custom-filter", "queue")
+	}
+
+	deadLetters := models.DeadLetters{}
+
+	criteria := models.Criteria{
+		Query: &models.All{},
+	}
+	criteria.Pagination.DefaultPageSize = 50
+	criteria.Pagination.Paginate(r.Form)
+
+	if err := criteria.Filters.FromForm(r.Form, deadLetters.AvailableFilters(), customFilters...); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+		return
+	}
+
+	if err := deadLetters.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, 500, "error fetching dead letters", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "dead-letters.html", deadLettersPageData{
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Failed Tasks",
+			Context:   r.Context(),
+		},
+		DeadLetters: deadLetters,
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+type deadLetterPageData struct {
+	basePageData
+	DeadLetter models.DeadLetter
+	Tags       string
+}
+
+func deadLetterHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	deadLetter := models.DeadLetter{}
+	if err := deadLetter.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "error fetching dead letter", err)
+		return
+	}
+
+	tags, err := json.MarshalIndent(deadLetter.Tags, "", "  ")
+	if err != nil {
+		errRes(w, r, 500, "error formatting tags", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "dead-letter.html", deadLetterPageData{
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Failed Task " + util.FirstFiveChars(deadLetter.ID),
+			Context:   r.Context(),
+		},
+		DeadLetter: deadLetter,
+		Tags:       string(tags),
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+func deadLetterRetryHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	deadLetter := models.DeadLetter{}
+	if err := deadLetter.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "error fetching dead letter", err)
+		return
+	}
+
+	if r.FormValue("body") != "" {
+		deadLetter.Body = r.FormValue("body")
+	}
+
+	if r.FormValue("tags") != "" {
+		tags := kewpie.Tags{}
+		if err := json.Unmarshal([]byte(r.FormValue("tags")), &tags); err != nil {
+			errRes(w, r, http.StatusBadRequest, "Tags must be a JSON object of strings", err)
+			return
+		}
+		deadLetter.Tags = tags
+	}
+
+	task := deadLetter.Task()
+	if err := models.Enqueue(r.Context(), deadLetter.QueueName, &task); err != nil {
+		errRes(w, r, 500, "error queueing task", err)
+		return
+	}
+
+	if err := deadLetter.Delete(r.Context()); err != nil {
+		errRes(w, r, 500, "error removing dead letter", err)
+		return
+	}
+
+	logger.Log(r.Context(), logger.Audit, fmt.Sprintf("user %s retried dead letter %s on %s", userFromContext(r.Context()).ID, deadLetter.ID, deadLetter.QueueName))
+
+	http.Redirect(w, r, nextFlow("/dead-letters", r.Form), 302)
+}
+
+func deadLetterDiscardHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	deadLetter := models.DeadLetter{}
+	if err := deadLetter.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "error fetching dead letter", err)
+		return
+	}
+
+	if err := deadLetter.Delete(r.Context()); err != nil {
+		errRes(w, r, 500, "error removing dead letter", err)
+		return
+	}
+
+	logger.Log(r.Context(), logger.Audit, fmt.Sprintf("user %s discarded dead letter %s on %s", userFromContext(r.Context()).ID, deadLetter.ID, deadLetter.QueueName))
+
+	http.Redirect(w, r, nextFlow("/dead-letters", r.Form), 302)
+}
@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/models"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterRetryHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+
+	u := models.User{}
+	u.New(bandEmail(), bandname())
+	u.SuperAdmin = true
+	assert.Nil(t, u.Save(ctx))
+	ctx = context.WithValue(ctx, "user", u)
+
+	fixture := deadLetterFixture(ctx, t)
+
+	form := url.Values{
+		"body": {`{"to":"` + bandEmail() + `"}`},
+		"tags": {`{"user_id":"` + u.ID + `"}`},
+	}
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/dead-letters/" + fixture.ID + "/retry"},
+		Form:   form,
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": fixture.ID})
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	deadLetterRetryHandler(rr, req)
+
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	notFound := models.DeadLetter{}
+	assert.Equal(t, sql.ErrNoRows, notFound.FindByID(ctx, fixture.ID))
+
+	closeTx(t, ctx)
+}
+
+func TestDeadLetterRetryHandlerRequiresSuperadmin(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	user, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", user)
+
+	fixture := deadLetterFixture(ctx, t)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/dead-letters/" + fixture.ID + "/retry"},
+		Form:   url.Values{},
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": fixture.ID})
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	deadLetterRetryHandler(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	closeTx(t, ctx)
+}
+
+func deadLetterFixture(ctx context.Context, t *testing.T) (deadLetter models.DeadLetter) {
+	deadLetter.New(config.SEND_EMAIL_QUEUE_NAME, kewpie.Task{Body: "{}"}, errors.New(bandname()))
+	assert.Nil(t, deadLetter.Save(ctx))
+	return deadLetter
+}
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"net/http"
+)
+
+// featureFlagMiddleware loads every flag once per request, so checking them in handlers and
+// templates doesn't go back to the database.
+func featureFlagMiddleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flags := models.FeatureFlags{}
+		if err := flags.FindAll(r.Context(), models.Criteria{Query: &models.All{}}); err != nil {
+			errRes(w, r, http.StatusInternalServerError, "error looking up feature flags", err)
+			return
+		}
+
+		h.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "feature_flags", flags)))
+	})
+}
+
+func featureFlagsFromContext(ctx context.Context) models.FeatureFlags {
+	if ctx == nil {
+		return models.FeatureFlags{}
+	}
+
+	unconv := ctx.Value("feature_flags")
+
+	if unconv == nil {
+		return models.FeatureFlags{}
+	}
+
+	return unconv.(models.FeatureFlags)
+}
+
+// featureEnabled is whether the flag is on for the logged in user in the organisation they're working in.
+func featureEnabled(ctx context.Context, key string) bool {
+	return featureFlagsFromContext(ctx).Enabled(key, activeOrgFromContext(ctx).ID, userFromContext(ctx).ID)
+}
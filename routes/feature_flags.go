@@ -0,0 +1,220 @@
+package routes
+
+import (
+	"database/sql"
+	"doubleboiler/models"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+)
+
+func init() {
+	r.Path("/admin/feature-flags").
+		Methods("GET").
+		HandlerFunc(featureFlagsHandler)
+
+	r.Path("/admin/feature-flags").
+		Methods("POST").
+		HandlerFunc(createFeatureFlagHandler)
+
+	r.Path("/admin/feature-flags/{id}").
+		Methods("GET").
+		HandlerFunc(featureFlagHandler)
+
+	r.Path("/admin/feature-flags/{id}").
+		Methods("POST").
+		HandlerFunc(updateFeatureFlagHandler)
+
+	r.Path("/admin/feature-flags/{id}/delete").
+		Methods("POST").
+		HandlerFunc(deleteFeatureFlagHandler)
+}
+
+type featureFlagsPageData struct {
+	basePageData
+	FeatureFlags models.FeatureFlags
+}
+
+func featureFlagsHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	flags := models.FeatureFlags{}
+	if err := flags.FindAll(r.Context(), models.Criteria{Query: &models.All{}}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error fetching feature flags", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "feature-flags.html", featureFlagsPageData{
+		FeatureFlags: flags,
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Feature Flags",
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+func createFeatureFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	if ok := checkFormInput([]string{"key"}, r.Form, w, r); !ok {
+		return
+	}
+
+	key := strings.ToLower(strings.TrimSpace(r.FormValue("key")))
+
+	existing := models.FeatureFlag{}
+	if err := existing.FindByColumn(r.Context(), "key", key); err == nil {
+		errRes(w, r, http.StatusBadRequest, "There's already a feature flag with that key", nil)
+		return
+	} else if err != sql.ErrNoRows {
+		errRes(w, r, http.StatusInternalServerError, "error checking feature flags", err)
+		return
+	}
+
+	flag := models.FeatureFlag{}
+	flag.New(key, strings.TrimSpace(r.FormValue("description")))
+
+	if err := flag.Save(r.Context()); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error creating feature flag", err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/feature-flags/"+flag.ID, http.StatusFound)
+}
+
+type featureFlagPageData struct {
+	basePageData
+	FeatureFlag models.FeatureFlag
+	Audits      models.Audits
+}
+
+func featureFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	flag := models.FeatureFlag{}
+	if err := flag.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		if err == sql.ErrNoRows {
+			errRes(w, r, http.StatusNotFound, "Feature flag not found", err)
+			return
+		}
+		errRes(w, r, http.StatusInternalServerError, "error fetching feature flag", err)
+		return
+	}
+
+	audits := models.Audits{}
+	criteria := models.Criteria{}
+	models.AddCustomQuery(models.ByEntityID{EntityID: flag.ID}, &criteria)
+	criteria.Pagination.Limit = 20
+	if err := audits.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error fetching audits", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "feature-flag.html", featureFlagPageData{
+		FeatureFlag: flag,
+		Audits:      audits,
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Feature Flags - " + flag.Key,
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+// idList reads IDs separated by commas, spaces or new lines, so they can be pasted in from anywhere.
+func idList(s string) ([]string, bool) {
+	ids := []string{}
+	for _, id := range strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	}) {
+		if _, err := uuid.FromString(id); err != nil {
+			return nil, false
+		}
+		ids = append(ids, id)
+	}
+	return ids, true
+}
+
+func updateFeatureFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	flag := models.FeatureFlag{}
+	if err := flag.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "Feature flag not found", err)
+		return
+	}
+
+	rollout := 0
+	if v := strings.TrimSpace(r.FormValue("rollout-percent")); v != "" {
+		var err error
+		if rollout, err = strconv.Atoi(v); err != nil {
+			errRes(w, r, http.StatusBadRequest, "The rollout must be a whole number", err)
+			return
+		}
+	}
+
+	orgIDs, ok := idList(r.FormValue("organisation-ids"))
+	if !ok {
+		errRes(w, r, http.StatusBadRequest, "Organisations must be a list of organisation IDs", nil)
+		return
+	}
+
+	userIDs, ok := idList(r.FormValue("user-ids"))
+	if !ok {
+		errRes(w, r, http.StatusBadRequest, "Users must be a list of user IDs", nil)
+		return
+	}
+
+	flag.Description = strings.TrimSpace(r.FormValue("description"))
+	flag.Enabled = r.FormValue("enabled") == "true"
+	flag.RolloutPercent = rollout
+	flag.OrganisationIDs = models.NullStringList{Valid: true, Strings: orgIDs}
+	flag.UserIDs = models.NullStringList{Valid: true, Strings: userIDs}
+
+	if err := flag.Save(r.Context()); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error updating feature flag", err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/feature-flags/"+flag.ID, http.StatusFound)
+}
+
+func deleteFeatureFlagHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	flag := models.FeatureFlag{}
+	if err := flag.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "Feature flag not found", err)
+		return
+	}
+
+	if err := flag.Delete(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error deleting feature flag", err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/feature-flags", http.StatusFound)
+}
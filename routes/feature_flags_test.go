@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/mux"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureFlagsRequireSuperadmin(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	user, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", user)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/admin/feature-flags"},
+		Form:   url.Values{"key": {"sneaky"}},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	createFeatureFlagHandler(rr, req)
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
+
+func TestFeatureFlagCreateAndUpdate(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	ctx = superadminContext(ctx, t)
+	org := organisationFixture(ctx, t)
+	key := "flag-" + uuid.NewV4().String()
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/admin/feature-flags"},
+		Form:   url.Values{"key": {key}, "description": {"Testing"}},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	createFeatureFlagHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	flag := models.FeatureFlag{}
+	assert.Nil(t, flag.FindByColumn(ctx, "key", key))
+	assert.False(t, flag.EnabledFor(org.ID, ""))
+
+	rr = httptest.NewRecorder()
+	createFeatureFlagHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code, "keys are unique")
+
+	for ids, expected := range map[string]int{
+		"not-an-id":                 http.StatusBadRequest,
+		org.ID + ",\n" + org.ID[:8]: http.StatusBadRequest,
+		"\n" + org.ID + "\n":        http.StatusFound,
+	} {
+		req = &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/admin/feature-flags/" + flag.ID},
+			Form:   url.Values{"organisation-ids": {ids}, "rollout-percent": {"0"}},
+		}
+		req = mux.SetURLVars(req.WithContext(ctx), map[string]string{"id": flag.ID})
+
+		rr = httptest.NewRecorder()
+		updateFeatureFlagHandler(rr, req)
+		assert.Equal(t, expected, rr.Code, ids)
+	}
+
+	assert.Nil(t, flag.FindByID(ctx, flag.ID))
+	assert.Equal(t, []string{org.ID}, flag.OrganisationIDs.Strings)
+
+	flags := models.FeatureFlags{}
+	assert.Nil(t, flags.FindAll(ctx, models.Criteria{Query: &models.All{}}))
+	flagCtx := context.WithValue(context.WithValue(contextifyOrgAdmin(ctx, org), "target_org", org.ID), "feature_flags", flags)
+	assert.True(t, featureEnabled(flagCtx, key))
+	assert.False(t, featureEnabled(ctx, key), "flags aren't loaded")
+	assert.False(t, featureEnabled(flagCtx, "no-such-flag"))
+}
@@ -1,9 +1,18 @@
 package routes
 
-import "net/http"
+import (
+	"doubleboiler/models"
+	"net/http"
+)
+
+// maxRequestBytes leaves room for the rest of the form around the biggest upload we accept.
+const maxRequestBytes = models.MaxAttachmentBytes + 1<<20
 
 func formParsingMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+		}
 		r.ParseForm()
 		h.ServeHTTP(w, r)
 	})
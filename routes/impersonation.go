@@ -0,0 +1,130 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/logger"
+	"doubleboiler/models"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/impersonation/stop").
+		Methods("POST").
+		HandlerFunc(stopImpersonatingHandler)
+}
+
+// impersonationDuration is how long a superadmin can act as someone else before they're put back
+// to being themselves.
+const impersonationDuration = time.Hour
+
+func impersonatorFromContext(ctx context.Context) models.User {
+	if ctx == nil {
+		return models.User{}
+	}
+	if impersonator, ok := ctx.Value("impersonator").(models.User); ok {
+		return impersonator
+	}
+	return models.User{}
+}
+
+func setUserCookie(w http.ResponseWriter, values map[string]string, expiration time.Time) error {
+	encoded, err := secureCookie.Encode("doubleboiler-user", values)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, &http.Cookie{
+		Path:     "/",
+		Name:     "doubleboiler-user",
+		Value:    encoded,
+		Expires:  expiration,
+		Secure:   true,
+		HttpOnly: true,
+	})
+	return nil
+}
+
+// userImpersonater lets a superadmin see the app as another user does. The superadmin's own ID
+// stays in the cookie, so they're still the one responsible for anything done in the meantime.
+func userImpersonater(w http.ResponseWriter, r *http.Request) {
+	loggedInUser := userFromContext(r.Context())
+	if !loggedInUser.SuperAdmin {
+		errRes(w, r, 403, "You are not an admin", nil)
+		return
+	}
+
+	if impersonatorFromContext(r.Context()).ID != "" {
+		errRes(w, r, http.StatusBadRequest, "Stop impersonating before impersonating someone else", nil)
+		return
+	}
+
+	if ok := checkFormInput([]string{"reason"}, r.Form, w, r); !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	user := models.User{}
+	if err := user.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "error fetching user", err)
+		return
+	}
+
+	if user.ID == loggedInUser.ID {
+		errRes(w, r, http.StatusBadRequest, "You can't impersonate yourself", nil)
+		return
+	}
+
+	if user.Disabled {
+		errRes(w, r, http.StatusBadRequest, "That account is disabled", nil)
+		return
+	}
+
+	if err := user.RecordImpersonation(r.Context(), r.FormValue("reason")); err != nil {
+		errRes(w, r, 500, "Error recording impersonation", err)
+		return
+	}
+
+	logger.Log(r.Context(), logger.Info, fmt.Sprintf("%s (%s) is impersonating %s (%s)", loggedInUser.Email, loggedInUser.ID, user.Email, user.ID))
+
+	expiration := time.Now().Add(impersonationDuration)
+	if err := setUserCookie(w, map[string]string{
+		"ID":                  user.ID,
+		"TOTP":                "true",
+		"ImpersonatorID":      loggedInUser.ID,
+		"ImpersonationExpiry": expiration.Format(time.RFC3339),
+	}, expiration); err != nil {
+		errRes(w, r, 500, "Error encoding cookie", err)
+		return
+	}
+
+	http.Redirect(w, r, "/dashboard", http.StatusFound)
+}
+
+func stopImpersonatingHandler(w http.ResponseWriter, r *http.Request) {
+	impersonator := impersonatorFromContext(r.Context())
+	if impersonator.ID == "" {
+		errRes(w, r, http.StatusBadRequest, "You are not impersonating anyone", nil)
+		return
+	}
+
+	impersonated := userFromContext(r.Context())
+
+	if err := resumeAsImpersonator(w, impersonator); err != nil {
+		errRes(w, r, 500, "Error encoding cookie", err)
+		return
+	}
+
+	http.Redirect(w, r, "/admin/users/"+impersonated.ID, http.StatusFound)
+}
+
+// resumeAsImpersonator puts the superadmin back in their own session. They had to pass 2FA to
+// start impersonating, so it's still considered verified.
+func resumeAsImpersonator(w http.ResponseWriter, impersonator models.User) error {
+	return setUserCookie(w, map[string]string{
+		"ID":   impersonator.ID,
+		"TOTP": "true",
+	}, time.Now().Add(30*24*time.Hour))
+}
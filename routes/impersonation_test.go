@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func impersonationRequest(ctx context.Context, target models.User, reason string) *http.Request {
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/users/" + target.ID + "/impersonate"},
+		Form:   url.Values{"reason": {reason}},
+	}
+	req = mux.SetURLVars(req, map[string]string{"id": target.ID})
+	return req.WithContext(ctx)
+}
+
+func TestUserImpersonater(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	target, _ := userFixture(ctx, t)
+	ctx = superadminContext(ctx, t)
+	admin := userFromContext(ctx)
+
+	rr := httptest.NewRecorder()
+	userImpersonater(rr, impersonationRequest(ctx, target, ""))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	rr = httptest.NewRecorder()
+	userImpersonater(rr, impersonationRequest(ctx, target, "Reproducing a support ticket"))
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	cookies := rr.Result().Cookies()
+	assert.Len(t, cookies, 1)
+	assert.WithinDuration(t, time.Now().Add(impersonationDuration), cookies[0].Expires, time.Minute)
+
+	values := map[string]string{}
+	assert.Nil(t, secureCookie.Decode("doubleboiler-user", cookies[0].Value, &values))
+	assert.Equal(t, target.ID, values["ID"])
+	assert.Equal(t, admin.ID, values["ImpersonatorID"])
+
+	audits := models.Audits{}
+	criteria := models.Criteria{}
+	models.AddCustomQuery(models.ByEntityID{EntityID: target.ID}, &criteria)
+	assert.Nil(t, audits.FindAll(ctx, criteria))
+	assert.Equal(t, "Impersonated: Reproducing a support ticket", audits.Data[0].Reason)
+	assert.Equal(t, admin.ID, audits.Data[0].UserID)
+}
+
+func TestUserMiddlewareImpersonation(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	target, _ := userFixture(ctx, t)
+	admin := userFromContext(superadminContext(ctx, t))
+
+	var seen context.Context
+	middle := userMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.Context()
+	}))
+
+	serve := func(expiry time.Time) *httptest.ResponseRecorder {
+		encoded, err := secureCookie.Encode("doubleboiler-user", map[string]string{
+			"ID":                  target.ID,
+			"TOTP":                "true",
+			"ImpersonatorID":      admin.ID,
+			"ImpersonationExpiry": expiry.Format(time.RFC3339),
+		})
+		assert.Nil(t, err)
+
+		req, err := http.NewRequest("GET", "/dashboard", nil)
+		assert.Nil(t, err)
+		req.AddCookie(&http.Cookie{Name: "doubleboiler-user", Value: encoded})
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		middle.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// While it lasts, the target is the user and the admin is kept alongside
+	rr := serve(time.Now().Add(time.Minute))
+	assert.Equal(t, target.ID, userFromContext(seen).ID)
+	assert.Equal(t, admin.ID, impersonatorFromContext(seen).ID)
+	assert.Len(t, rr.Result().Cookies(), 0)
+
+	// Once it expires the admin is put back to being themselves
+	rr = serve(time.Now().Add(-time.Minute))
+	assert.Equal(t, admin.ID, userFromContext(seen).ID)
+	assert.Equal(t, "", impersonatorFromContext(seen).ID)
+	assert.Len(t, rr.Result().Cookies(), 1)
+}
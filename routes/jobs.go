@@ -0,0 +1,133 @@
+package routes
+
+import (
+	"doubleboiler/models"
+	"doubleboiler/workers/scheduler"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/jobs").
+		Methods("GET").
+		HandlerFunc(jobsHandler)
+
+	r.Path("/jobs/{name}/run").
+		Methods("POST").
+		HandlerFunc(jobRunHandler)
+}
+
+type jobSummary struct {
+	Name     string
+	Schedule string
+	LastRun  models.JobRun
+	NextRun  time.Time
+	Failures int
+}
+
+type jobsPageData struct {
+	basePageData
+	Jobs    []jobSummary
+	JobRuns models.JobRuns
+}
+
+func jobsHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	summaries := []jobSummary{}
+	for _, job := range scheduler.Jobs() {
+		recent := models.JobRuns{}
+		if err := recent.FindAll(r.Context(), models.Criteria{
+			Query: &models.All{},
+			Filters: models.Filters{&models.Custom{
+				Col:    "name",
+				Values: []string{job.Name},
+			}},
+			Pagination: models.Pagination{Limit: 20},
+		}); err != nil {
+			errRes(w, r, 500, "error fetching job runs", err)
+			return
+		}
+
+		summaries = append(summaries, jobSummary{
+			Name:     job.Name,
+			Schedule: job.Schedule,
+			LastRun:  recent.Latest()[job.Name],
+			NextRun:  job.Next(time.Now()),
+			Failures: recent.Failures()[job.Name],
+		})
+	}
+
+	customFilters := models.Filters{}
+	if r.FormValue("job-name") != "" {
+		customFilters = append(customFilters, &models.Custom{
+			Col:         "name",
+			Values:      []string{r.FormValue("job-name")},
+			CustomID:    "job-name",
+			CustomLabel: r.FormValue("job-name"),
+		})
+		r.Form.Add("custom-filter", "job-name")
+	}
+
+	jobRuns := models.JobRuns{}
+
+	criteria := models.Criteria{
+		Query: &models.All{},
+	}
+	criteria.Pagination.DefaultPageSize = 50
+	criteria.Pagination.Paginate(r.Form)
+
+	if err := criteria.Filters.FromForm(r.Form, jobRuns.AvailableFilters(), customFilters...); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+		return
+	}
+
+	if err := jobRuns.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, 500, "error fetching job runs", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "jobs.html", jobsPageData{
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Scheduled Jobs",
+			Context:   r.Context(),
+		},
+		Jobs:    summaries,
+		JobRuns: jobRuns,
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+func jobRunHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "You are not a superadmin", nil)
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	job, ok := scheduler.JobByName(vars["name"])
+	if !ok {
+		errRes(w, r, http.StatusNotFound, "No such job", nil)
+		return
+	}
+
+	// Claimed in the same minute slots the scheduler uses, so a manual run can't double up a scheduled one.
+	if err := scheduler.Fire(job, time.Now().Truncate(time.Minute)); err != nil {
+		if err == scheduler.ErrJobRunning {
+			errRes(w, r, http.StatusConflict, "That job is already running", nil)
+			return
+		}
+		errRes(w, r, 500, "error running job", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/jobs?job-name="+job.Name, r.Form), 302)
+}
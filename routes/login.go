@@ -80,6 +80,11 @@ func loginHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.Disabled {
+		errRes(w, r, http.StatusForbidden, errAccountDisabled, nil)
+		return
+	}
+
 	expiration := time.Now().Add(30 * 24 * time.Hour)
 	if user.TOTPActive {
 		expiration = time.Now().Add(10 * time.Minute)
@@ -127,6 +132,11 @@ func login2FAHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if user.Disabled {
+		errRes(w, r, http.StatusForbidden, errAccountDisabled, nil)
+		return
+	}
+
 	if !user.TOTPActive {
 		errRes(w, r, http.StatusBadRequest, "User does not have 2FA active", nil)
 		return
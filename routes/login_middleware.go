@@ -17,6 +17,9 @@ var unAuthedPaths = append([]string{
 	"health",
 	"contact",
 	"webhooks",
+	"files",
+	"logos",
+	"changelog.atom",
 }, assetPaths...)
 
 func loginMiddleware(h http.Handler) http.Handler {
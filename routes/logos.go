@@ -0,0 +1,115 @@
+package routes
+
+import (
+	"database/sql"
+	"doubleboiler/filestore"
+	"doubleboiler/models"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/organisation-settings/logo").
+		Methods("POST").
+		HandlerFunc(logoUploadHandler)
+
+	r.Path("/organisation-settings/logo/delete").
+		Methods("POST").
+		HandlerFunc(logoDeletionHandler)
+
+	r.Path("/logos/{id}").
+		Methods("GET").
+		HandlerFunc(logoHandler)
+}
+
+func logoUploadHandler(w http.ResponseWriter, r *http.Request) {
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "Only admins may change the organisation's logo", nil)
+		return
+	}
+
+	file, _, err := r.FormFile("logo")
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Please choose a logo to upload", err)
+		return
+	}
+	defer file.Close()
+
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), targetOrg.ID); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	if err := org.UploadLogo(r.Context(), filestore.Default, file); err != nil {
+		if err == models.ErrInvalidLogo {
+			errRes(w, r, http.StatusBadRequest, models.ErrInvalidLogo.Message, nil)
+			return
+		}
+		errRes(w, r, http.StatusInternalServerError, "Error uploading logo", err)
+		return
+	}
+
+	http.Redirect(w, r, "/organisation-settings", http.StatusFound)
+}
+
+func logoDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "Only admins may change the organisation's logo", nil)
+		return
+	}
+
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), targetOrg.ID); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	if err := org.RemoveLogo(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error removing logo", err)
+		return
+	}
+
+	http.Redirect(w, r, "/organisation-settings", http.StatusFound)
+}
+
+// logoHandler serves logos to anyone, since they're shown in emails as well as the app.
+func logoHandler(w http.ResponseWriter, r *http.Request) {
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		if err == sql.ErrNoRows {
+			errRes(w, r, http.StatusNotFound, "Not found", err)
+			return
+		}
+		errRes(w, r, http.StatusInternalServerError, "Error looking up organisation", err)
+		return
+	}
+
+	if !org.LogoKey.Valid {
+		errRes(w, r, http.StatusNotFound, "Not found", nil)
+		return
+	}
+
+	file, err := filestore.Default.Open(r.Context(), org.LogoKey.String)
+	if errors.Is(err, filestore.ErrNotFound) {
+		errRes(w, r, http.StatusNotFound, "Not found", err)
+		return
+	}
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error reading logo", err)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", org.LogoContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	io.Copy(w, file)
+}
@@ -34,6 +34,7 @@ func orgMiddleware(h http.Handler) http.Handler {
 				errRes(w, r, 500, "error looking up organisation users", err)
 				return
 			}
+			organisationUsers = organisationUsers.WithInherited(organisations)
 
 			if !user.SuperAdmin {
 				for _, org := range organisations.Data {
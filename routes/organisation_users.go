@@ -27,6 +27,10 @@ func init() {
 		Methods("DELETE").
 		HandlerFunc(organisationUserDeletionHandler)
 
+	r.Path("/organisation-users/{id}").
+		Methods("GET").
+		HandlerFunc(organisationUserHandler)
+
 	r.Path("/organisation-users/{id}").
 		Methods("POST").
 		HandlerFunc(organisationUserCreateOrUpdateHandler)
@@ -36,6 +40,67 @@ func init() {
 		HandlerFunc(organisationUserCreateOrUpdateHandler)
 }
 
+type organisationUserPageData struct {
+	basePageData
+	OrganisationUser models.OrganisationUser
+	Organisation     models.Organisation
+	ValidRoles       models.Roles
+	Timeline         models.Timeline
+}
+
+// canSeeMember lets admins see everyone, and team leads themselves and the members they lead.
+func canSeeMember(ctx context.Context, org models.Organisation, member models.OrganisationUser) bool {
+	if can(ctx, org, "admin") {
+		return true
+	}
+	if !can(ctx, org, "teamlead") {
+		return false
+	}
+	orgUser := orgUserFromContext(ctx, org)
+	return member.ID == orgUser.ID || util.Contains(orgUser.Roles.ByName("teamlead").Over, member.ID)
+}
+
+func organisationUserHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	orgUser := models.OrganisationUser{}
+	if err := orgUser.FindByID(r.Context(), vars["id"]); err != nil {
+		if err == sql.ErrNoRows {
+			errRes(w, r, http.StatusNotFound, "Member not found", err)
+			return
+		}
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	org := orgFromContext(r.Context(), orgUser.OrganisationID)
+
+	if !canSeeMember(r.Context(), org, orgUser) {
+		errRes(w, r, http.StatusForbidden, "You cannot view that member", nil)
+		return
+	}
+
+	timeline, err := activityTimeline(r.Context(), org, orgUser.ID)
+	if err != nil {
+		errRes(w, r, 500, "error fetching activity", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "organisation-user.html", organisationUserPageData{
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Member " + util.FirstFiveChars(orgUser.ID),
+			Context:   r.Context(),
+		},
+		OrganisationUser: orgUser,
+		Organisation:     org,
+		ValidRoles:       models.ValidRoles,
+		Timeline:         timeline,
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
 func organisationUserCreateOrUpdateHandler(w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 	required := []string{
@@ -160,7 +225,7 @@ func sendOrgAdditionEmail(ctx context.Context, user models.User, org models.Orga
 	task.Tags.Set("organisation_id", org.ID)
 	task.Tags.Set("communication_subject", fmt.Sprintf("New organisation notification"))
 
-	if err := config.QUEUE.Publish(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+	if err := models.Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
 		return err
 	}
 
@@ -192,7 +257,7 @@ func sendOrgInviteEmail(ctx context.Context, user models.User, org models.Organi
 	task.Tags.Set("organisation_id", org.ID)
 	task.Tags.Set("communication_subject", fmt.Sprintf("Organisation invitation"))
 
-	if err := config.QUEUE.Publish(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
+	if err := models.Enqueue(ctx, config.SEND_EMAIL_QUEUE_NAME, &task); err != nil {
 		return err
 	}
 
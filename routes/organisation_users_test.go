@@ -254,3 +254,36 @@ func TestOrganisationUserCreateHandlerAddNewUserByEmail(t *testing.T) {
 
 	closeTx(t, ctx)
 }
+
+func TestOrganisationUserHandler(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	member := organisationUserFixture(ctx, t)
+	org := models.Organisation{}
+	assert.Nil(t, org.FindByID(ctx, member.OrganisationID))
+
+	comment := models.Comment{}
+	comment.New(org.ID, "Member", member.ID, "", bandname())
+	assert.Nil(t, comment.Save(ctx))
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/organisation-users/" + member.ID},
+	}
+	req = mux.SetURLVars(req.WithContext(contextifyOrgAdmin(ctx, org)), map[string]string{"id": member.ID})
+
+	rr := httptest.NewRecorder()
+	organisationUserHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), comment.Body)
+
+	// Someone from another organisation can't see them
+	other := organisationFixture(ctx, t)
+	req = mux.SetURLVars(req.WithContext(contextifyOrgAdmin(ctx, other)), map[string]string{"id": member.ID})
+
+	rr = httptest.NewRecorder()
+	organisationUserHandler(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	"doubleboiler/config"
+	"doubleboiler/flashes"
 	"doubleboiler/models"
 	"doubleboiler/util"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/gorilla/mux"
@@ -36,11 +38,36 @@ func init() {
 	r.Path("/organisation-settings").
 		Methods("GET").
 		HandlerFunc(organisationSettingsHandler)
+
+	r.Path("/organisation-settings").
+		Methods("POST").
+		HandlerFunc(organisationSettingsUpdateHandler)
+
+	r.Path("/organisations/{id}/transfer-ownership").
+		Methods("POST").
+		HandlerFunc(organisationTransferOwnershipHandler)
+
+	r.Path("/organisations/{id}/delete").
+		Methods("POST").
+		HandlerFunc(organisationDeletionHandler)
+
+	r.Path("/organisations/{id}/cancel-deletion").
+		Methods("POST").
+		HandlerFunc(organisationCancelDeletionHandler)
+
+	r.Path("/organisations/{id}/data-export").
+		Methods("POST").
+		HandlerFunc(organisationDataExportHandler)
+
+	r.Path("/organisations/{id}/quotas").
+		Methods("POST").
+		HandlerFunc(organisationQuotasHandler)
 }
 
 type orgCreationPageData struct {
 	basePageData
-	User models.User
+	User          models.User
+	ParentOptions models.Organisations
 }
 
 func organisationCreationFormHandler(w http.ResponseWriter, r *http.Request) {
@@ -49,7 +76,8 @@ func organisationCreationFormHandler(w http.ResponseWriter, r *http.Request) {
 			PageTitle: "DoubleBoiler - Create Organisation",
 			Context:   r.Context(),
 		},
-		User: r.Context().Value("user").(models.User),
+		User:          r.Context().Value("user").(models.User),
+		ParentOptions: parentOptions(r.Context(), nil),
 	}); err != nil {
 		errRes(w, r, 500, "Templating error", err)
 		return
@@ -84,6 +112,11 @@ func organisationCreateOrUpdateHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if !can(r.Context(), org, "admin") {
+			errRes(w, r, http.StatusForbidden, "Only admins can change this organisation", nil)
+			return
+		}
+
 		if org.Revision != r.FormValue("revision") {
 			errRes(w, r, http.StatusBadRequest, models.ErrWrongRev.Message, nil)
 			return
@@ -98,6 +131,7 @@ func organisationCreateOrUpdateHandler(w http.ResponseWriter, r *http.Request) {
 			r.FormValue("name"),
 			r.FormValue("country"),
 		)
+		org.OwnerID = sql.NullString{Valid: true, String: user.ID}
 
 		if err := org.Save(r.Context()); err != nil {
 			errRes(w, r, 500, "A database error has occurred", err)
@@ -120,6 +154,12 @@ func organisationCreateOrUpdateHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		subscription := models.Subscription{}
+		subscription.New(org.ID, models.TrialPlan)
+		if err := subscription.Save(r.Context()); err != nil {
+			errRes(w, r, 500, "A database error has occurred", err)
+			return
+		}
 	}
 
 	lowered := strings.ToLower(org.Country)
@@ -127,9 +167,25 @@ func organisationCreateOrUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		org.Country = "Australia"
 	}
 
-	org.Toggles.FromForm(r.Form)
+	if _, ok := r.Form["parentID"]; ok && r.FormValue("parentID") != org.ParentID.String {
+		// Moving an organisation takes it out from under one set of admins and puts it under
+		// another, so both need to agree.
+		if org.ParentID.Valid && !can(r.Context(), orgFromContext(r.Context(), org.ParentID.String), "admin") {
+			errRes(w, r, http.StatusForbidden, "Only an admin of the parent organisation can move this one", nil)
+			return
+		}
+		if r.FormValue("parentID") != "" && !can(r.Context(), orgFromContext(r.Context(), r.FormValue("parentID")), "admin") {
+			errRes(w, r, http.StatusForbidden, "You are not an admin of that parent organisation", nil)
+			return
+		}
+		org.ParentID = sql.NullString{Valid: r.FormValue("parentID") != "", String: r.FormValue("parentID")}
+	}
 
 	if err := org.Save(r.Context()); err != nil {
+		if err == models.ErrOrganisationCycle {
+			errRes(w, r, http.StatusBadRequest, models.ErrOrganisationCycle.Message, nil)
+			return
+		}
 		errRes(w, r, 500, "A database error has occurred", err)
 		return
 	}
@@ -193,6 +249,14 @@ type organisationPageData struct {
 	URI               string
 	ProductName       string
 	ValidRoles        models.Roles
+	Vocabulary        models.Tags
+	CustomFields      models.CustomFields
+	FieldTypes        []string
+	ManageOwnership   bool
+	ParentOptions     models.Organisations
+	Quotas            models.Quotas
+	QuotaReports      []models.QuotaReport
+	Timeline          models.Timeline
 }
 
 func organisationHandler(w http.ResponseWriter, r *http.Request) {
@@ -217,10 +281,60 @@ func organisationHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	definitions := models.SomeThings{}
+	if err := definitions.LoadDefinitions(r.Context(), targetOrg.ID); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error looking up tags and fields", err)
+		return
+	}
+
+	subtree, err := targetOrg.SubtreeIDs(r.Context())
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error looking up sub-accounts", err)
+		return
+	}
+
+	// The current parent stays selectable even for admins who can't move things under it, or
+	// saving the settings would take the organisation out from under it.
+	parents := parentOptions(r.Context(), subtree)
+	if _, ok := parents.ByID()[targetOrg.ParentID.String]; targetOrg.ParentID.Valid && !ok {
+		parent := models.Organisation{}
+		if err := parent.FindByID(r.Context(), targetOrg.ParentID.String); err != nil {
+			errRes(w, r, http.StatusInternalServerError, "Error looking up parent organisation", err)
+			return
+		}
+		parents.Data = append(parents.Data, parent)
+	}
+
+	quotas, err := targetOrg.Quotas(r.Context())
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error calculating quotas", err)
+		return
+	}
+
+	usage, err := targetOrg.Usage(r.Context())
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error calculating usage", err)
+		return
+	}
+
+	timeline, err := activityTimeline(r.Context(), targetOrg, targetOrg.ID)
+	if err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error fetching activity", err)
+		return
+	}
+
 	if err := Tmpl.ExecuteTemplate(w, "organisation.html", organisationPageData{
 		Organisation:      targetOrg,
 		OrganisationUsers: orgUsers,
 		ValidRoles:        models.ValidRoles,
+		Vocabulary:        definitions.Vocabulary,
+		CustomFields:      definitions.CustomFields,
+		FieldTypes:        models.ValidFieldTypes,
+		ManageOwnership:   canManageOwnership(r.Context(), targetOrg),
+		ParentOptions:     parents,
+		Quotas:            quotas,
+		QuotaReports:      quotas.Report(usage),
+		Timeline:          timeline,
 		ProductName:       config.NAME,
 		basePageData: basePageData{
 			PageTitle: "DoubleBoiler - Organisation " + util.FirstFiveChars(targetOrg.ID),
@@ -233,10 +347,287 @@ func organisationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type organisationSettingsPageData struct {
+	basePageData
+	Organisation models.Organisation
+	Categories   []string
+}
+
 func organisationSettingsHandler(w http.ResponseWriter, r *http.Request) {
 	targetOrg := activeOrgFromContext(r.Context())
-	http.Redirect(w, r, "/organisations/"+targetOrg.ID, 302)
-	return
+	if targetOrg.ID == "" {
+		redirToDefaultOrg(w, r)
+		return
+	}
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "Only admins may view organisation settings", nil)
+		return
+	}
+
+	if err := targetOrg.FindByID(r.Context(), targetOrg.ID); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error looking up organisation", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "organisation-settings.html", organisationSettingsPageData{
+		Organisation: targetOrg,
+		Categories:   models.SettingCategories,
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Organisation Settings",
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+// organisationSettingsUpdateHandler changes the toggles and settings of the active organisation.
+// Settings left out of the form are left alone, and each one needs its own role to change.
+func organisationSettingsUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "Only admins may change organisation settings", nil)
+		return
+	}
+
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), targetOrg.ID); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	if org.Revision != r.FormValue("revision") {
+		errRes(w, r, http.StatusBadRequest, models.ErrWrongRev.Message, nil)
+		return
+	}
+
+	org.Toggles.FromForm(r.Form)
+
+	for _, setting := range org.Settings {
+		if _, ok := r.Form[setting.Key]; !ok {
+			continue
+		}
+		if !can(r.Context(), org, setting.Role) {
+			errRes(w, r, http.StatusForbidden, "You don't have permission to change "+setting.Label, nil)
+			return
+		}
+		if err := org.Settings.Set(setting.Key, r.FormValue(setting.Key)); err != nil {
+			errRes(w, r, http.StatusBadRequest, "Invalid setting", err)
+			return
+		}
+	}
+
+	if err := org.Save(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "A database error has occurred", err)
+		return
+	}
+
+	http.Redirect(w, r, "/organisation-settings", http.StatusFound)
+}
+
+func organisationTransferOwnershipHandler(w http.ResponseWriter, r *http.Request) {
+	if ok := checkFormInput([]string{"organisationUserID"}, r.Form, w, r); !ok {
+		return
+	}
+
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	if !canManageOwnership(r.Context(), org) {
+		errRes(w, r, http.StatusForbidden, "Only the owner can transfer ownership of the organisation", nil)
+		return
+	}
+
+	ou := models.OrganisationUser{}
+	if err := ou.FindByID(r.Context(), r.FormValue("organisationUserID")); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation user not found", err)
+		return
+	}
+
+	if ou.OrganisationID != org.ID {
+		errRes(w, r, http.StatusBadRequest, models.ErrTransferToNonMember.Message, nil)
+		return
+	}
+
+	if err := org.TransferOwnership(r.Context(), ou); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error transferring ownership", err)
+		return
+	}
+
+	http.Redirect(w, r, "/organisations/"+org.ID, http.StatusFound)
+}
+
+func organisationDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	if !canManageOwnership(r.Context(), org) {
+		errRes(w, r, http.StatusForbidden, "Only the owner can delete the organisation", nil)
+		return
+	}
+
+	if org.DeletionRequestedAt.Valid {
+		http.Redirect(w, r, "/organisations/"+org.ID, http.StatusFound)
+		return
+	}
+
+	if strings.TrimSpace(r.FormValue("confirmation")) != org.Name {
+		errRes(w, r, http.StatusBadRequest, models.ErrDeletionNotConfirmed.Message, nil)
+		return
+	}
+
+	user := userFromContext(r.Context())
+
+	if err := org.RequestDeletion(r.Context(), user, r.FormValue("confirmation")); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error requesting deletion", err)
+		return
+	}
+
+	if ctx, err := user.PersistFlash(r.Context(), flashes.Flash{
+		Persistent: true,
+		Type:       flashes.Success,
+		Text:       org.Name + " will be deleted on " + org.DeletionDue().Format("2 January 2006") + ". Any admin can cancel until then.",
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error adding flash message", err)
+		return
+	} else {
+		r = r.WithContext(ctx)
+	}
+
+	http.Redirect(w, r, "/organisations/"+org.ID, http.StatusFound)
+}
+
+func organisationCancelDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You are not an admin of that organisation", nil)
+		return
+	}
+
+	if err := org.CancelDeletion(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error cancelling deletion", err)
+		return
+	}
+
+	http.Redirect(w, r, "/organisations/"+org.ID, http.StatusFound)
+}
+
+func organisationDataExportHandler(w http.ResponseWriter, r *http.Request) {
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You are not an admin of that organisation", nil)
+		return
+	}
+
+	user := userFromContext(r.Context())
+
+	export := models.DataExport{}
+	export.NewForOrganisation(user.ID, org)
+	if err := export.Request(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error requesting data export", err)
+		return
+	}
+
+	if ctx, err := user.PersistFlash(r.Context(), flashes.Flash{
+		Type: flashes.Success,
+		Text: "We're putting together a copy of everything in " + org.Name + ". We'll email you a link to download it when it's ready.",
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error adding flash message", err)
+		return
+	} else {
+		r = r.WithContext(ctx)
+	}
+
+	http.Redirect(w, r, "/organisations/"+org.ID, http.StatusFound)
+}
+
+func organisationQuotasHandler(w http.ResponseWriter, r *http.Request) {
+	if !isAppAdmin(r.Context()) {
+		errRes(w, r, http.StatusForbidden, "Only application admins may override quotas", nil)
+		return
+	}
+
+	org := models.Organisation{}
+	if err := org.FindByID(r.Context(), mux.Vars(r)["id"]); err != nil {
+		errRes(w, r, http.StatusNotFound, "Organisation not found", err)
+		return
+	}
+
+	// A blank field clears the override, leaving the plan's quota in place.
+	quota := func(name string) (int64, error) {
+		if r.FormValue(name) == "" {
+			return 0, nil
+		}
+		val, err := strconv.ParseInt(r.FormValue(name), 10, 64)
+		if err != nil || val < 0 {
+			return 0, models.ClientSafeError{Message: "Quotas must be whole numbers, or blank to use the plan's"}
+		}
+		return val, nil
+	}
+
+	members, err := quota("memberQuota")
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Invalid member quota", err)
+		return
+	}
+	someThings, err := quota("someThingQuota")
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Invalid SomeThing quota", err)
+		return
+	}
+	emails, err := quota("emailQuota")
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Invalid email quota", err)
+		return
+	}
+	storage, err := quota("attachmentQuota")
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Invalid storage quota", err)
+		return
+	}
+
+	org.MemberQuota = int(members)
+	org.SomeThingQuota = int(someThings)
+	org.EmailQuota = int(emails)
+	org.AttachmentQuota = storage
+
+	if err := org.Save(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error saving quotas", err)
+		return
+	}
+
+	http.Redirect(w, r, "/organisations/"+org.ID, http.StatusFound)
+}
+
+// parentOptions are the organisations the user could put an organisation underneath: those they're
+// an admin of, leaving out the organisation's own subtree.
+func parentOptions(ctx context.Context, exclude []string) models.Organisations {
+	ret := models.Organisations{}
+	for _, org := range orgsFromContext(ctx).Data {
+		if can(ctx, org, "admin") && !util.Contains(exclude, org.ID) {
+			ret.Data = append(ret.Data, org)
+		}
+	}
+	return ret
 }
 
 func copySampleOrgData(ctx context.Context, target models.Organisation) error {
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"context"
+	"database/sql"
 	"doubleboiler/config"
 	"doubleboiler/models"
 	"net/http"
@@ -78,20 +79,61 @@ func TestOrganisationCreateOrUpdateHandlerUpdate(t *testing.T) {
 
 	ctx = context.WithValue(ctx, "user", u)
 
-	req = req.WithContext(ctx)
-
 	r := mux.NewRouter()
 
 	r.HandleFunc("/organisations/{id}", organisationCreateOrUpdateHandler).Methods("POST")
 
 	rr := httptest.NewRecorder()
-	r.ServeHTTP(rr, req)
+	r.ServeHTTP(rr, req.WithContext(ctx))
+
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	rr = httptest.NewRecorder()
+	r.ServeHTTP(rr, req.WithContext(ctx))
 
 	assert.Equal(t, http.StatusFound, rr.Code)
 
 	closeTx(t, ctx)
 }
 
+func TestOrganisationAdoptionRequiresAdmin(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	user, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", user)
+
+	parent := organisationFixture(ctx, t)
+	other := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, parent)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/organisations/" + other.ID},
+		Form: url.Values{
+			"name":     {other.Name},
+			"country":  {other.Country},
+			"id":       {other.ID},
+			"revision": {other.Revision},
+			"parentID": {parent.ID},
+		},
+	}
+
+	r := mux.NewRouter()
+	r.HandleFunc("/organisations/{id}", organisationCreateOrUpdateHandler).Methods("POST")
+
+	rr := httptest.NewRecorder()
+	r.ServeHTTP(rr, req.WithContext(ctx))
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	found := models.Organisation{}
+	assert.Nil(t, found.FindByID(ctx, other.ID))
+	assert.False(t, found.ParentID.Valid)
+}
+
 func TestOrganisationHandler(t *testing.T) {
 	t.Parallel()
 	ctx := getCtx(t)
@@ -208,3 +250,160 @@ func organisationFixture(ctx context.Context, t *testing.T) (i models.Organisati
 	assert.Nil(t, err)
 	return
 }
+
+func TestOrganisationOwnershipHandlers(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	owner, _ := userFixture(ctx, t)
+	admin, _ := userFixture(ctx, t)
+
+	org := models.Organisation{}
+	org.New(bandname(), "Australia")
+	org.OwnerID = sql.NullString{Valid: true, String: owner.ID}
+	assert.Nil(t, org.Save(ctx))
+
+	adminOU := models.OrganisationUser{}
+	adminOU.New(admin.ID, org.ID, models.Roles{models.Role{Name: "admin"}})
+	assert.Nil(t, adminOU.Save(ctx))
+
+	serve := func(user models.User, handler http.HandlerFunc, form url.Values) int {
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/organisations/" + org.ID},
+			Form:   form,
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": org.ID})
+		req = req.WithContext(contextifyOrgAdmin(context.WithValue(ctx, "user", user), org))
+
+		rr := httptest.NewRecorder()
+		handler(rr, req)
+		return rr.Code
+	}
+
+	// Being an admin isn't enough once someone owns the organisation
+	assert.Equal(t, http.StatusForbidden, serve(admin, organisationDeletionHandler, url.Values{"confirmation": {org.Name}}))
+	assert.Equal(t, http.StatusForbidden, serve(admin, organisationTransferOwnershipHandler, url.Values{"organisationUserID": {adminOU.ID}}))
+
+	assert.Equal(t, http.StatusBadRequest, serve(owner, organisationDeletionHandler, url.Values{"confirmation": {"nope"}}))
+	assert.Equal(t, http.StatusFound, serve(owner, organisationDeletionHandler, url.Values{"confirmation": {org.Name}}))
+
+	found := models.Organisation{}
+	assert.Nil(t, found.FindByID(ctx, org.ID))
+	assert.True(t, found.DeletionRequestedAt.Valid)
+
+	assert.Equal(t, http.StatusFound, serve(admin, organisationCancelDeletionHandler, url.Values{}))
+	assert.Nil(t, found.FindByID(ctx, org.ID))
+	assert.False(t, found.DeletionRequestedAt.Valid)
+
+	assert.Equal(t, http.StatusFound, serve(owner, organisationTransferOwnershipHandler, url.Values{"organisationUserID": {adminOU.ID}}))
+	assert.Nil(t, found.FindByID(ctx, org.ID))
+	assert.True(t, found.IsOwner(admin.ID))
+}
+
+func TestOrganisationQuotasHandler(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture(ctx, t)
+	admin, _ := userFixture(ctx, t)
+	superadmin, _ := userFixture(ctx, t)
+	superadmin.SuperAdmin = true
+
+	serve := func(user models.User, form url.Values) int {
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/organisations/" + org.ID + "/quotas"},
+			Form:   form,
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": org.ID})
+		req = req.WithContext(contextifyOrgAdmin(context.WithValue(ctx, "user", user), org))
+
+		rr := httptest.NewRecorder()
+		organisationQuotasHandler(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, serve(admin, url.Values{"memberQuota": {"5"}}))
+	assert.Equal(t, http.StatusBadRequest, serve(superadmin, url.Values{"memberQuota": {"lots"}}))
+	assert.Equal(t, http.StatusFound, serve(superadmin, url.Values{"memberQuota": {"5"}, "emailQuota": {"20"}, "someThingQuota": {""}}))
+
+	found := models.Organisation{}
+	assert.Nil(t, found.FindByID(ctx, org.ID))
+	assert.Equal(t, 5, found.MemberQuota)
+	assert.Equal(t, 20, found.EmailQuota)
+	assert.Equal(t, 0, found.SomeThingQuota)
+
+	quotas, err := found.Quotas(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, quotas.Members)
+	assert.Equal(t, config.SOME_THING_QUOTA, quotas.SomeThings)
+}
+
+func TestOrganisationSettingsUpdateHandler(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture(ctx, t)
+	admin, _ := userFixture(ctx, t)
+	superadmin, _ := userFixture(ctx, t)
+	superadmin.SuperAdmin = true
+
+	serve := func(user models.User, form url.Values) int {
+		current := models.Organisation{}
+		assert.Nil(t, current.FindByID(ctx, org.ID))
+		form.Set("revision", current.Revision)
+
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/organisation-settings"},
+			Form:   form,
+		}
+		req = req.WithContext(contextifyOrgAdmin(context.WithValue(ctx, "user", user), org))
+
+		rr := httptest.NewRecorder()
+		organisationSettingsUpdateHandler(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusFound, serve(admin, url.Values{
+		models.EmailSenderName.Key:          {"Acme Support"},
+		models.DeletionNoticeRecipients.Key: {"Legal@example.com"},
+		models.RequireAdmin2FA.Key:          {"true"},
+	}))
+
+	found := models.Organisation{}
+	assert.Nil(t, found.FindByID(ctx, org.ID))
+	assert.True(t, found.Toggles.ByKey(models.RequireAdmin2FA.Key).State)
+
+	assert.Equal(t, http.StatusBadRequest, serve(admin, url.Values{models.BroadcastReplyTo.Key: {"nobody"}}))
+	assert.Equal(t, http.StatusForbidden, serve(admin, url.Values{models.CommunicationRetentionDays.Key: {"30"}}))
+	assert.Equal(t, http.StatusFound, serve(superadmin, url.Values{models.CommunicationRetentionDays.Key: {"30"}}))
+
+	assert.Nil(t, found.FindByID(ctx, org.ID))
+	assert.Equal(t, "Acme Support", found.Settings.ByKey(models.EmailSenderName.Key).Current())
+	assert.Equal(t, []string{"legal@example.com"}, found.Settings.ByKey(models.DeletionNoticeRecipients.Key).Emails())
+	assert.Equal(t, "sender", found.Settings.ByKey(models.BroadcastReplyTo.Key).Current())
+	assert.Equal(t, 30, found.Settings.ByKey(models.CommunicationRetentionDays.Key).Int())
+}
+
+func TestLogoHandlerWithoutLogo(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture(ctx, t)
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/logos/" + org.ID},
+	}
+	req = mux.SetURLVars(req.WithContext(ctx), map[string]string{"id": org.ID})
+
+	rr := httptest.NewRecorder()
+	logoHandler(rr, req)
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
@@ -1,16 +1,9 @@
 package routes
 
 import (
-	"doubleboiler/config"
-	"doubleboiler/copy"
 	"doubleboiler/models"
-	"doubleboiler/util"
-	"fmt"
 	"net/http"
-	"net/url"
 	"strings"
-
-	"github.com/davidbanham/notifications"
 )
 
 func init() {
@@ -32,20 +25,7 @@ func passwordResetHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token := util.CalcToken(config.SECRET, 1, user.Email)
-	escaped := url.QueryEscape(token.String())
-	resetUrl := fmt.Sprintf("%s/reset-password?expiry=%s&uid=%s&token=%s", config.URI, token.ExpiryString(), user.ID, escaped)
-
-	emailHTML, emailText := copy.PasswordResetEmail(resetUrl)
-
-	if err := notifications.SendEmail(notifications.Email{
-		To:      user.Email,
-		From:    config.SYSTEM_EMAIL,
-		ReplyTo: config.SUPPORT_EMAIL,
-		Text:    emailText,
-		HTML:    emailHTML,
-		Subject: fmt.Sprintf("Password reset for your %s account", config.NAME),
-	}); err != nil {
+	if err := user.SendPasswordResetEmail(r.Context()); err != nil {
 		errRes(w, r, 500, "Error sending email", err)
 		return
 	}
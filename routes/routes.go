@@ -5,10 +5,13 @@ import (
 	"doubleboiler/config"
 	"doubleboiler/logger"
 	"doubleboiler/models"
+	"doubleboiler/util"
 	"doubleboiler/views"
 	"log"
 	"net/http"
+	"net/url"
 	"os"
+	"time"
 
 	_ "time/tzdata"
 
@@ -31,7 +34,9 @@ func Init() (h http.Handler) {
 
 	h = csrfMiddleware(r)
 	h = formParsingMiddleware(h)
+	h = featureFlagMiddleware(h)
 	h = orgMiddleware(h)
+	h = changelogMiddleware(h)
 	h = pathMiddleware(h)
 	h = loginMiddleware(h)
 	h = userMiddleware(h)
@@ -92,20 +97,68 @@ func (pd basePageData) Title() string {
 type dashboardPageData struct {
 	basePageData
 	Organisations models.Organisations
+	Stats         models.OrganisationStats
 }
 
 func serveDashboard(w http.ResponseWriter, r *http.Request) {
-	if err := Tmpl.ExecuteTemplate(w, "dashboard.html", dashboardPageData{
+	data := dashboardPageData{
 		basePageData: basePageData{
 			Context:   r.Context(),
 			PageTitle: "DoubleBoiler - Dashboard",
 		},
-	}); err != nil {
+	}
+
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if targetOrg.ID != "" && can(r.Context(), targetOrg, "admin") {
+		period, err := dashboardPeriod(r.Form)
+		if err != nil {
+			errRes(w, r, http.StatusBadRequest, "Invalid date range", err)
+			return
+		}
+
+		if err := data.Stats.Find(r.Context(), targetOrg.ID, period, config.SEND_EMAIL_QUEUE_NAME); err != nil {
+			errRes(w, r, http.StatusInternalServerError, "Error gathering statistics", err)
+			return
+		}
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "dashboard.html", data); err != nil {
 		errRes(w, r, 500, "Problem with template", err)
 		return
 	}
 }
 
+// dashboardPeriod is the date range picked on the dashboard, the last 30 days by default.
+func dashboardPeriod(form url.Values) (util.Period, error) {
+	today := time.Now().Truncate(24 * time.Hour)
+	period := util.Period{
+		Start: today.Add(-29 * 24 * time.Hour),
+		End:   today,
+	}
+
+	if form.Get("start") == "" || form.Get("end") == "" {
+		return period, nil
+	}
+
+	start, err := time.Parse("2006-01-02", form.Get("start"))
+	if err != nil {
+		return period, err
+	}
+	end, err := time.Parse("2006-01-02", form.Get("end"))
+	if err != nil {
+		return period, err
+	}
+	if end.Before(start) {
+		return period, models.ClientSafeError{Message: "The end of the range must be after the start"}
+	}
+	if end.Sub(start) > 366*24*time.Hour {
+		return period, models.ClientSafeError{Message: "The range can be at most a year"}
+	}
+
+	return util.Period{Start: start, End: end}, nil
+}
+
 var upgrader = websocket.Upgrader{}
 
 func serveChangeWatcher(w http.ResponseWriter, r *http.Request) {
@@ -7,6 +7,9 @@ import (
 	"doubleboiler/models"
 	"doubleboiler/views"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	bn "github.com/davidbanham/bandname_go"
@@ -72,3 +75,34 @@ func TestTmplParse(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Nil(t, plate.FillCache())
 }
+
+func TestDashboardStats(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	someThingFixture(ctx, t, org)
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/dashboard"},
+		Form:   url.Values{},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	serveDashboard(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "SomeThings created per day")
+
+	req.Form = url.Values{"start": {"2026-01-10"}, "end": {"2026-01-01"}}
+	rr = httptest.NewRecorder()
+	serveDashboard(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	closeTx(t, ctx)
+}
@@ -0,0 +1,142 @@
+package routes
+
+import (
+	"doubleboiler/models"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/saved-views").
+		Methods("POST").
+		HandlerFunc(savedViewCreateHandler)
+
+	r.Path("/saved-views/{id}/delete").
+		Methods("POST").
+		HandlerFunc(savedViewDeletionHandler)
+}
+
+type savedViewsData struct {
+	List           string
+	OrganisationID string
+	Views          models.SavedViews
+	Active         models.SavedView
+	Query          string
+}
+
+// applySavedView loads the views available on a list page. When one has just been picked, or the list
+// was loaded without any parameters and there's a default, it redirects to the list with that view's
+// parameters and reports that the response has been written.
+func applySavedView(w http.ResponseWriter, r *http.Request, list string, org models.Organisation) (savedViewsData, bool) {
+	query := r.URL.Query()
+
+	data := savedViewsData{
+		List:           list,
+		OrganisationID: org.ID,
+		Query:          query.Encode(),
+	}
+
+	criteria := models.Criteria{}
+	models.AddCustomQuery(models.SavedViewsFor{
+		UserID:         userFromContext(r.Context()).ID,
+		OrganisationID: org.ID,
+		List:           list,
+	}, &criteria)
+
+	if err := data.Views.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error looking up saved views", err)
+		return data, true
+	}
+
+	if len(query) == 0 {
+		if view, ok := data.Views.Default(userFromContext(r.Context()).ID); ok {
+			http.Redirect(w, r, view.Path(), http.StatusFound)
+			return data, true
+		}
+		return data, false
+	}
+
+	view, ok := data.Views.ByID()[query.Get("view")]
+	if !ok {
+		return data, false
+	}
+
+	// A view without filters is already all there, and redirecting to it would go round in circles.
+	if len(query) == 1 && view.Path() != r.URL.RequestURI() {
+		http.Redirect(w, r, view.Path(), http.StatusFound)
+		return data, true
+	}
+
+	data.Active = view
+
+	return data, false
+}
+
+func savedViewCreateHandler(w http.ResponseWriter, r *http.Request) {
+	required := []string{
+		"name",
+		"list",
+	}
+	if okay := checkFormInput(required, r.Form, w, r); !okay {
+		return
+	}
+
+	org := models.Organisation{}
+	if r.FormValue("organisationID") != "" {
+		org = orgFromContext(r.Context(), r.FormValue("organisationID"))
+		if org.ID == "" {
+			errRes(w, r, http.StatusForbidden, "You are not a member of that organisation", nil)
+			return
+		}
+	}
+
+	values, err := url.ParseQuery(r.FormValue("query"))
+	if err != nil {
+		errRes(w, r, http.StatusBadRequest, "Error reading the list's filters", err)
+		return
+	}
+
+	view := models.SavedView{}
+	view.New(userFromContext(r.Context()).ID, org.ID, r.FormValue("list"), r.FormValue("name"), values)
+	view.Shared = r.FormValue("shared") == "true"
+	view.IsDefault = r.FormValue("default") == "true"
+
+	if view.Shared && !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "Only admins can share views with the organisation", nil)
+		return
+	}
+
+	if err := view.Save(r.Context()); err != nil {
+		errRes(w, r, errCode(err), "Error saving view", err)
+		return
+	}
+
+	http.Redirect(w, r, view.Path(), 302)
+}
+
+func savedViewDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	view := models.SavedView{}
+	if err := view.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	mine := view.UserID == userFromContext(r.Context()).ID
+	sharedAndAdmin := view.Shared && can(r.Context(), orgFromContext(r.Context(), view.OrganisationID.String), "admin")
+
+	if !mine && !sharedAndAdmin {
+		errRes(w, r, http.StatusForbidden, "You can only remove your own views", nil)
+		return
+	}
+
+	if err := view.Delete(r.Context()); err != nil {
+		errRes(w, r, 500, "Error removing view", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/"+view.List+"?view=none", r.Form), 302)
+}
@@ -0,0 +1,157 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSavedViewDefaultApplies(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+	user, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", user)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/saved-views"},
+		Form: url.Values{
+			"name":           {"Deleted"},
+			"list":           {"some-things"},
+			"organisationID": {org.ID},
+			"query":          {"filter=is-deleted&limit=10&skip=20"},
+			"default":        {"true"},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	savedViewCreateHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+
+	viewPath := rr.Header().Get("Location")
+	assert.Contains(t, viewPath, "filter=is-deleted")
+	assert.NotContains(t, viewPath, "skip")
+
+	req, err := http.NewRequest("GET", "/some-things", nil)
+	assert.Nil(t, err)
+	req = req.WithContext(ctx)
+
+	rr = httptest.NewRecorder()
+	someThingsHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, viewPath, rr.Header().Get("Location"))
+
+	req, err = http.NewRequest("GET", "/some-things?view=none", nil)
+	assert.Nil(t, err)
+	req = req.WithContext(ctx)
+
+	rr = httptest.NewRecorder()
+	someThingsHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	closeTx(t, ctx)
+}
+
+func TestSavedViewSharingRequiresAdmin(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	user, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", user)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/saved-views"},
+		Form: url.Values{
+			"name":   {"Everyone's"},
+			"list":   {"some-things"},
+			"shared": {"true"},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	savedViewCreateHandler(rr, req)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+
+	closeTx(t, ctx)
+}
+
+func TestSavedViewCreateForUnknownList(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	user, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", user)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/saved-views"},
+		Form: url.Values{
+			"name": {"Mine"},
+			"list": {"nonsense"},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	savedViewCreateHandler(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+
+	closeTx(t, ctx)
+}
+
+func TestSavedViewWithoutFilters(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+	user, _ := userFixture(ctx, t)
+	ctx = context.WithValue(ctx, "user", user)
+
+	req := &http.Request{
+		Method: "POST",
+		URL:    &url.URL{Path: "/saved-views"},
+		Form: url.Values{
+			"name":           {"Everything"},
+			"list":           {"some-things"},
+			"organisationID": {org.ID},
+			"query":          {""},
+			"default":        {"true"},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	savedViewCreateHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+	viewPath := rr.Header().Get("Location")
+
+	req, err := http.NewRequest("GET", "/some-things", nil)
+	assert.Nil(t, err)
+	req = req.WithContext(ctx)
+
+	rr = httptest.NewRecorder()
+	someThingsHandler(rr, req)
+	assert.Equal(t, http.StatusFound, rr.Code)
+	assert.Equal(t, viewPath, rr.Header().Get("Location"))
+
+	req, err = http.NewRequest("GET", viewPath, nil)
+	assert.Nil(t, err)
+	req = req.WithContext(ctx)
+
+	rr = httptest.NewRecorder()
+	someThingsHandler(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	closeTx(t, ctx)
+}
@@ -1,7 +1,9 @@
 package routes
 
 import (
+	"context"
 	"doubleboiler/models"
+	"encoding/json"
 	"net/http"
 )
 
@@ -11,6 +13,10 @@ func init() {
 	r.Path("/search").
 		Methods("GET").
 		HandlerFunc(searchHandler)
+
+	r.Path("/search/typeahead").
+		Methods("GET").
+		HandlerFunc(typeaheadHandler)
 }
 
 type searchResultPageData struct {
@@ -28,18 +34,42 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if !can(r.Context(), targetOrg, "admin") {
+	if !can(r.Context(), targetOrg, "teamlead") {
 		errRes(w, r, http.StatusForbidden, "You cannot search for that organisation", nil)
 		return
 	}
 
 	results := models.SearchResults{}
 
-	query := &models.ByPhrase{
-		OrganisationID: targetOrg.ID,
-		Phrase:         r.FormValue("search_field"),
+	criteria := searchCriteria(r, targetOrg, r.FormValue("search_field"))
+	criteria.Pagination.DefaultPageSize = 50
+	criteria.Pagination.Paginate(r.Form)
+
+	roles := orgUserFromContext(r.Context(), targetOrg).Roles
+
+	if err := results.FindAll(r.Context(), roles, criteria, models.SearchTargets); err != nil {
+		errRes(w, r, 500, "error fetching results", err)
+		return
+	}
+
+	if err := results.Count(r.Context(), roles, criteria, models.SearchTargets); err != nil {
+		errRes(w, r, 500, "error counting results", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "searchresults.html", searchResultPageData{
+		Results: results,
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - Search Results",
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
 	}
+}
 
+func searchCriteria(r *http.Request, org models.Organisation, phrase string) models.SearchCriteria {
 	entities := []string{}
 	targets := models.SearchTargets
 	if len(r.Form["entity-filter"]) != 0 {
@@ -49,30 +79,74 @@ func searchHandler(w http.ResponseWriter, r *http.Request) {
 		entities = append(entities, target.Tablename)
 	}
 
-	criteria := models.SearchCriteria{
+	query := &models.ByPrefix{
+		OrganisationID: org.ID,
+		Phrase:         phrase,
+		Restrictions:   map[string]models.Filter{},
+	}
+
+	if !can(r.Context(), org, "admin") {
+		query.Restrictions["organisations_users"] = ledMembers(r.Context(), org)
+	}
+
+	return models.SearchCriteria{
 		Entities: entities,
 		Query:    query,
-		Pagination: models.Pagination{
-			DefaultPageSize: 50,
-		},
 	}
+}
 
-	criteria.Pagination.Paginate(r.Form)
-	roles := orgUserFromContext(r.Context(), targetOrg).Roles
+// ledMembers limits a team lead to themselves and the members their role is over.
+func ledMembers(ctx context.Context, org models.Organisation) models.Filter {
+	orgUser := orgUserFromContext(ctx, org)
+	return &models.Custom{
+		Col:    "organisations_users.id",
+		Values: append([]string{orgUser.ID}, orgUser.Roles.ByName("teamlead").Over...),
+	}
+}
 
-	if err := results.FindAll(r.Context(), roles, criteria, models.SearchTargets); err != nil {
+type typeaheadResult struct {
+	EntityType string `json:"entity_type"`
+	Label      string `json:"label"`
+	Headline   string `json:"headline"`
+	Path       string `json:"path"`
+}
+
+// typeaheadHandler backs the quick search box in the header with the best few matches as JSON.
+func typeaheadHandler(w http.ResponseWriter, r *http.Request) {
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if !can(r.Context(), targetOrg, "teamlead") {
+		errRes(w, r, http.StatusForbidden, "You cannot search for that organisation", nil)
+		return
+	}
+
+	results := models.SearchResults{}
+
+	criteria := searchCriteria(r, targetOrg, r.FormValue("q"))
+	criteria.Pagination.Limit = 8
+
+	if err := results.FindAll(r.Context(), orgUserFromContext(r.Context(), targetOrg).Roles, criteria, models.SearchTargets); err != nil {
 		errRes(w, r, 500, "error fetching results", err)
 		return
 	}
 
-	if err := Tmpl.ExecuteTemplate(w, "searchresults.html", searchResultPageData{
-		Results: results,
-		basePageData: basePageData{
-			PageTitle: "DoubleBoiler - Search Results",
-			Context:   r.Context(),
-		},
-	}); err != nil {
-		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+	ret := []typeaheadResult{}
+	for _, result := range results.Data {
+		path := ""
+		if result.Path != "" {
+			path = "/" + result.Path + "/" + result.ID
+		}
+		ret = append(ret, typeaheadResult{
+			EntityType: result.EntityType,
+			Label:      result.Label,
+			Headline:   string(result.Highlighted()),
+			Path:       path,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(ret); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "error encoding results", err)
 		return
 	}
 }
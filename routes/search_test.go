@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"context"
+	"doubleboiler/models"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypeaheadHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	fixture := someThingFixture(ctx, t, org)
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/search/typeahead"},
+		Form: url.Values{
+			"q":              {fixture.Name[:4]},
+			"organisationid": {org.ID},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	typeaheadHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), "application/json"))
+
+	results := []typeaheadResult{}
+	assert.Nil(t, json.Unmarshal(rr.Body.Bytes(), &results))
+
+	found := false
+	for _, result := range results {
+		if result.Path == fmt.Sprintf("/some-things/%s", fixture.ID) {
+			found = true
+			assert.Contains(t, result.Headline, "<mark>")
+		}
+	}
+	assert.True(t, found)
+
+	closeTx(t, ctx)
+}
+
+func TestSearchTeamleadSeesLedMembers(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+
+	member := func(roles models.Roles) models.OrganisationUser {
+		user := models.User{}
+		user.New(bandEmail(), bandname())
+		assert.Nil(t, user.Save(ctx))
+
+		orgUser := models.OrganisationUser{}
+		orgUser.New(user.ID, org.ID, roles)
+		orgUser.FamilyName = "Zanzibarian"
+		assert.Nil(t, orgUser.Save(ctx))
+		return orgUser
+	}
+
+	led := member(models.Roles{})
+	other := member(models.Roles{})
+	lead := member(models.Roles{{Name: "teamlead", Over: []string{led.ID}}})
+
+	ctx = context.WithValue(ctx, "organisation_users", models.OrganisationUsers{Data: []models.OrganisationUser{lead}})
+	ctx = context.WithValue(ctx, "organisations", models.Organisations{Data: []models.Organisation{org}})
+	ctx = context.WithValue(ctx, "target_org", org.ID)
+
+	req := &http.Request{
+		Method: "GET",
+		URL:    &url.URL{Path: "/search"},
+		Form: url.Values{
+			"search_field":   {"zanzib"},
+			"organisationid": {org.ID},
+		},
+	}
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	searchHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "/organisation-users/"+lead.ID)
+	assert.Contains(t, rr.Body.String(), "/organisation-users/"+led.ID)
+	assert.NotContains(t, rr.Body.String(), "/organisation-users/"+other.ID)
+
+	closeTx(t, ctx)
+}
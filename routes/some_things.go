@@ -4,6 +4,7 @@ import (
 	"doubleboiler/models"
 	"doubleboiler/util"
 	"net/http"
+	"net/url"
 
 	"github.com/gorilla/mux"
 )
@@ -21,6 +22,10 @@ func init() {
 		Methods("GET").
 		HandlerFunc(someThingCreationFormHandler)
 
+	r.Path("/some-things/rollup").
+		Methods("GET").
+		HandlerFunc(someThingsRollupHandler)
+
 	r.Path("/some-things").
 		Methods("GET").
 		HandlerFunc(someThingsHandler)
@@ -40,6 +45,9 @@ func init() {
 
 type someThingCreationPageData struct {
 	basePageData
+	SomeThing    models.SomeThing
+	Vocabulary   models.Tags
+	CustomFields models.CustomFields
 }
 
 func someThingCreationFormHandler(w http.ResponseWriter, r *http.Request) {
@@ -54,11 +62,19 @@ func someThingCreationFormHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	definitions := models.SomeThings{}
+	if err := definitions.LoadDefinitions(r.Context(), targetOrg.ID); err != nil {
+		errRes(w, r, 500, "error fetching tags and fields", err)
+		return
+	}
+
 	if err := Tmpl.ExecuteTemplate(w, "create-some-thing.html", someThingCreationPageData{
 		basePageData: basePageData{
 			PageTitle: "DoubleBoiler - Create SomeThing",
 			Context:   r.Context(),
 		},
+		Vocabulary:   definitions.Vocabulary,
+		CustomFields: definitions.CustomFields,
 	}); err != nil {
 		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
 		return
@@ -105,17 +121,75 @@ func someThingCreateOrUpdateHandler(w http.ResponseWriter, r *http.Request) {
 		)
 	}
 
+	fields := models.CustomFields{}
+	if err := fields.FindAll(r.Context(), models.Criteria{Query: &models.ByOrg{ID: org.ID}}); err != nil {
+		errRes(w, r, 500, "error fetching custom fields", err)
+		return
+	}
+
+	if err := customDataFromForm(&someThing, fields, r.Form); err != nil {
+		errRes(w, r, http.StatusBadRequest, "Error reading custom fields", err)
+		return
+	}
+
 	if err := someThing.Save(r.Context()); err != nil {
-		errRes(w, r, 500, "A database error has occurred", err)
+		errRes(w, r, errCode(err), "Error saving someThing", err)
 		return
 	}
 
 	http.Redirect(w, r, nextFlow("/some-things/"+someThing.ID, r.Form), 302)
 }
 
+// customDataFromForm reads the tag checkboxes and field.<key> inputs onto the SomeThing.
+// Only what the form sends is changed, so tags and fields it leaves out keep their stored
+// values. Checkboxes come after a blank hidden input of the same name so an unticked box
+// still shows up. Blank inputs clear the value, apart from booleans where blank means false.
+func customDataFromForm(someThing *models.SomeThing, fields models.CustomFields, form url.Values) error {
+	if inputs, ok := form["tags"]; ok {
+		tags := models.TagList{}
+		for _, tag := range inputs {
+			if tag != "" {
+				tags = append(tags, tag)
+			}
+		}
+		someThing.Tags = tags
+	}
+
+	values := models.CustomFieldValues{}
+	for key, value := range someThing.CustomFields {
+		values[key] = value
+	}
+	for _, field := range fields.Data {
+		inputs, ok := form["field."+field.Key]
+		if !ok {
+			continue
+		}
+		// A ticked box is sent after its hidden blank.
+		input := inputs[len(inputs)-1]
+		if input == "" && field.FieldType != models.FieldTypeBoolean {
+			delete(values, field.Key)
+			continue
+		}
+		value, err := field.Parse(input)
+		if err != nil {
+			return err
+		}
+		values[field.Key] = value
+	}
+	someThing.CustomFields = values
+
+	return nil
+}
+
 type someThingPageData struct {
 	basePageData
-	SomeThing models.SomeThing
+	SomeThing       models.SomeThing
+	Vocabulary      models.Tags
+	CustomFields    models.CustomFields
+	Attachments     models.Attachments
+	AttachmentsUsed int64
+	AttachmentQuota int64
+	Timeline        models.Timeline
 }
 
 func someThingHandler(w http.ResponseWriter, r *http.Request) {
@@ -133,12 +207,48 @@ func someThingHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	attachments := models.Attachments{}
+	if err := attachments.FindAll(r.Context(), attachmentsForSomeThing(someThing)); err != nil {
+		errRes(w, r, 500, "error fetching attachments", err)
+		return
+	}
+
+	used, err := models.AttachmentBytesUsed(r.Context(), org.ID)
+	if err != nil {
+		errRes(w, r, 500, "error calculating attachment usage", err)
+		return
+	}
+
+	quotas, err := org.Quotas(r.Context())
+	if err != nil {
+		errRes(w, r, 500, "error calculating attachment quota", err)
+		return
+	}
+
+	timeline, err := activityTimeline(r.Context(), org, someThing.ID)
+	if err != nil {
+		errRes(w, r, 500, "error fetching activity", err)
+		return
+	}
+
+	definitions := models.SomeThings{}
+	if err := definitions.LoadDefinitions(r.Context(), org.ID); err != nil {
+		errRes(w, r, 500, "error fetching tags and fields", err)
+		return
+	}
+
 	if err := Tmpl.ExecuteTemplate(w, "some-thing.html", someThingPageData{
 		basePageData: basePageData{
 			PageTitle: "DoubleBoiler - SomeThing " + util.FirstFiveChars(someThing.ID),
 			Context:   r.Context(),
 		},
-		SomeThing: someThing,
+		SomeThing:       someThing,
+		Vocabulary:      definitions.Vocabulary,
+		CustomFields:    definitions.CustomFields,
+		Attachments:     attachments,
+		AttachmentsUsed: used,
+		AttachmentQuota: quotas.StorageBytes,
+		Timeline:        timeline,
 	}); err != nil {
 		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
 		return
@@ -147,7 +257,9 @@ func someThingHandler(w http.ResponseWriter, r *http.Request) {
 
 type someThingsPageData struct {
 	basePageData
-	SomeThings models.SomeThings
+	SomeThings     models.SomeThings
+	SavedViews     savedViewsData
+	HasSubAccounts bool
 }
 
 func someThingDeletionHandler(w http.ResponseWriter, r *http.Request) {
@@ -162,6 +274,19 @@ func someThingDeletionHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	attachments := models.Attachments{}
+	if err := attachments.FindAll(r.Context(), attachmentsForSomeThing(someThing)); err != nil {
+		errRes(w, r, 500, "error fetching attachments", err)
+		return
+	}
+
+	for _, attachment := range attachments.Data {
+		if err := attachment.Delete(r.Context()); err != nil {
+			errRes(w, r, 500, "error removing attachment", err)
+			return
+		}
+	}
+
 	if err := someThing.HardDelete(r.Context()); err != nil {
 		errRes(w, r, 500, "error removing user from organisation", err)
 		return
@@ -183,7 +308,16 @@ func someThingsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	savedViews, written := applySavedView(w, r, "some-things", targetOrg)
+	if written {
+		return
+	}
+
 	someThings := models.SomeThings{}
+	if err := someThings.LoadDefinitions(r.Context(), targetOrg.ID); err != nil {
+		errRes(w, r, 500, "error fetching tags and fields", err)
+		return
+	}
 
 	criteria := models.Criteria{
 		Query: &models.ByOrg{ID: targetOrg.ID},
@@ -196,13 +330,17 @@ func someThingsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	criteria.Sort.FromForm(r.Form, someThings.Sortables())
+
 	if err := someThings.FindAll(r.Context(), criteria); err != nil {
 		errRes(w, r, 500, "error fetching someThings", err)
 		return
 	}
 
 	if err := Tmpl.ExecuteTemplate(w, "some-things.html", someThingsPageData{
-		SomeThings: someThings,
+		SomeThings:     someThings,
+		SavedViews:     savedViews,
+		HasSubAccounts: orgsFromContext(r.Context()).HasChildren(targetOrg.ID),
 		basePageData: basePageData{
 			PageTitle: "DoubleBoiler - SomeThings",
 			Context:   r.Context(),
@@ -212,3 +350,67 @@ func someThingsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+type someThingsRollupPageData struct {
+	basePageData
+	SomeThings    models.SomeThings
+	Organisations map[string]models.Organisation
+}
+
+// someThingsRollupHandler lists the SomeThings of the active organisation and all its sub-accounts
+// together.
+func someThingsRollupHandler(w http.ResponseWriter, r *http.Request) {
+	targetOrg := activeOrgFromContext(r.Context())
+
+	if targetOrg.ID == "" {
+		redirToDefaultOrg(w, r)
+		return
+	}
+
+	if !can(r.Context(), targetOrg, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot list someThings for that organisation", nil)
+		return
+	}
+
+	someThings := models.SomeThings{}
+
+	criteria := models.Criteria{
+		Query: &models.ByOrgTree{ID: targetOrg.ID},
+	}
+	criteria.Pagination.DefaultPageSize = 50
+	criteria.Pagination.Paginate(r.Form)
+
+	if err := criteria.Filters.FromForm(r.Form, someThings.AvailableFilters()); err != nil {
+		errRes(w, r, http.StatusBadRequest, "error interpreting filters", err)
+		return
+	}
+
+	criteria.Sort.FromForm(r.Form, someThings.Sortables())
+
+	if err := someThings.FindAll(r.Context(), criteria); err != nil {
+		errRes(w, r, 500, "error fetching someThings", err)
+		return
+	}
+
+	if err := Tmpl.ExecuteTemplate(w, "some-things-rollup.html", someThingsRollupPageData{
+		SomeThings:    someThings,
+		Organisations: orgsFromContext(r.Context()).ByID(),
+		basePageData: basePageData{
+			PageTitle: "DoubleBoiler - SomeThings - " + targetOrg.Name + " and sub-accounts",
+			Context:   r.Context(),
+		},
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Templating error", err)
+		return
+	}
+}
+
+func attachmentsForSomeThing(someThing models.SomeThing) models.Criteria {
+	return models.Criteria{
+		Query: &models.ByOrg{ID: someThing.OrganisationID},
+		Filters: models.Filters{&models.Custom{
+			Col:    "some_thing_id",
+			Values: []string{someThing.ID},
+		}},
+	}
+}
@@ -2,11 +2,13 @@ package routes
 
 import (
 	"context"
+	"database/sql"
 	"doubleboiler/models"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/gorilla/mux"
@@ -129,6 +131,37 @@ func TestSomeThingsHandler(t *testing.T) {
 	closeTx(t, ctx)
 }
 
+func TestSomeThingsHandlerSorting(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	org := organisationFixture(ctx, t)
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	first := someThingFixture(ctx, t, org)
+	first.Name = "aaa " + first.Name
+	assert.Nil(t, first.Save(ctx))
+
+	last := someThingFixture(ctx, t, org)
+	last.Name = "zzz " + last.Name
+	assert.Nil(t, last.Save(ctx))
+
+	req, err := http.NewRequest("GET", "/some-things?sort=-name&limit=10", nil)
+	assert.Nil(t, err)
+	assert.Nil(t, req.ParseForm())
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	someThingsHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Less(t, strings.Index(body, last.Name), strings.Index(body, first.Name))
+	assert.Contains(t, body, "sort=name")
+
+	closeTx(t, ctx)
+}
+
 func someThingFixture(ctx context.Context, t *testing.T, org models.Organisation) (someThing models.SomeThing) {
 	someThing.New(
 		bandname(),
@@ -138,3 +171,32 @@ func someThingFixture(ctx context.Context, t *testing.T, org models.Organisation
 	assert.Nil(t, someThing.Save(ctx))
 	return someThing
 }
+
+func TestSomeThingsRollupHandler(t *testing.T) {
+	t.Parallel()
+
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	org := organisationFixture(ctx, t)
+	sub := models.Organisation{}
+	sub.New(bandname(), "Australia")
+	sub.ParentID = sql.NullString{Valid: true, String: org.ID}
+	assert.Nil(t, sub.Save(ctx))
+
+	ctx = contextifyOrgAdmin(ctx, org)
+
+	own := someThingFixture(ctx, t, org)
+	inSub := someThingFixture(ctx, t, sub)
+
+	req, err := http.NewRequest("GET", "/some-things/rollup", nil)
+	assert.Nil(t, err)
+	req = req.WithContext(ctx)
+
+	rr := httptest.NewRecorder()
+	someThingsRollupHandler(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), own.Name)
+	assert.Contains(t, rr.Body.String(), inSub.Name)
+}
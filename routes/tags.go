@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"doubleboiler/models"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+func init() {
+	r.Path("/tags").
+		Methods("POST").
+		HandlerFunc(tagCreateHandler)
+
+	r.Path("/tags/{id}/delete").
+		Methods("POST").
+		HandlerFunc(tagDeletionHandler)
+}
+
+func tagCreateHandler(w http.ResponseWriter, r *http.Request) {
+	required := []string{
+		"name",
+		"organisationID",
+	}
+	if okay := checkFormInput(required, r.Form, w, r); !okay {
+		return
+	}
+
+	org := orgFromContext(r.Context(), r.FormValue("organisationID"))
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot manage tags for that organisation", nil)
+		return
+	}
+
+	tag := models.Tag{}
+	tag.New(org.ID, r.FormValue("name"))
+
+	if err := tag.Save(r.Context()); err != nil {
+		errRes(w, r, errCode(err), "Error saving tag", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/organisations/"+org.ID, r.Form), 302)
+}
+
+func tagDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	tag := models.Tag{}
+	if err := tag.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "A database error has occurred", err)
+		return
+	}
+
+	org := orgFromContext(r.Context(), tag.OrganisationID)
+
+	if !can(r.Context(), org, "admin") {
+		errRes(w, r, http.StatusForbidden, "You cannot manage tags for that organisation", nil)
+		return
+	}
+
+	if err := tag.Delete(r.Context()); err != nil {
+		errRes(w, r, 500, "Error removing tag", err)
+		return
+	}
+
+	http.Redirect(w, r, nextFlow("/organisations/"+org.ID, r.Form), 302)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"doubleboiler/config"
 	"doubleboiler/logger"
+	"doubleboiler/workers/outbox"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -15,13 +16,11 @@ type codeCapturedResponseWriter struct {
 	statusCode int
 }
 
-func (this codeCapturedResponseWriter) drainQueueUnlessError(ctx context.Context) {
+// Tasks are written to the outbox inside the request transaction. Once that has committed,
+// the relay is kicked so they go out straight away rather than on its next tick.
+func (this codeCapturedResponseWriter) relayOutboxUnlessError() {
 	if this.statusCode >= 200 && this.statusCode < 400 {
-		if err := config.QUEUE.Drain(ctx); err != nil {
-			config.ReportError(fmt.Errorf("%w: draining buffered queue items", err), ctx)
-			msg := fmt.Sprintf("draining buffered queue items: %+v", err)
-			logger.Log(ctx, logger.Error, msg)
-		}
+		outbox.Kick()
 	}
 }
 
@@ -53,14 +52,12 @@ func txMiddleware(h http.Handler) http.Handler {
 		} else {
 			codeWrapper := NewCodeCapturedResponseWriter(w)
 
-			ctx = config.QUEUE.PrepareContext(ctx)
-
 			if taskMatcher.MatchString(r.URL.Path) {
 				ctx = context.WithValue(ctx, "tx", config.Db)
 				ctx = context.WithValue(ctx, "parallelizable", true)
-				h.ServeHTTP(w, r.WithContext(ctx))
+				h.ServeHTTP(codeWrapper, r.WithContext(ctx))
 
-				codeWrapper.drainQueueUnlessError(ctx)
+				codeWrapper.relayOutboxUnlessError()
 				return
 			}
 
@@ -94,9 +91,7 @@ func txMiddleware(h http.Handler) http.Handler {
 				}
 			}
 
-			ctx = context.WithValue(ctx, "tx", config.Db)
-
-			codeWrapper.drainQueueUnlessError(ctx)
+			codeWrapper.relayOutboxUnlessError()
 		}
 	})
 }
@@ -36,6 +36,8 @@ var assetPaths = []string{
 	"safari-pinned-tab.svg",
 }
 
+const errAccountDisabled = "This account has been disabled. Please contact support."
+
 func userMiddleware(h http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.FormValue("token") != "" {
@@ -52,6 +54,11 @@ func userMiddleware(h http.Handler) http.Handler {
 					return
 				}
 
+				if user.Disabled {
+					errRes(w, r, http.StatusForbidden, errAccountDisabled, nil)
+					return
+				}
+
 				expiration, _ := time.Parse(time.RFC3339, r.FormValue("expiry"))
 
 				encoded, err := secureCookie.Encode("doubleboiler-user", map[string]string{
@@ -111,6 +118,39 @@ func userMiddleware(h http.Handler) http.Handler {
 				return
 			}
 
+			impersonating := models.User{}
+			if cookieValue["ImpersonatorID"] != "" {
+				impersonator := models.User{}
+				if err := impersonator.FindByID(r.Context(), cookieValue["ImpersonatorID"]); err != nil {
+					errRes(w, r, 403, "Invalid user", err)
+					return
+				}
+
+				expiry, err := time.Parse(time.RFC3339, cookieValue["ImpersonationExpiry"])
+				if err != nil || time.Now().After(expiry) || !impersonator.SuperAdmin || impersonator.Disabled {
+					if err := resumeAsImpersonator(w, impersonator); err != nil {
+						errRes(w, r, 500, "Error encoding cookie", err)
+						return
+					}
+					user = impersonator
+				} else {
+					impersonating = impersonator
+				}
+			}
+
+			if user.Disabled {
+				http.SetCookie(w, &http.Cookie{
+					Path:     "/",
+					Name:     "doubleboiler-user",
+					Value:    "logged_out",
+					Expires:  time.Date(1970, 0, 0, 0, 0, 0, 0, time.UTC),
+					Secure:   true,
+					HttpOnly: true,
+				})
+				errRes(w, r, http.StatusForbidden, errAccountDisabled, nil)
+				return
+			}
+
 			if util.Contains(assetPaths, util.RootPath(r.URL)) {
 				logger.Log(r.Context(), logger.Info, fmt.Sprintf("User seen: %s, %s, %s, %s\n", user.ID, user.Email, r.Method, r.URL.Path))
 			}
@@ -128,6 +168,9 @@ func userMiddleware(h http.Handler) http.Handler {
 			}
 
 			con := context.WithValue(r.Context(), "user", user)
+			if impersonating.ID != "" {
+				con = context.WithValue(con, "impersonator", impersonating)
+			}
 			con = context.WithValue(con, "totp-verified", cookieValue["TOTP"] == "true")
 			r = r.WithContext(con)
 
@@ -63,54 +63,14 @@ func init() {
 	r.Path("/users/{id}/disable-totp").
 		Methods("POST").
 		HandlerFunc(userDisableTOTPHandler)
-}
-
-func userImpersonater(w http.ResponseWriter, r *http.Request) {
-	loggedInUser := userFromContext(r.Context())
-	if !loggedInUser.SuperAdmin {
-		errRes(w, r, 403, "You are not an admin", nil)
-		return
-	}
-
-	vars := mux.Vars(r)
-	user := models.User{}
-	if err := user.FindByID(r.Context(), vars["id"]); err != nil {
-		errRes(w, r, 500, "error fetching user", err)
-		return
-	}
-
-	expiration := time.Now().Add(30 * 24 * time.Hour)
-	encoded, err := secureCookie.Encode("doubleboiler-user", map[string]string{
-		"ID":   user.ID,
-		"TOTP": "true",
-	})
-	if err != nil {
-		errRes(w, r, 500, "Error encoding cookie", nil)
-		return
-	}
-	cookie := http.Cookie{
-		Path:     "/",
-		Name:     "doubleboiler-user",
-		Value:    encoded,
-		Expires:  expiration,
-		Secure:   true,
-		HttpOnly: true,
-	}
 
-	http.SetCookie(w, &cookie)
-
-	orgs := orgsFromContext(r.Context())
+	r.Path("/users/{id}/delete-account").
+		Methods("POST").
+		HandlerFunc(userDeleteAccountHandler)
 
-	if err := Tmpl.ExecuteTemplate(w, "dashboard.html", dashboardPageData{
-		Organisations: orgs,
-		basePageData: basePageData{
-			PageTitle: "DoubleBoiler - Dashboard",
-			Context:   r.Context(),
-		},
-	}); err != nil {
-		errRes(w, r, 500, "Problem with template", err)
-		return
-	}
+	r.Path("/users/{id}/cancel-deletion").
+		Methods("POST").
+		HandlerFunc(userCancelDeletionHandler)
 }
 
 func userCreateOrUpdateHandler(w http.ResponseWriter, r *http.Request) {
@@ -267,8 +227,9 @@ func userCreateOrUpdateHandler(w http.ResponseWriter, r *http.Request) {
 
 type usersPageData struct {
 	basePageData
-	Users   models.Users
-	Context context.Context
+	Users      models.Users
+	Context    context.Context
+	SavedViews savedViewsData
 }
 
 func usersHandler(w http.ResponseWriter, r *http.Request) {
@@ -292,6 +253,11 @@ func usersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	savedViews, written := applySavedView(w, r, "users", models.Organisation{})
+	if written {
+		return
+	}
+
 	users := models.Users{}
 
 	criteria := models.Criteria{
@@ -302,14 +268,17 @@ func usersHandler(w http.ResponseWriter, r *http.Request) {
 
 	criteria.Filters.FromForm(r.Form, users.AvailableFilters())
 
+	criteria.Sort.FromForm(r.Form, users.Sortables())
+
 	if err := users.FindAll(r.Context(), criteria); err != nil {
 		errRes(w, r, 500, "error fetching users", err)
 		return
 	}
 
 	if err := Tmpl.ExecuteTemplate(w, "users.html", usersPageData{
-		Users:   users,
-		Context: r.Context(),
+		Users:      users,
+		Context:    r.Context(),
+		SavedViews: savedViews,
 	}); err != nil {
 		errRes(w, r, 500, "Templating error", err)
 		return
@@ -364,6 +333,7 @@ func createOrgFromSignup(ctx context.Context, user models.User, orgname, orgcoun
 		orgname,
 		orgcountry,
 	)
+	org.OwnerID = sql.NullString{Valid: true, String: user.ID}
 
 	if err := org.Save(ctx); err != nil {
 		return err, org
@@ -388,6 +358,13 @@ func createOrgFromSignup(ctx context.Context, user models.User, orgname, orgcoun
 	if err := orgUser.Save(ctx); err != nil {
 		return err, org
 	}
+
+	subscription := models.Subscription{}
+	subscription.New(org.ID, models.TrialPlan)
+	if err := subscription.Save(ctx); err != nil {
+		return err, org
+	}
+
 	return nil, org
 }
 
@@ -644,6 +621,80 @@ func userDisableTOTPHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/users/"+user.ID, http.StatusFound)
 }
 
+// userDeleteAccountHandler is someone asking for their own account to be deleted. Nothing happens
+// straight away, the account is anonymised once the cooling off period is over.
+func userDeleteAccountHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	loggedInUser := userFromContext(r.Context())
+
+	if loggedInUser.ID != vars["id"] || impersonatorFromContext(r.Context()).ID != "" {
+		errRes(w, r, http.StatusForbidden, "Only you can delete your account", nil)
+		return
+	}
+
+	user := models.User{}
+	if err := user.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "error fetching user", err)
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(r.FormValue("password"))); err != nil {
+		errRes(w, r, 403, "Incorrect password", nil)
+		return
+	}
+
+	if user.DeletionRequestedAt.Valid {
+		http.Redirect(w, r, "/users/"+user.ID, http.StatusFound)
+		return
+	}
+
+	if err := user.RequestDeletion(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error requesting account deletion", err)
+		return
+	}
+
+	if ctx, err := user.PersistFlash(r.Context(), flashes.Flash{
+		Persistent: true,
+		Type:       flashes.Success,
+		Text:       "Your account will be deleted on " + user.DeletionDue().Format("2 January 2006") + ". You can cancel until then.",
+	}); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error adding flash message", err)
+		return
+	} else {
+		r = r.WithContext(ctx)
+	}
+
+	http.Redirect(w, r, "/users/"+user.ID, http.StatusFound)
+}
+
+func userCancelDeletionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	loggedInUser := userFromContext(r.Context())
+
+	if loggedInUser.ID != vars["id"] && !loggedInUser.SuperAdmin {
+		errRes(w, r, 403, "You are not logged in as this user, nor are you an application admin", nil)
+		return
+	}
+
+	user := models.User{}
+	if err := user.FindByID(r.Context(), vars["id"]); err != nil {
+		errRes(w, r, 500, "error fetching user", err)
+		return
+	}
+
+	if user.AnonymisedAt.Valid {
+		errRes(w, r, http.StatusBadRequest, "This account has already been deleted", nil)
+		return
+	}
+
+	if err := user.CancelDeletion(r.Context()); err != nil {
+		errRes(w, r, http.StatusInternalServerError, "Error cancelling account deletion", err)
+		return
+	}
+
+	http.Redirect(w, r, "/users/"+user.ID, http.StatusFound)
+}
+
 type recoveryCodesPageData struct {
 	basePageData
 	ActiveOrg     models.Organisation
@@ -128,3 +128,34 @@ func TestUsersInvalidInputChecking(t *testing.T) {
 
 	closeTx(t, ctx)
 }
+
+func TestUserDeleteAccountHandler(t *testing.T) {
+	t.Parallel()
+	ctx := getCtx(t)
+	defer closeTx(t, ctx)
+
+	fixture, password := userFixture(ctx, t)
+	other, _ := userFixture(ctx, t)
+
+	serve := func(ctx context.Context, target models.User, password string) int {
+		req := &http.Request{
+			Method: "POST",
+			URL:    &url.URL{Path: "/users/" + target.ID + "/delete-account"},
+			Form:   url.Values{"password": {password}},
+		}
+		req = mux.SetURLVars(req, map[string]string{"id": target.ID})
+		req = req.WithContext(ctx)
+
+		rr := httptest.NewRecorder()
+		userDeleteAccountHandler(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, serve(context.WithValue(ctx, "user", other), fixture, password))
+	assert.Equal(t, http.StatusForbidden, serve(context.WithValue(ctx, "user", fixture), fixture, "wrong"))
+	assert.Equal(t, http.StatusFound, serve(context.WithValue(ctx, "user", fixture), fixture, password))
+
+	found := models.User{}
+	assert.Nil(t, found.FindByID(ctx, fixture.ID))
+	assert.True(t, found.DeletionRequestedAt.Valid)
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"doubleboiler/changelog"
 	"doubleboiler/config"
 	"doubleboiler/flashes"
 	"doubleboiler/logger"
@@ -40,11 +41,26 @@ var templateFuncMap = template.FuncMap{
 		return false
 	},
 	"firstFiveChars":       util.FirstFiveChars,
+	"humanBytes":           models.HumanBytes,
+	"markdown":             util.Markdown,
+	"listQuery":            listQuery,
+	"sortQuery":            sortQuery,
 	"loggedIn":             isLoggedIn,
 	"user":                 userFromContext,
+	"impersonator":         impersonatorFromContext,
 	"orgsFromContext":      orgsFromContext,
 	"flashes":              flashesFromContext,
 	"activeOrgFromContext": activeOrgFromContext,
+	"feature":              featureEnabled,
+	"unreadChanges": func(ctx context.Context) []changelog.Change {
+		if !isLoggedIn(ctx) {
+			return nil
+		}
+		return userFromContext(ctx).UnreadChanges()
+	},
+	"branding": func(ctx context.Context) models.Branding {
+		return activeOrgFromContext(ctx).Branding()
+	},
 	"can": func(ctx context.Context, role string) bool {
 		org := activeOrgFromContext(ctx)
 		return can(ctx, org, role)
@@ -184,6 +200,14 @@ func isClientSafe(err error) (bool, string) {
 	}
 }
 
+// errCode is the status for an error from a model, blaming the client only when they can fix it.
+func errCode(err error) int {
+	if clientSafe, _ := isClientSafe(err); clientSafe {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
 func userFromContext(ctx context.Context) models.User {
 	if !isLoggedIn(ctx) {
 		return models.User{}
@@ -227,3 +251,23 @@ func flashesFromContext(ctx context.Context) flashes.Flashes {
 }
 
 var nextFlow = util.NextFlow
+
+// listQuery is the link to another page of a list that keeps its filters and sort.
+func listQuery(criteria any, page url.Values) template.URL {
+	if list, ok := criteria.(interface{ With(url.Values) url.Values }); ok {
+		return "?" + template.URL(list.With(page).Encode())
+	}
+	return "?" + template.URL(page.Encode())
+}
+
+// sortQuery is the link that sorts a list by key, or adds key as a further sort when then is set.
+// Filters and page size are kept but the list goes back to the first page.
+func sortQuery(criteria models.Criteria, key string, then bool) template.URL {
+	sort := criteria.Sort.By(key)
+	if then {
+		sort = criteria.Sort.Then(key)
+	}
+	values := criteria.With(url.Values{"sort": sort.Params()})
+	values.Del("skip")
+	return "?" + template.URL(values.Encode())
+}
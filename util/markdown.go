@@ -0,0 +1,18 @@
+package util
+
+import (
+	"html/template"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/parser"
+	"github.com/microcosm-cc/bluemonday"
+)
+
+var markdownPolicy = bluemonday.UGCPolicy()
+
+// Markdown renders user supplied Markdown to HTML, stripping anything unsafe on the way out.
+func Markdown(in string) template.HTML {
+	p := parser.NewWithExtensions(parser.CommonExtensions)
+	unsafe := markdown.ToHTML([]byte(in), p, nil)
+	return template.HTML(markdownPolicy.SanitizeBytes(unsafe))
+}
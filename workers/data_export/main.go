@@ -0,0 +1,62 @@
+package data_export
+
+import (
+	"context"
+	"doubleboiler/config"
+	"doubleboiler/filestore"
+	"doubleboiler/logger"
+	"doubleboiler/models"
+	"doubleboiler/util"
+	"doubleboiler/workers/deadletter"
+	"doubleboiler/workers/outbox"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+)
+
+func Init() {
+	go func() {
+		if err := config.QUEUE.Subscribe(context.Background(), config.DATA_EXPORT_QUEUE_NAME, deadletter.Wrap(config.DATA_EXPORT_QUEUE_NAME, outbox.Once(Handler{}))); err != nil {
+			logger.Log(context.Background(), logger.Error, "Queue error", config.DATA_EXPORT_QUEUE_NAME, err)
+		}
+	}()
+}
+
+type Handler struct{}
+
+func (h Handler) Handle(task kewpie.Task) (requeue bool, err error) {
+	input := map[string]string{}
+
+	if err := task.Unmarshal(&input); err != nil {
+		config.ReportError(err)
+		return false, err
+	}
+
+	ctx, tx, err := util.GetTxCtx()
+	if err != nil {
+		util.RollbackTx(ctx)
+		return true, err
+	}
+
+	export := models.DataExport{}
+	if err := export.FindByID(ctx, input["id"]); err != nil {
+		util.RollbackTx(ctx)
+		return true, err
+	}
+
+	if export.Status == models.DataExportReady {
+		util.RollbackTx(ctx)
+		return false, nil
+	}
+
+	if err := export.Build(ctx, filestore.Default); err != nil {
+		util.RollbackTx(ctx)
+		return true, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		config.ReportError(err)
+		return true, err
+	}
+
+	return false, nil
+}
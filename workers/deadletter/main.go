@@ -0,0 +1,46 @@
+package deadletter
+
+import (
+	"context"
+	"doubleboiler/config"
+	"doubleboiler/logger"
+	"doubleboiler/models"
+	"fmt"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+)
+
+// Wrap persists tasks that fail without being requeued, so they can be inspected and retried later.
+func Wrap(queueName string, handler kewpie.Handler) kewpie.Handler {
+	return wrapped{
+		queueName: queueName,
+		handler:   handler,
+	}
+}
+
+type wrapped struct {
+	queueName string
+	handler   kewpie.Handler
+}
+
+func (this wrapped) Handle(task kewpie.Task) (requeue bool, err error) {
+	requeue, err = this.handler.Handle(task)
+	if err == nil || requeue {
+		return requeue, err
+	}
+
+	ctx := context.WithValue(context.Background(), "tx", config.Db)
+
+	deadLetter := models.DeadLetter{}
+	deadLetter.New(this.queueName, task, err)
+
+	if saveErr := deadLetter.Save(ctx); saveErr != nil {
+		// If we can't record it, let the queue hang on to it instead.
+		config.ReportError(fmt.Errorf("%w: saving dead letter for %s", saveErr, this.queueName))
+		return true, err
+	}
+
+	logger.Log(ctx, logger.Warning, fmt.Sprintf("task on %s dead lettered as %s: %+v", this.queueName, deadLetter.ID, err))
+
+	return false, err
+}
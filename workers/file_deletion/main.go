@@ -0,0 +1,40 @@
+package file_deletion
+
+import (
+	"context"
+	"doubleboiler/config"
+	"doubleboiler/filestore"
+	"doubleboiler/logger"
+	"doubleboiler/models"
+	"doubleboiler/workers/deadletter"
+	"doubleboiler/workers/outbox"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+)
+
+func Init() {
+	go func() {
+		if err := config.QUEUE.Subscribe(context.Background(), config.FILE_DELETION_QUEUE_NAME, deadletter.Wrap(config.FILE_DELETION_QUEUE_NAME, outbox.Once(Handler{}))); err != nil {
+			logger.Log(context.Background(), logger.Error, "Queue error", config.FILE_DELETION_QUEUE_NAME, err)
+		}
+	}()
+}
+
+type Handler struct{}
+
+func (h Handler) Handle(task kewpie.Task) (requeue bool, err error) {
+	deletion := models.FileDeletion{}
+
+	if err := task.Unmarshal(&deletion); err != nil {
+		config.ReportError(err)
+		return false, err
+	}
+
+	ctx := context.WithValue(context.Background(), "tx", config.Db)
+
+	if err := deletion.Run(ctx, filestore.Default); err != nil {
+		return true, err
+	}
+
+	return false, nil
+}
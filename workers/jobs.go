@@ -0,0 +1,59 @@
+package workers
+
+import (
+	"context"
+	"doubleboiler/filestore"
+	"doubleboiler/models"
+	"doubleboiler/workers/scheduler"
+	"time"
+)
+
+func init() {
+	scheduler.Register(scheduler.Job{
+		Name:     "prune_job_runs",
+		Schedule: "30 3 * * *",
+		Run: func(ctx context.Context) error {
+			return models.PruneJobRuns(ctx, time.Now().AddDate(0, 0, -90))
+		},
+	})
+
+	scheduler.Register(scheduler.Job{
+		Name:     "prune_outbox",
+		Schedule: "45 3 * * *",
+		Run: func(ctx context.Context) error {
+			return models.PruneOutbox(ctx, time.Now().Add(-models.OutboxRetention))
+		},
+	})
+
+	scheduler.Register(scheduler.Job{
+		Name:     "anonymise_deleted_users",
+		Schedule: "15 4 * * *",
+		Run: func(ctx context.Context) error {
+			return models.AnonymiseUsersDueForDeletion(ctx, time.Now().Add(-models.AccountDeletionCoolingOff))
+		},
+	})
+
+	scheduler.Register(scheduler.Job{
+		Name:     "prune_data_exports",
+		Schedule: "30 4 * * *",
+		Run: func(ctx context.Context) error {
+			return models.PruneDataExports(ctx, filestore.Default, time.Now())
+		},
+	})
+
+	scheduler.Register(scheduler.Job{
+		Name:     "queue_organisation_deletions",
+		Schedule: "45 4 * * *",
+		Run: func(ctx context.Context) error {
+			return models.QueueDueOrganisationDeletions(ctx, time.Now().Add(-models.OrganisationDeletionGracePeriod))
+		},
+	})
+
+	scheduler.Register(scheduler.Job{
+		Name:     "prune_communications",
+		Schedule: "0 5 * * *",
+		Run: func(ctx context.Context) error {
+			return models.PruneCommunications(ctx, time.Now())
+		},
+	})
+}
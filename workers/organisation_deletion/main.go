@@ -0,0 +1,69 @@
+package organisation_deletion
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/filestore"
+	"doubleboiler/logger"
+	"doubleboiler/models"
+	"doubleboiler/util"
+	"doubleboiler/workers/deadletter"
+	"doubleboiler/workers/outbox"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+)
+
+func Init() {
+	go func() {
+		if err := config.QUEUE.Subscribe(context.Background(), config.ORGANISATION_DELETION_QUEUE_NAME, deadletter.Wrap(config.ORGANISATION_DELETION_QUEUE_NAME, outbox.Once(Handler{}))); err != nil {
+			logger.Log(context.Background(), logger.Error, "Queue error", config.ORGANISATION_DELETION_QUEUE_NAME, err)
+		}
+	}()
+}
+
+type Handler struct{}
+
+func (h Handler) Handle(task kewpie.Task) (requeue bool, err error) {
+	input := map[string]string{}
+
+	if err := task.Unmarshal(&input); err != nil {
+		config.ReportError(err)
+		return false, err
+	}
+
+	ctx, tx, err := util.GetTxCtx()
+	if err != nil {
+		util.RollbackTx(ctx)
+		return true, err
+	}
+
+	org := models.Organisation{}
+	if err := org.FindByID(ctx, input["id"]); err != nil {
+		util.RollbackTx(ctx)
+		if err == sql.ErrNoRows {
+			// Already purged
+			return false, nil
+		}
+		return true, err
+	}
+
+	// The deletion may have been cancelled since this was queued.
+	if !org.DeletionRequestedAt.Valid || org.DeletionDue().After(time.Now()) {
+		util.RollbackTx(ctx)
+		return false, nil
+	}
+
+	if err := org.Purge(ctx, filestore.Default); err != nil {
+		util.RollbackTx(ctx)
+		return true, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		config.ReportError(err)
+		return true, err
+	}
+
+	return false, nil
+}
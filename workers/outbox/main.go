@@ -0,0 +1,139 @@
+package outbox
+
+import (
+	"context"
+	"doubleboiler/config"
+	"doubleboiler/logger"
+	"doubleboiler/models"
+	"doubleboiler/util"
+	"fmt"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+)
+
+const batchSize = 100
+
+var kick = make(chan struct{}, 1)
+
+// Init starts the relay, which publishes committed outbox entries to the queue.
+// It runs every few seconds as a safety net and immediately whenever Kick is called.
+func Init() {
+	go func() {
+		ticker := time.NewTicker(10 * time.Second)
+		for {
+			select {
+			case <-ticker.C:
+			case <-kick:
+			}
+			if err := Relay(); err != nil {
+				config.ReportError(fmt.Errorf("%w: relaying outbox", err))
+			}
+		}
+	}()
+}
+
+// Kick asks the relay to run now rather than waiting for its next tick.
+func Kick() {
+	select {
+	case kick <- struct{}{}:
+	default:
+	}
+}
+
+// Relay publishes unpublished outbox entries until there are none left, or until a batch has
+// failures. Those wait for their retry time rather than being hammered.
+func Relay() error {
+	for {
+		num, err := relayBatch()
+		if err != nil {
+			return err
+		}
+		if num < batchSize {
+			return nil
+		}
+	}
+}
+
+func relayBatch() (int, error) {
+	ctx, tx, err := util.GetTxCtx()
+	if err != nil {
+		return 0, err
+	}
+
+	entries, err := models.PendingOutbox(ctx, batchSize)
+	if err != nil {
+		util.RollbackTx(ctx)
+		return 0, err
+	}
+
+	published := 0
+	for _, entry := range entries {
+		task := entry.Task
+		if err := config.QUEUE.Publish(context.Background(), entry.QueueName, &task); err != nil {
+			logger.Log(ctx, logger.Error, fmt.Sprintf("publishing outbox entry %s: %+v", entry.ID, err))
+			if err := entry.MarkFailed(ctx, err); err != nil {
+				util.RollbackTx(ctx)
+				return 0, err
+			}
+			continue
+		}
+		if err := entry.MarkPublished(ctx); err != nil {
+			util.RollbackTx(ctx)
+			return 0, err
+		}
+		published++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return published, nil
+}
+
+// Once wraps a handler so that an outbox entry published more than once is only acted on once.
+// A failed attempt releases its claim so the task can be retried.
+func Once(handler kewpie.Handler) kewpie.Handler {
+	return once{handler: handler}
+}
+
+type once struct {
+	handler kewpie.Handler
+}
+
+func (this once) Handle(task kewpie.Task) (requeue bool, err error) {
+	id := task.Tags.Get("outbox_id")
+	if id == "" {
+		return this.handler.Handle(task)
+	}
+
+	ctx := context.WithValue(context.Background(), "tx", config.Db)
+
+	claim, err := models.ClaimOutboxDelivery(ctx, id)
+	if err != nil {
+		return true, err
+	}
+	switch claim {
+	case models.OutboxDelivered:
+		logger.Log(ctx, logger.Info, fmt.Sprintf("skipping duplicate delivery of outbox entry %s", id))
+		return false, nil
+	case models.OutboxBusy:
+		return true, fmt.Errorf("outbox entry %s is already being handled", id)
+	}
+
+	requeue, err = this.handler.Handle(task)
+	if err != nil {
+		if releaseErr := models.ReleaseOutboxDelivery(ctx, id); releaseErr != nil {
+			config.ReportError(fmt.Errorf("%w: releasing outbox entry %s", releaseErr, id))
+		}
+		return requeue, err
+	}
+
+	if err := models.MarkOutboxDelivered(ctx, id); err != nil {
+		// The work is done, so running it again would be worse than a redelivery slipping through.
+		config.ReportError(fmt.Errorf("%w: marking outbox entry %s delivered", err, id))
+	}
+
+	return requeue, nil
+}
@@ -0,0 +1,146 @@
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed five field cron expression: minute, hour, day of month, month and day of week.
+// Each field accepts *, single values, ranges (1-5), lists (1,3,5) and steps (*/15, 0-30/5).
+type Schedule struct {
+	Expression string
+	minute     map[int]bool
+	hour       map[int]bool
+	dom        map[int]bool
+	month      map[int]bool
+	dow        map[int]bool
+	domStar    bool
+	dowStar    bool
+}
+
+var scheduleAliases = map[string]string{
+	"@hourly":  "0 * * * *",
+	"@daily":   "0 0 * * *",
+	"@weekly":  "0 0 * * 0",
+	"@monthly": "0 0 1 * *",
+}
+
+func Parse(expr string) (Schedule, error) {
+	spec := expr
+	if alias, ok := scheduleAliases[expr]; ok {
+		spec = alias
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return Schedule{}, fmt.Errorf("cron expression %q must have five fields", expr)
+	}
+
+	bounds := [][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+	parsed := []map[int]bool{}
+	for i, field := range fields {
+		set, err := parseField(field, bounds[i][0], bounds[i][1])
+		if err != nil {
+			return Schedule{}, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		parsed = append(parsed, set)
+	}
+
+	return Schedule{
+		Expression: expr,
+		minute:     parsed[0],
+		hour:       parsed[1],
+		dom:        parsed[2],
+		month:      parsed[3],
+		dow:        parsed[4],
+		domStar:    fields[2] == "*",
+		dowStar:    fields[4] == "*",
+	}, nil
+}
+
+func parseField(field string, min, max int) (map[int]bool, error) {
+	ret := map[int]bool{}
+
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if rangePart, stepPart, found := strings.Cut(part, "/"); found {
+			s, err := strconv.Atoi(stepPart)
+			if err != nil || s < 1 {
+				return nil, fmt.Errorf("invalid step %q", stepPart)
+			}
+			step = s
+			part = rangePart
+		}
+
+		lo, hi := min, max
+		if part != "*" {
+			start, end, isRange := strings.Cut(part, "-")
+			l, err := strconv.Atoi(start)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", start)
+			}
+			lo, hi = l, l
+			if isRange {
+				h, err := strconv.Atoi(end)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value %q", end)
+				}
+				hi = h
+			} else if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return nil, fmt.Errorf("%q out of range %d-%d", part, min, max)
+		}
+
+		for i := lo; i <= hi; i += step {
+			ret[i] = true
+		}
+	}
+
+	return ret, nil
+}
+
+// Matches reports whether the schedule fires in the minute containing t.
+// As with cron, when both day of month and day of week are restricted, either may match.
+func (this Schedule) Matches(t time.Time) bool {
+	if !this.minute[t.Minute()] || !this.hour[t.Hour()] || !this.month[int(t.Month())] {
+		return false
+	}
+
+	domMatch := this.dom[t.Day()]
+	dowMatch := this.dow[int(t.Weekday())]
+
+	if this.domStar || this.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+// Next returns the first minute after t that the schedule fires, or the zero time if there is none within five years.
+func (this Schedule) Next(t time.Time) time.Time {
+	candidate := t.Truncate(time.Minute).Add(time.Minute)
+	limit := candidate.AddDate(5, 0, 0)
+
+	for candidate.Before(limit) {
+		if !this.month[int(candidate.Month())] {
+			candidate = time.Date(candidate.Year(), candidate.Month()+1, 1, 0, 0, 0, 0, candidate.Location())
+			continue
+		}
+		if !this.Matches(candidate) {
+			if !this.hour[candidate.Hour()] {
+				candidate = candidate.Truncate(time.Hour).Add(time.Hour)
+				continue
+			}
+			candidate = candidate.Add(time.Minute)
+			continue
+		}
+		return candidate
+	}
+
+	return time.Time{}
+}
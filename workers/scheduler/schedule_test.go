@@ -0,0 +1,49 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseInvalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	start := time.Date(2026, time.October, 19, 9, 7, 30, 0, time.UTC)
+
+	for expr, expected := range map[string]time.Time{
+		"* * * * *":    time.Date(2026, time.October, 19, 9, 8, 0, 0, time.UTC),
+		"*/15 * * * *": time.Date(2026, time.October, 19, 9, 15, 0, 0, time.UTC),
+		"30 3 * * *":   time.Date(2026, time.October, 20, 3, 30, 0, 0, time.UTC),
+		"0 9 * * 1-5":  time.Date(2026, time.October, 20, 9, 0, 0, 0, time.UTC),
+		"0 0 1 1 *":    time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC),
+		"@daily":       time.Date(2026, time.October, 20, 0, 0, 0, 0, time.UTC),
+		"0 12 25 * 0":  time.Date(2026, time.October, 25, 12, 0, 0, 0, time.UTC),
+	} {
+		schedule, err := Parse(expr)
+		assert.Nil(t, err, expr)
+		assert.Equal(t, expected, schedule.Next(start), expr)
+	}
+}
+
+func TestScheduleMatches(t *testing.T) {
+	schedule, err := Parse("0,30 9-17 * * *")
+	assert.Nil(t, err)
+	assert.True(t, schedule.Matches(time.Date(2026, time.October, 19, 9, 30, 0, 0, time.UTC)))
+	assert.False(t, schedule.Matches(time.Date(2026, time.October, 19, 18, 0, 0, 0, time.UTC)))
+	assert.False(t, schedule.Matches(time.Date(2026, time.October, 19, 9, 15, 0, 0, time.UTC)))
+}
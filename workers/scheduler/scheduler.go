@@ -0,0 +1,213 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"doubleboiler/config"
+	"doubleboiler/logger"
+	"doubleboiler/models"
+	"doubleboiler/util"
+	"doubleboiler/workers/outbox"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	kewpie "github.com/davidbanham/kewpie_go/v3"
+)
+
+// Every instance runs the scheduler loop, but only the one holding this Postgres
+// advisory lock fires jobs.
+const advisoryLockKey = 7314896201
+
+// jobLockClass namespaces the advisory locks held while each job runs, keyed on a hash of its name.
+const jobLockClass = 7315
+
+// ErrJobRunning is returned by Fire when the job is already running, here or on another instance.
+var ErrJobRunning = errors.New("job is already running")
+
+type Job struct {
+	Name     string
+	Schedule string
+	Run      func(ctx context.Context) error
+	schedule Schedule
+}
+
+func (this Job) Next(after time.Time) time.Time {
+	return this.schedule.Next(after)
+}
+
+var registry = []Job{}
+
+// Register adds a job to the schedule. It is intended to be called from init functions,
+// so an invalid schedule or a duplicate name is fatal.
+func Register(job Job) {
+	schedule, err := Parse(job.Schedule)
+	if err != nil {
+		log.Fatal(err)
+	}
+	job.schedule = schedule
+
+	for _, existing := range registry {
+		if existing.Name == job.Name {
+			log.Fatalf("scheduled job %s registered twice", job.Name)
+		}
+	}
+
+	registry = append(registry, job)
+}
+
+func Jobs() []Job {
+	return registry
+}
+
+func JobByName(name string) (Job, bool) {
+	for _, job := range registry {
+		if job.Name == name {
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+// PublishTask builds a job that enqueues a kewpie task. The task is published once the job's transaction commits.
+func PublishTask(queueName string, payload interface{}) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		task := kewpie.Task{}
+		if err := task.Marshal(payload); err != nil {
+			return err
+		}
+		return models.Enqueue(ctx, queueName, &task)
+	}
+}
+
+func Init() {
+	go loop(context.Background())
+}
+
+func loop(ctx context.Context) {
+	var conn *sql.Conn
+
+	for {
+		now := time.Now()
+		time.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+
+		tick := time.Now().Truncate(time.Minute)
+
+		conn = lead(ctx, conn)
+		if conn == nil {
+			continue
+		}
+
+		for _, job := range registry {
+			if job.schedule.Matches(tick) {
+				go func(job Job) {
+					if err := Fire(job, tick); err != nil && err != ErrJobRunning {
+						config.ReportError(fmt.Errorf("%w: running scheduled job %s", err, job.Name))
+					}
+				}(job)
+			}
+		}
+	}
+}
+
+// lead returns a connection holding the advisory lock, or nil if another instance is the leader.
+// The lock lives as long as the session, so a dropped connection hands leadership on.
+func lead(ctx context.Context, conn *sql.Conn) *sql.Conn {
+	if conn != nil {
+		if err := conn.PingContext(ctx); err == nil {
+			return conn
+		}
+		logger.Log(ctx, logger.Warning, "scheduler lost its leadership connection")
+		conn.Close()
+	}
+
+	conn, err := config.Db.Conn(ctx)
+	if err != nil {
+		logger.Log(ctx, logger.Error, fmt.Sprintf("scheduler getting connection: %+v", err))
+		return nil
+	}
+
+	acquired := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", advisoryLockKey).Scan(&acquired); err != nil {
+		logger.Log(ctx, logger.Error, fmt.Sprintf("scheduler acquiring advisory lock: %+v", err))
+		conn.Close()
+		return nil
+	}
+
+	if !acquired {
+		conn.Close()
+		return nil
+	}
+
+	logger.Log(ctx, logger.Info, "scheduler acquired leadership")
+
+	return conn
+}
+
+// Fire runs a job for the given scheduled time and records the outcome in job_runs.
+// If another instance has already claimed that slot, Fire does nothing. Scheduled and manual
+// runs of a job hold the same lock while they run, so they never overlap.
+func Fire(job Job, scheduledFor time.Time) error {
+	ctx := context.WithValue(context.Background(), "tx", config.Db)
+
+	conn, err := config.Db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	locked := false
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1, hashtext($2))", jobLockClass, job.Name).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		return ErrJobRunning
+	}
+	defer conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1, hashtext($2))", jobLockClass, job.Name)
+
+	run := models.JobRun{}
+	run.New(job.Name, scheduledFor)
+
+	claimed, err := run.Claim(ctx)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return nil
+	}
+
+	runErr := execute(job)
+	if runErr != nil {
+		logger.Log(ctx, logger.Error, fmt.Sprintf("scheduled job %s failed: %+v", job.Name, runErr))
+	}
+
+	return run.Finish(ctx, runErr)
+}
+
+func execute(job Job) (err error) {
+	ctx, tx, err := util.GetTxCtx()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			util.RollbackTx(ctx)
+			err = fmt.Errorf("panic: %+v", r)
+		}
+	}()
+
+	if err := job.Run(ctx); err != nil {
+		util.RollbackTx(ctx)
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	outbox.Kick()
+
+	return nil
+}
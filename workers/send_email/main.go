@@ -2,10 +2,13 @@ package send_email
 
 import (
 	"context"
+	"database/sql"
 	"doubleboiler/config"
 	"doubleboiler/logger"
 	"doubleboiler/models"
 	"doubleboiler/util"
+	"doubleboiler/workers/deadletter"
+	"doubleboiler/workers/outbox"
 	"fmt"
 	"strings"
 
@@ -15,7 +18,7 @@ import (
 
 func Init() {
 	go func() {
-		if err := config.QUEUE.Subscribe(context.Background(), config.SEND_EMAIL_QUEUE_NAME, Handler{}); err != nil {
+		if err := config.QUEUE.Subscribe(context.Background(), config.SEND_EMAIL_QUEUE_NAME, deadletter.Wrap(config.SEND_EMAIL_QUEUE_NAME, outbox.Once(Handler{}))); err != nil {
 			logger.Log(context.Background(), logger.Error, "Queue error", config.SEND_EMAIL_QUEUE_NAME, err)
 		}
 	}()
@@ -41,7 +44,24 @@ func (h Handler) Handle(task kewpie.Task) (requeue bool, err error) {
 		return true, err
 	}
 
-	if task.Tags.Get("user_id") != "" {
+	orgGone := false
+	if task.Tags.Get("organisation_id") != "" {
+		org := models.Organisation{}
+		err := org.FindByID(ctx, task.Tags.Get("organisation_id"))
+		switch {
+		case err == sql.ErrNoRows:
+			// Deleted since the email was queued. It still goes out, just unbranded and without
+			// a record against an organisation that isn't there any more.
+			orgGone = true
+		case err != nil:
+			util.RollbackTx(ctx)
+			return true, err
+		default:
+			input = org.Branding().Email(input)
+		}
+	}
+
+	if task.Tags.Get("user_id") != "" && !orgGone {
 		user := models.User{}
 		if err := user.FindByID(ctx, task.Tags.Get("user_id")); err != nil {
 			util.RollbackTx(ctx)
@@ -53,9 +73,16 @@ func (h Handler) Handle(task kewpie.Task) (requeue bool, err error) {
 			subject = task.Tags.Get("communication_subject")
 		}
 
-		if err := models.LogUserCommunication(ctx, task.Tags.Get("organisation_id"), user, "email", subject); err != nil {
-			util.RollbackTx(ctx)
-			return false, err
+		if task.Tags.Get("broadcast_id") != "" {
+			if err := models.LogBroadcastCommunication(ctx, task.Tags.Get("organisation_id"), task.Tags.Get("broadcast_id"), user, "email", subject); err != nil {
+				util.RollbackTx(ctx)
+				return false, err
+			}
+		} else {
+			if err := models.LogUserCommunication(ctx, task.Tags.Get("organisation_id"), user, "email", subject); err != nil {
+				util.RollbackTx(ctx)
+				return false, err
+			}
 		}
 	}
 
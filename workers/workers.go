@@ -2,6 +2,12 @@ package workers
 
 import (
 	"doubleboiler/config"
+	"doubleboiler/workers/data_export"
+	"doubleboiler/workers/deadletter"
+	"doubleboiler/workers/file_deletion"
+	"doubleboiler/workers/organisation_deletion"
+	"doubleboiler/workers/outbox"
+	"doubleboiler/workers/scheduler"
 	"doubleboiler/workers/send_email"
 
 	kewpie "github.com/davidbanham/kewpie_go/v3"
@@ -15,8 +21,15 @@ var queue kewpie.Kewpie
 
 func Init() {
 	send_email.Init()
+	data_export.Init()
+	organisation_deletion.Init()
+	file_deletion.Init()
+	scheduler.Init()
 }
 
 var Handlers = map[string]kewpie.Handler{
-	config.SEND_EMAIL_QUEUE_NAME: send_email.Handler{},
+	config.SEND_EMAIL_QUEUE_NAME:            deadletter.Wrap(config.SEND_EMAIL_QUEUE_NAME, outbox.Once(send_email.Handler{})),
+	config.DATA_EXPORT_QUEUE_NAME:           deadletter.Wrap(config.DATA_EXPORT_QUEUE_NAME, outbox.Once(data_export.Handler{})),
+	config.ORGANISATION_DELETION_QUEUE_NAME: deadletter.Wrap(config.ORGANISATION_DELETION_QUEUE_NAME, outbox.Once(organisation_deletion.Handler{})),
+	config.FILE_DELETION_QUEUE_NAME:         deadletter.Wrap(config.FILE_DELETION_QUEUE_NAME, outbox.Once(file_deletion.Handler{})),
 }